@@ -0,0 +1,89 @@
+package tagparser
+
+// Anonymize replaces the contents of identifiers and values in the tag with
+// same-shape placeholders, so that failing tags from proprietary code can be
+// shared in bug reports. Lower case ASCII letters become 'x', upper case ones
+// become 'X', digits become '0', and other non-ASCII bytes become 'x'.
+// Punctuation, whitespace, quotes, escapes and invisible characters are kept.
+//
+// Each distinct word (a run of letters, digits and non-ASCII bytes) gets its
+// own placeholder, so `foo,bar,foo` becomes `xxx,xxy,xxx`: repeated keys stay
+// repeated, and different ones stay different, as long as there are enough
+// placeholders of that shape.
+//
+// The replacement is byte-for-byte, so the structure of the tag and the exact
+// byte offsets of any syntax errors are preserved. The returned error is the
+// syntax error of the anonymized tag as reported by ParseFunc, if any.
+func Anonymize(tag string) (string, error) {
+	b := []byte(tag)
+	placeholders := make(map[string]string) // by word
+	shapes := make(map[string]int)          // number of words of each shape
+	for i := 0; i < len(b); {
+		j := i
+		for j < len(b) && isWordByte(tag, j) {
+			j++
+		}
+		if j == i {
+			if l := invisibleCharLen(tag[i:]); l > 0 {
+				i += l
+			} else {
+				i++
+			}
+			continue
+		}
+		word := tag[i:j]
+		p, ok := placeholders[word]
+		if !ok {
+			shape := wordShape(word)
+			p = placeholder(shape, shapes[shape])
+			shapes[shape]++
+			placeholders[word] = p
+		}
+		copy(b[i:], p)
+		i = j
+	}
+	result := string(b)
+	return result, ParseFunc(result, func(key, value string) error {
+		return nil
+	})
+}
+
+// isWordByte reports whether tag[i] is a part of a word for Anonymize.
+func isWordByte(tag string, i int) bool {
+	c := tag[i]
+	return isAlnum(c) || c >= 0x80 && invisibleCharLen(tag[i:]) == 0
+}
+
+// wordShape returns the first placeholder for words like w.
+func wordShape(w string) string {
+	b := []byte(w)
+	for i, c := range b {
+		switch {
+		case c >= 'A' && c <= 'Z':
+			b[i] = 'X'
+		case c >= '0' && c <= '9':
+			b[i] = '0'
+		default:
+			b[i] = 'x'
+		}
+	}
+	return string(b)
+}
+
+// placeholder returns the n-th placeholder of the given shape, counting the
+// letters from 'x' and digits from '0' in the trailing positions. It wraps
+// around once the shape runs out of placeholders.
+func placeholder(shape string, n int) string {
+	b := []byte(shape)
+	for i := len(b) - 1; i >= 0 && n > 0; i-- {
+		switch c := b[i]; c {
+		case '0':
+			b[i] = '0' + byte(n%10)
+			n /= 10
+		default:
+			b[i] = c - 'x' + 'a' + byte(('x'-'a'+n%26)%26)
+			n /= 26
+		}
+	}
+	return string(b)
+}
@@ -0,0 +1,37 @@
+package tagparser
+
+import "testing"
+
+func TestAnonymize(t *testing.T) {
+	var tests = []struct {
+		tag      string
+		expected string
+		error    string
+	}{
+		{``, ``, ``},
+		{`alfa,Bravo:42`, `xxxx,Xxxxx:00`, ``},
+		{`alfa:'bravo, charlie\'s'`, `xxxx:'xxxxx, xxxxxxx\'x'`, ``},
+		{"caf\u00E9:x", "xxxxx:x", ``},
+		{"alfa,bra\u200Bvo", "xxxx,xxx\u200Bxx", ``},
+		{`alfa,bravo:'charlie`, `xxxx,xxxxx:'xxxxxxx`, `unterminated quote (at 12)`},
+		{`a\lfa`, `x\xxx`, `invalid escape character (at 3)`},
+		{`alfa,:bravo`, `xxxx,:xxxxx`, `empty key (at 6)`},
+		{`foo,bar,foo:bar`, `xxx,xxy,xxx:xxy`, ``},
+		{`a1,b2,A1,Bc:10`, `x0,x1,X0,Xx:00`, ``},
+		{`a,b,c,d`, `x,y,z,a`, ``},
+		{`ab,cd,ef`, `xx,xy,xz`, ``},
+	}
+	for _, test := range tests {
+		actual, err := Anonymize(test.tag)
+		if actual != test.expected {
+			t.Errorf("** Anonymize(%q) = %q, wanted %q", test.tag, actual, test.expected)
+		}
+		if err != nil {
+			if ae := err.Error(); ae != test.error {
+				t.Errorf("** Anonymize(%q) error %q, wanted %q", test.tag, ae, test.error)
+			}
+		} else if test.error != "" {
+			t.Errorf("** Anonymize(%q) no error, wanted %q", test.tag, test.error)
+		}
+	}
+}
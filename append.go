@@ -0,0 +1,43 @@
+package tagparser
+
+import "strings"
+
+// AppendParse parses a tag like ParseFunc does, appending all unescaped keys
+// and values to dst and returning them as alternating key and value slices
+// into the resulting buffer, e.g. `foo,bar:boz` yields values
+// {"foo", "", "bar", "boz"}.
+//
+// AppendParse gives callers full control over allocations: if dst has spare
+// capacity of at least len(tag) bytes, the buffer is never reallocated, and
+// the only allocation is the values slice itself. The returned slices are
+// only valid until buf is modified.
+func AppendParse(dst []byte, tag string) (values [][]byte, buf []byte, err error) {
+	// Unescaped output is never longer than the input, so reserving len(tag)
+	// bytes upfront guarantees that the slices we return stay within buf.
+	if cap(dst)-len(dst) < len(tag) {
+		b := make([]byte, len(dst), len(dst)+len(tag))
+		copy(b, dst)
+		dst = b
+	}
+	buf = dst
+	if tag != "" {
+		values = make([][]byte, 0, 2*(strings.Count(tag, ",")+1))
+	}
+
+	s := scanner{tag: tag, conf: nameNone}
+	s.scan(func(it item) {
+		keyStart := len(buf)
+		buf = s.appendUnquote(buf, it.keyStart, it.keyEnd)
+		keyEnd := len(buf)
+		if it.hasValue {
+			buf = s.appendUnquote(buf, it.valueStart, it.valueEnd)
+		}
+		if keyStart == keyEnd {
+			s.fail(it.keyStart, CodeEmptyKey)
+			buf = buf[:keyStart]
+			return
+		}
+		values = append(values, buf[keyStart:keyEnd:keyEnd], buf[keyEnd:len(buf):len(buf)])
+	})
+	return values, buf, s.err
+}
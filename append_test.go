@@ -0,0 +1,71 @@
+package tagparser
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestAppendParse(t *testing.T) {
+	var tests = []struct {
+		testName string
+		tag      string
+		values   []string
+		buf      string
+		error    string
+	}{
+		{`empty`, ``, nil, ``, ``},
+		{`simple`, `alfa,bravo:charlie`, []string{"alfa", "", "bravo", "charlie"}, `alfabravocharlie`, ``},
+		{`escapes`, `alfa\,bravo:'charlie, delta'`, []string{"alfa,bravo", "charlie, delta"}, `alfa,bravocharlie, delta`, ``},
+		{`empty key`, `alfa,:bravo,charlie`, []string{"alfa", "", "charlie", ""}, `alfacharlie`, `empty key (at 6)`},
+		{`unterminated quote`, `alfa:'bravo`, []string{"alfa", "bravo"}, `alfabravo`, `unterminated quote (at 6)`},
+		{`invalid quote`, `alfa:bravo'charlie'`, []string{"alfa", "bravocharlie"}, `alfabravocharlie`, `invalid quote (at 11)`},
+	}
+	for _, test := range tests {
+		t.Run(test.testName, func(t *testing.T) {
+			values, buf, err := AppendParse(nil, test.tag)
+			if err != nil {
+				if ae := err.Error(); ae != test.error {
+					t.Errorf("** AppendParse(%q) error %q, wanted %q", test.tag, ae, test.error)
+				}
+			} else if test.error != "" {
+				t.Errorf("** AppendParse(%q) no error, wanted %q", test.tag, test.error)
+			}
+			var actual []string
+			for _, v := range values {
+				actual = append(actual, string(v))
+			}
+			if !reflect.DeepEqual(actual, test.values) {
+				t.Errorf("** AppendParse(%q) values = %q, wanted %q", test.tag, actual, test.values)
+			}
+			if string(buf) != test.buf {
+				t.Errorf("** AppendParse(%q) buf = %q, wanted %q", test.tag, buf, test.buf)
+			}
+		})
+	}
+}
+
+func TestAppendParse_reuses_buffer(t *testing.T) {
+	const tag = `alfa,bravo:'charlie, delta'`
+	dst := make([]byte, 0, 64)
+	dst = append(dst, "xyz"...)
+	values, buf, err := AppendParse(dst, tag)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if &buf[0] != &dst[0] {
+		t.Errorf("** buffer was reallocated")
+	}
+	if string(buf) != "xyzalfabravocharlie, delta" {
+		t.Errorf("** buf = %q", buf)
+	}
+	if &values[0][0] != &buf[3] {
+		t.Errorf("** values do not point into buf")
+	}
+
+	allocs := testing.AllocsPerRun(10, func() {
+		AppendParse(dst[:0], tag)
+	})
+	if allocs > 1 {
+		t.Errorf("** AppendParse allocates %v times, wanted at most 1", allocs)
+	}
+}
@@ -0,0 +1,158 @@
+// Package astscan finds struct field tags in Go syntax trees and checks them
+// with a tagparser.StructTagParser, reporting diagnostics at source
+// positions. It is meant for vet-style checkers of custom tag dialects.
+package astscan
+
+import (
+	"errors"
+	"go/ast"
+	"go/token"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/andreyvit/tagparser"
+)
+
+// Diagnostic is a problem found in a struct field tag.
+type Diagnostic struct {
+	// Pos is the source position of the problem within the tag literal.
+	Pos token.Pos
+	// Err is the parse error. Its Tag and Pos refer to the struct tag, and
+	// Info names the struct (empty for anonymous structs), the field and the
+	// namespace.
+	Err *tagparser.Error
+}
+
+// Format renders the diagnostic like go vet does, e.g.
+// `user.go:12:27: User.Email json: empty key [empty-key]`.
+func (d Diagnostic) Format(fset *token.FileSet) string {
+	// Reuse the go vet style of the error, replacing its tag-relative
+	// location with the source position.
+	e := *d.Err
+	e.Info = tagparser.ErrorInfo{}
+	msg := e.Format(tagparser.ErrorGoVet)
+	msg = msg[strings.Index(msg, ": ")+2:]
+	if info := d.Err.Info.String(); info != "" {
+		msg = info + ": " + msg
+	}
+	return fset.Position(d.Pos).String() + ": " + msg
+}
+
+// Check parses the tags of all struct fields within node, usually an
+// *ast.File, using the namespaces registered with p, and returns the
+// problems found in the order of their positions within the node. Like
+// StructTagParser.Parse, it reports one error per tag unless the namespace
+// configuration has CollectAllErrors set.
+func Check(node ast.Node, p *tagparser.StructTagParser) []Diagnostic {
+	var diags []Diagnostic
+	for _, field := range Fields(node) {
+		_, err := p.Parse(field.Tag)
+		var errs []*tagparser.Error
+		var list *tagparser.ErrorList
+		var e *tagparser.Error
+		if errors.As(err, &list) {
+			errs = list.Errors
+		} else if errors.As(err, &e) {
+			errs = []*tagparser.Error{e}
+		}
+		for _, e := range errs {
+			e.Info.Struct, e.Info.Field = field.Struct, field.Name
+			diags = append(diags, Diagnostic{field.Lit.Pos() + token.Pos(literalOffset(field.Lit.Value, e.Pos)), e})
+		}
+	}
+	return diags
+}
+
+// Field is a struct field with a tag.
+type Field struct {
+	// Struct is the name of the struct type, empty for anonymous structs.
+	Struct string
+	// Name is the name of the field, or the type name of an embedded field.
+	// Fields declared together, like A and B in `A, B int`, are named after
+	// the first one, since they share the tag.
+	Name string
+	// Tag is the unquoted struct tag.
+	Tag string
+	// Lit is the tag literal.
+	Lit *ast.BasicLit
+}
+
+// Fields returns the struct fields with tags within node, usually an
+// *ast.File, in the order of their positions, for tools that process the
+// tags in other ways than Check.
+func Fields(node ast.Node) []Field {
+	var fields []Field
+	names := make(map[*ast.StructType]string)
+	owners := make(map[*ast.Field]string)
+	ast.Inspect(node, func(n ast.Node) bool {
+		switch n := n.(type) {
+		case *ast.TypeSpec:
+			if st, ok := n.Type.(*ast.StructType); ok {
+				names[st] = n.Name.Name
+			}
+		case *ast.StructType:
+			for _, field := range n.Fields.List {
+				owners[field] = names[n]
+			}
+		case *ast.Field:
+			if n.Tag == nil {
+				break
+			}
+			tag, err := strconv.Unquote(n.Tag.Value)
+			if err != nil {
+				break // not produced by go/parser
+			}
+			fields = append(fields, Field{owners[n], fieldName(n), tag, n.Tag})
+		}
+		return true
+	})
+	return fields
+}
+
+// fieldName returns the name of the field, or the type name of an embedded
+// field.
+func fieldName(field *ast.Field) string {
+	if len(field.Names) > 0 {
+		return field.Names[0].Name
+	}
+	typ := field.Type
+	for {
+		switch t := typ.(type) {
+		case *ast.StarExpr:
+			typ = t.X
+		case *ast.SelectorExpr:
+			return t.Sel.Name
+		case *ast.IndexExpr:
+			typ = t.X
+		case *ast.IndexListExpr:
+			typ = t.X
+		case *ast.Ident:
+			return t.Name
+		default:
+			return ""
+		}
+	}
+}
+
+// literalOffset maps a byte position within the value of a Go string literal
+// to the corresponding position within the literal.
+func literalOffset(lit string, pos int) int {
+	if lit[0] == '`' {
+		return 1 + pos
+	}
+	rest := lit[1 : len(lit)-1]
+	for n := 0; len(rest) > 0; {
+		r, multibyte, tail, _ := strconv.UnquoteChar(rest, '"')
+		if multibyte {
+			n += utf8.RuneLen(r)
+		} else {
+			n++
+		}
+		if n > pos {
+			break
+		}
+		rest = tail
+	}
+	return len(lit) - 1 - len(rest)
+}
@@ -0,0 +1,100 @@
+package astscan
+
+import (
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"reflect"
+	"testing"
+
+	"github.com/andreyvit/tagparser"
+)
+
+const testSource = `package x
+
+type User struct {
+	Email   string ` + "`" + `json:"email,omitempty" db:"email,:x"` + "`" + `
+	Name    string "json:\"name,\u00e9,:x\""
+	Base    ` + "`" + `json:"base,a,a"` + "`" + `
+	*ext.Ptr ` + "`" + `db:"ptr,q:'"` + "`" + `
+	A, B    int ` + "`" + `validate:"min=2" json:"a"` + "`" + `
+	Plain   int
+	Nested  struct {
+		C int ` + "`" + `json:",:c"` + "`" + `
+	}
+	List[T] ` + "`" + `json` + "`" + `
+	*Pair[int, string] ` + "`" + `json:"pair,,:p"` + "`" + `
+}
+
+var _ = struct{ D int ` + "`" + `json:"d,:x"` + "`" + ` }{}
+`
+
+func TestCheck(t *testing.T) {
+	var p tagparser.StructTagParser
+	p.Register("json", tagparser.Configuration{NamePosition: tagparser.NameFirst})
+	p.RegisterSchema("db", tagparser.Configuration{NamePosition: tagparser.NameFirst, CollectAllErrors: true}, &tagparser.Schema{})
+
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, "user.go", testSource, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var actual []string
+	for _, d := range Check(file, &p) {
+		actual = append(actual, d.Format(fset))
+	}
+	expected := []string{
+		`user.go:4:51: User.Email db: empty key [empty-key]`,
+		`user.go:5:37: User.Name json: empty key [empty-key]`,
+		`user.go:6:24: User.Base json: a: duplicate option key [duplicate-key]`,
+		`user.go:7:20: User.Ptr db: q: unknown option key [unknown-key]`,
+		`user.go:7:22: User.Ptr db: unterminated quote [unterminated-quote]`,
+		`user.go:11:17: C json: empty key [empty-key]`,
+		`user.go:13:15: User.List: malformed struct tag [malformed-struct-tag]`,
+		`user.go:14:34: User.Pair json: empty key [empty-key]`,
+		`user.go:17:32: D json: empty key [empty-key]`,
+	}
+	if !reflect.DeepEqual(actual, expected) {
+		t.Errorf("** Check = %q, wanted %q", actual, expected)
+	}
+}
+
+func TestCheck_unusual_nodes(t *testing.T) {
+	var p tagparser.StructTagParser
+	p.Register("json", tagparser.Configuration{})
+	st := &ast.StructType{Fields: &ast.FieldList{List: []*ast.Field{
+		{Type: &ast.ArrayType{Elt: ast.NewIdent("int")}, Tag: &ast.BasicLit{Kind: token.STRING, Value: "`json:\":x\"`"}},
+		{Names: []*ast.Ident{ast.NewIdent("Bad")}, Tag: &ast.BasicLit{Kind: token.STRING, Value: "`json:\":x\""}},
+	}}}
+	diags := Check(st, &p)
+	if len(diags) != 1 || diags[0].Err.Info.Field != "" || diags[0].Pos != 7 {
+		t.Errorf("** Check = %+v, wanted one error in an unnamed field at 7", diags)
+	}
+}
+
+func TestFields(t *testing.T) {
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, "user.go", testSource, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var actual []string
+	for _, f := range Fields(file) {
+		actual = append(actual, fmt.Sprintf("%d %s.%s %s", fset.Position(f.Lit.Pos()).Line, f.Struct, f.Name, f.Tag))
+	}
+	expected := []string{
+		`4 User.Email json:"email,omitempty" db:"email,:x"`,
+		"5 User.Name json:\"name,\u00e9,:x\"",
+		`6 User.Base json:"base,a,a"`,
+		`7 User.Ptr db:"ptr,q:'"`,
+		`8 User.A validate:"min=2" json:"a"`,
+		`11 .C json:",:c"`,
+		`13 User.List json`,
+		`14 User.Pair json:"pair,,:p"`,
+		`17 .D json:"d,:x"`,
+	}
+	if !reflect.DeepEqual(actual, expected) {
+		t.Errorf("** Fields = %q, wanted %q", actual, expected)
+	}
+}
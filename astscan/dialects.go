@@ -0,0 +1,59 @@
+package astscan
+
+import (
+	"go/ast"
+	"strconv"
+	"strings"
+
+	"github.com/andreyvit/tagparser"
+)
+
+// Dialect is a tag namespace and the syntax of its tags.
+type Dialect struct {
+	Namespace string
+	Conf      tagparser.Configuration
+}
+
+// ImportDialects returns the dialects implied by importing the packages that
+// read them, keyed by import path, for use with Detect. A key also matches
+// the subpackages and major versions of the package, like
+// github.com/go-playground/validator/v10. Each call returns a new map, so
+// adjust it as needed.
+func ImportDialects() map[string]Dialect {
+	gorm := Dialect{"gorm", tagparser.Gorm()}
+	validate := Dialect{"validate", tagparser.Validator()}
+	protobuf := Dialect{"protobuf", tagparser.Protobuf()}
+	return map[string]Dialect{
+		"encoding/json":                       {"json", tagparser.JSON()},
+		"encoding/xml":                        {"xml", tagparser.XML()},
+		"gorm.io/gorm":                        gorm,
+		"github.com/jinzhu/gorm":              gorm,
+		"github.com/go-playground/validator":  validate,
+		"gopkg.in/go-playground/validator.v8": validate,
+		"gopkg.in/go-playground/validator.v9": validate,
+		"github.com/vmihailenco/msgpack":      {"msgpack", tagparser.VMihailenco()},
+		"google.golang.org/protobuf":          protobuf,
+		"github.com/golang/protobuf":          protobuf,
+	}
+}
+
+// Detect returns a parser for the tags of file that knows the namespaces of
+// the dialects implied by its imports, and the namespaces of overrides, which
+// take precedence. This saves mapping namespaces to dialects by hand when
+// auditing code that uses several of them, although models declared apart
+// from the code using them need overrides.
+func Detect(file *ast.File, dialects map[string]Dialect, overrides map[string]tagparser.Configuration) *tagparser.StructTagParser {
+	var p tagparser.StructTagParser
+	for _, spec := range file.Imports {
+		path, _ := strconv.Unquote(spec.Path.Value)
+		for prefix, d := range dialects {
+			if path == prefix || strings.HasPrefix(path, prefix+"/") {
+				p.Register(d.Namespace, d.Conf)
+			}
+		}
+	}
+	for namespace, conf := range overrides {
+		p.Register(namespace, conf)
+	}
+	return &p
+}
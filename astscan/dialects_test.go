@@ -0,0 +1,63 @@
+package astscan
+
+import (
+	"go/parser"
+	"go/token"
+	"reflect"
+	"testing"
+
+	"github.com/andreyvit/tagparser"
+)
+
+func TestDetect(t *testing.T) {
+	const source = `package x
+
+import (
+	"encoding/json"
+	"github.com/go-playground/validator/v10"
+	"gorm.io/gorm/clause"
+	"gorm.io/gormx"
+)
+
+type User struct {
+	Email string ` + "`" + `json:"email,'" gorm:"size:10;SIZE:20" validate:"required,required" xml:"a,'"` + "`" + `
+}
+`
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, "user.go", source, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	gorm := tagparser.Gorm()
+	gorm.DuplicateKeys, gorm.CollectAllErrors = tagparser.DuplicateKeysError, true
+	xml := tagparser.XML()
+	xml.CollectAllErrors = true
+	var tests = []struct {
+		overrides map[string]tagparser.Configuration
+		expected  []string
+	}{
+		{nil, []string{
+			`user.go:11:28: User.Email json: unterminated quote [unterminated-quote]`,
+		}},
+		{map[string]tagparser.Configuration{"gorm": gorm, "xml": xml}, []string{
+			`user.go:11:28: User.Email json: unterminated quote [unterminated-quote]`,
+			`user.go:11:45: User.Email gorm: size: duplicate option key differing only in case: "size" (at 1) and "SIZE" (at 9) [duplicate-key-case]`,
+			`user.go:11:90: User.Email xml: unterminated quote [unterminated-quote]`,
+		}},
+	}
+	dialects := ImportDialects()
+	for name, d := range dialects {
+		d.Conf.CollectAllErrors = true
+		dialects[name] = d
+	}
+	for _, test := range tests {
+		p := Detect(file, dialects, test.overrides)
+		var actual []string
+		for _, d := range Check(file, p) {
+			actual = append(actual, d.Format(fset))
+		}
+		if !reflect.DeepEqual(actual, test.expected) {
+			t.Errorf("** Detect(%v) found %q, wanted %q", test.overrides, actual, test.expected)
+		}
+	}
+}
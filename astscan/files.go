@@ -0,0 +1,32 @@
+package astscan
+
+import (
+	"io/fs"
+	"path/filepath"
+	"strings"
+)
+
+// Files returns the Go files at path in lexical order, following the
+// conventions of the go command: path is a Go file, a directory, or a
+// directory followed by /... to include its subdirectories, skipping
+// testdata, vendor and hidden ones. A file named explicitly is returned
+// even without the .go extension. On error, Files returns the files found
+// so far along with it.
+func Files(path string) ([]string, error) {
+	root, recursive := strings.CutSuffix(path, "/...")
+	var files []string
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			if name := d.Name(); path != root && (!recursive || name == "testdata" || name == "vendor" || strings.HasPrefix(name, ".") || strings.HasPrefix(name, "_")) {
+				return filepath.SkipDir
+			}
+		} else if path == root || strings.HasSuffix(path, ".go") {
+			files = append(files, path)
+		}
+		return nil
+	})
+	return files, err
+}
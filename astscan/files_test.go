@@ -0,0 +1,44 @@
+package astscan
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestFiles(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"a.go", "notes.txt", "sub/b.go", "sub/deep/c.go", "testdata/d.go", "vendor/e.go", ".hidden/f.go", "_tmp/g.go"} {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, nil, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var tests = []struct {
+		path  string
+		files []string
+		error string
+	}{
+		{dir, []string{"a.go"}, ""},
+		{dir + "/...", []string{"a.go", "sub/b.go", "sub/deep/c.go"}, ""},
+		{filepath.Join(dir, "sub") + "/...", []string{"sub/b.go", "sub/deep/c.go"}, ""},
+		{filepath.Join(dir, "notes.txt"), []string{"notes.txt"}, ""},
+		{filepath.Join(dir, "missing"), nil, "no such file or directory"},
+	}
+	for _, test := range tests {
+		files, err := Files(test.path)
+		var actual []string
+		for _, f := range files {
+			actual = append(actual, filepath.ToSlash(strings.TrimPrefix(f, dir+string(filepath.Separator))))
+		}
+		if !reflect.DeepEqual(actual, test.files) || test.error == "" && err != nil || test.error != "" && (err == nil || !strings.Contains(err.Error(), test.error)) {
+			t.Errorf("** Files(%q) = %q, %v, wanted %q, %s", test.path, actual, err, test.files, test.error)
+		}
+	}
+}
@@ -0,0 +1,258 @@
+package tagparser
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// ErrUnrepresentable is returned by Build when a name or option cannot be
+// written in the dialect of the configuration so that it parses back as is.
+var ErrUnrepresentable = errors.New("cannot be represented in the tag")
+
+// Build is the inverse of Parse: it returns a tag with the given name and
+// options that parses back into them with the same configuration. Options are
+// written in the order of keys. See BuildOrdered for details.
+func (conf Configuration) Build(name string, opts map[string]string) (string, error) {
+	keys := make([]string, 0, len(opts))
+	for k := range opts {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	ordered := make([]Option, len(keys))
+	for i, k := range keys {
+		ordered[i] = Option{k, opts[k]}
+	}
+	return conf.BuildOrdered(name, ordered)
+}
+
+// BuildOrdered is like Build, but writes the options in the given order, as
+// returned by ParseOrdered.
+//
+// Keys, values and names are written bare when possible, and quoted or
+// escaped otherwise. Options with empty values are written as bare keys. The
+// error, if any, wraps ErrUnrepresentable: keys must not be empty, equal to
+// ContinuationKey, contain invisible characters or, with FoldKeys, upper case
+// letters, or be changed by KeyNormalizer; a name requires NameFirst or
+// NameLast, and NameLast names cannot follow values with GreedyLastValue;
+// keys must not be KeyAliases aliases of other keys, since they would parse
+// back as those keys.
+//
+// The tag is written on a single line. With Multiline, Dedent would turn
+// newlines within keys, values and names into spaces, so quoted text has
+// them written as \n, which requires AllowStandardEscapes; otherwise, or
+// when the text cannot be quoted, they are an error.
+func (conf Configuration) BuildOrdered(name string, opts []Option) (string, error) {
+	items := make([]buildItem, len(opts))
+	for i, o := range opts {
+		items[i].Option = o
+	}
+	return conf.build(name, items)
+}
+
+// buildItem is an option to write, possibly a directive, negated flag or list
+// value as reported by the parser.
+type buildItem struct {
+	Option
+	isDirective, isNegated, isList bool
+}
+
+func (conf *Configuration) build(name string, items []buildItem) (string, error) {
+	kvSep, itemSep := conf.separators()
+	var b strings.Builder
+	if name != "" && conf.NamePosition == NameNone {
+		return "", fmt.Errorf("name %q: %w without NamePosition", name, ErrUnrepresentable)
+	}
+	if conf.NamePosition == NameFirst && (name != "" || len(items) > 0) {
+		if err := conf.writeName(&b, name); err != nil {
+			return "", err
+		}
+		if len(items) > 0 {
+			b.WriteByte(itemSep)
+		}
+	}
+	var hasValues bool
+	for i, it := range items {
+		if err := conf.checkKey(it.Key); err != nil {
+			return "", err
+		}
+		if !it.isDirective {
+			if alias, ok := conf.lookupKey(conf.KeyAliases, it.Key); ok && conf.foldKey(alias) != it.Key {
+				return "", fmt.Errorf("key %q: %w, it is an alias of %q", it.Key, ErrUnrepresentable, alias)
+			}
+		}
+		escaped := it.isDirective || it.isNegated
+		if err := conf.checkMultiline("key", it.Key, !escaped); err != nil {
+			return "", err
+		}
+		if err := conf.checkMultiline("value", it.Value, true); err != nil {
+			return "", err
+		}
+		if i > 0 {
+			b.WriteByte(itemSep)
+		}
+		key := it.Key
+		switch {
+		case it.isDirective:
+			b.WriteByte(conf.DirectivePrefix)
+		case it.isNegated:
+			b.WriteByte(conf.NegationPrefix)
+		}
+		if !it.isDirective && conf.StripKeyPrefix != "" && strings.HasPrefix(key, conf.StripKeyPrefix) {
+			key = conf.StripKeyPrefix + key
+		}
+		if escaped {
+			// A quote cannot follow the prefix. The parser has accepted
+			// the escapes of such keys, so they are escapable.
+			conf.writeEscaped(&b, key)
+		} else {
+			conf.writeText(&b, key, true)
+		}
+		if it.isNegated {
+			continue
+		}
+		// With GreedyLastValue, a bare key after a value would become a part
+		// of that value.
+		if it.Value != "" || conf.GreedyLastValue && hasValues {
+			b.WriteByte(kvSep)
+			value := it.Value
+			if it.isList {
+				b.WriteString(value)
+			} else {
+				if conf.PercentDecodeValues {
+					value = strings.ReplaceAll(value, "%", "%25")
+				}
+				conf.writeText(&b, value, false)
+			}
+			hasValues = true
+		}
+	}
+	if conf.NamePosition == NameLast && (name != "" || len(items) > 0) {
+		if hasValues && conf.GreedyLastValue {
+			return "", fmt.Errorf("name %q: %w after values with GreedyLastValue", name, ErrUnrepresentable)
+		}
+		if len(items) > 0 {
+			b.WriteByte(itemSep)
+		}
+		if err := conf.writeName(&b, name); err != nil {
+			return "", err
+		}
+	}
+	return b.String(), nil
+}
+
+func (conf *Configuration) checkKey(key string) error {
+	switch {
+	case key == "":
+		return fmt.Errorf("empty key: %w", ErrUnrepresentable)
+	case key == conf.ContinuationKey:
+		return fmt.Errorf("key %q: %w, it is the ContinuationKey", key, ErrUnrepresentable)
+	case conf.FoldKeys && FoldKey(key) != key:
+		return fmt.Errorf("key %q: %w with FoldKeys", key, ErrUnrepresentable)
+	case conf.KeyNormalizer != nil && conf.KeyNormalizer(key) != key:
+		return fmt.Errorf("key %q: %w with KeyNormalizer", key, ErrUnrepresentable)
+	case hasInvisible(key):
+		return fmt.Errorf("key %q: %w, it contains invisible characters", key, ErrUnrepresentable)
+	}
+	return nil
+}
+
+// checkMultiline returns an error if s contains a newline that cannot be
+// written with Multiline. Quotable text can have it as \n with
+// AllowStandardEscapes.
+func (conf *Configuration) checkMultiline(what, s string, quotable bool) error {
+	if conf.Multiline && strings.IndexByte(s, '\n') >= 0 && !(quotable && conf.AllowStandardEscapes) {
+		return fmt.Errorf("%s %q: %w, it contains a newline with Multiline", what, s, ErrUnrepresentable)
+	}
+	return nil
+}
+
+func (conf *Configuration) writeName(b *strings.Builder, name string) error {
+	if err := conf.checkMultiline("name", name, conf.QuotedNames != QuotedNamesError); err != nil {
+		return err
+	}
+	if conf.QuotedNames != QuotedNamesError || !conf.needsQuoting(name, true) {
+		conf.writeText(b, name, true)
+		return nil
+	}
+	for i := 0; i < len(name); i++ {
+		c := name[i]
+		if conf.needsEscape(name, i, true) && conf.EscapableChars != "" && strings.IndexByte(conf.EscapableChars, c) < 0 {
+			return fmt.Errorf("name %q: %w, %q is not escapable and names cannot be quoted", name, ErrUnrepresentable, c)
+		}
+	}
+	conf.writeEscaped(b, name)
+	return nil
+}
+
+// writeEscaped writes a key or name with backslashes instead of quotes.
+func (conf *Configuration) writeEscaped(b *strings.Builder, s string) {
+	for i := 0; i < len(s); i++ {
+		if conf.needsEscape(s, i, true) {
+			b.WriteByte('\\')
+		}
+		b.WriteByte(s[i])
+	}
+}
+
+// writeText writes a key, value or name, quoting it if necessary.
+func (conf *Configuration) writeText(b *strings.Builder, s string, isKey bool) {
+	if !conf.needsQuoting(s, isKey) {
+		b.WriteString(s)
+		return
+	}
+	quote := conf.quotes()[0]
+	b.WriteByte(quote)
+	for i := 0; i < len(s); i++ {
+		switch c := s[i]; {
+		case c == '\n' && conf.Multiline:
+			b.WriteString(`\n`)
+			continue
+		case c == quote || c == '\\':
+			b.WriteByte('\\')
+		}
+		b.WriteByte(s[i])
+	}
+	b.WriteByte(quote)
+}
+
+func (conf *Configuration) needsQuoting(s string, isKey bool) bool {
+	for i := 0; i < len(s); i++ {
+		if conf.needsEscape(s, i, isKey) {
+			return true
+		}
+	}
+	return false
+}
+
+// needsEscape reports whether s[i] has to be escaped or quoted to be read
+// literally. Whitespace only needs it at the ends of s, including Unicode
+// whitespace with UnicodeWhitespace, the directive and negation prefixes at
+// the start of a key or name, and with ListValues, an opening bracket at the
+// start of a value. Newlines always need it with Multiline. For a multi-byte
+// rune, it reports on its first byte.
+func (conf *Configuration) needsEscape(s string, i int, isKey bool) bool {
+	if conf.Multiline && s[i] == '\n' {
+		return true
+	}
+	if conf.UnicodeWhitespace && s[i] >= utf8.RuneSelf {
+		r, n := utf8.DecodeRuneInString(s[i:])
+		if unicode.IsSpace(r) && (i == 0 || i+n == len(s)) {
+			return true
+		}
+	}
+	class := conf.Classify(s[i])
+	if class&CharSpace != 0 && i > 0 && i < len(s)-1 {
+		class &^= CharSpace
+	}
+	if i > 0 || !isKey {
+		class &^= CharDirectivePrefix | CharNegationPrefix
+	}
+	if i > 0 || isKey {
+		class &^= CharListBracket
+	}
+	return class != 0
+}
@@ -0,0 +1,103 @@
+package tagparser
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestConfiguration_Build(t *testing.T) {
+	nameFirst := Configuration{NamePosition: NameFirst}
+	var tests = []struct {
+		conf     Configuration
+		name     string
+		opts     map[string]string
+		expected string
+	}{
+		{Configuration{}, "", nil, ``},
+		{Configuration{}, "", M{"b": "", "a": "1"}, `a:1,b`},
+		{Configuration{}, "", M{"a": "x, y", "b": "it's", "c": ` x `, "d": `a\b`, "e": "a b", "f": "a:b"}, `a:'x, y',b:'it\'s',c:' x ',d:'a\\b',e:a b,f:'a:b'`},
+		{Configuration{}, "", M{"a b": "", " a": "", "a:b": "", "#a": ""}, `' a',#a,a b,'a:b'`},
+		{Configuration{QuoteChars: "\"'"}, "", M{"a": `x, "y"`, "b": "it's"}, `a:"x, \"y\"",b:"it's"`},
+		{nameFirst, "", nil, ``},
+		{nameFirst, "name", nil, `name`},
+		{nameFirst, "", M{"a": "1"}, `,a:1`},
+		{nameFirst, "my name", M{"a": ""}, `my name,a`},
+		{nameFirst, "a:b", nil, `'a:b'`},
+		{nameFirst, "a\u200b\u00a0", M{"b": "\u00a0c\u200b"}, "a\u200b\u00a0,b:\u00a0c\u200b"},
+		{Configuration{NamePosition: NameFirst, QuotedNames: QuotedNamesError}, " a:b,'c ", nil, `\ a\:b\,\'c\ `},
+		{Configuration{NamePosition: NameLast}, "name", M{"a": "1"}, `a:1,name`},
+		{Configuration{NamePosition: NameLast}, "", M{"a": "1"}, `a:1,`},
+		{Configuration{NamePosition: NameLast}, "name", nil, `name`},
+		{Configuration{DirectivePrefix: '#'}, "", M{"#a": "#"}, `'#a':#`},
+		{Configuration{NegationPrefix: '!'}, "", M{"!a": "!"}, `'!a':!`},
+		{Configuration{NamePosition: NameFirst, DirectivePrefix: '#'}, "#a", nil, `'#a'`},
+		{Configuration{KeyValueSeparator: '=', ItemSeparator: ';'}, "", M{"a": "x;y", "b": "c:d,e"}, `a='x;y';b=c:d,e`},
+		{Configuration{GreedyLastValue: true}, "", M{"a": "x", "b": "", "c": "1,2"}, `a:x,b:,c:'1,2'`},
+		{Configuration{PercentDecodeValues: true}, "", M{"a": "50%"}, `a:50%25`},
+		{Configuration{StripKeyPrefix: "x-"}, "", M{"a": "", "x-b": ""}, `a,x-x-b`},
+		{Configuration{FoldKeys: true}, "", M{"a": ""}, `a`},
+		{Configuration{ListValues: true}, "", M{"a": "[x", "b": "x[y]", "[c": "[]"}, `[c:'[]',a:'[x',b:x[y]`},
+		{Configuration{KeyAliases: M{"pk": "primaryKey", "id": "id"}}, "", M{"primaryKey": "", "id": ""}, `id,primaryKey`},
+		{Configuration{Multiline: true, AllowStandardEscapes: true}, "", M{"a": "x\n  y", "b\nc": ""}, `a:'x\n  y','b\nc'`},
+		{Configuration{UnicodeWhitespace: true}, "", M{"a": "\u00a0x", "b": "x\u3000", "c": "x\u3000y"}, "a:'\u00a0x',b:'x\u3000',c:x\u3000y"},
+		{Configuration{NamePosition: NameFirst, QuotedNames: QuotedNamesError, UnicodeWhitespace: true}, "\u3000x\u2003", nil, "\\\u3000x\\\u2003"},
+	}
+	for _, test := range tests {
+		actual, err := test.conf.Build(test.name, test.opts)
+		if err != nil {
+			t.Errorf("** Build(%q, %v) error: %v", test.name, test.opts, err)
+			continue
+		}
+		if actual != test.expected {
+			t.Errorf("** Build(%q, %v) = %s, wanted %s", test.name, test.opts, actual, test.expected)
+		}
+		name, opts, err := test.conf.Parse(actual)
+		if err != nil || name != test.name || !reflect.DeepEqual(opts, test.opts) && len(opts)+len(test.opts) > 0 {
+			t.Errorf("** Parse(%s) = %q, %v, %v, wanted %q, %v", actual, name, opts, err, test.name, test.opts)
+		}
+	}
+}
+
+func TestConfiguration_BuildOrdered(t *testing.T) {
+	conf := Configuration{NamePosition: NameFirst}
+	opts := []Option{{"z", "1"}, {"a", ""}}
+	actual, err := conf.BuildOrdered("n", opts)
+	if expected := `n,z:1,a`; err != nil || actual != expected {
+		t.Errorf("** BuildOrdered = %s, %v, wanted %s", actual, err, expected)
+	}
+	name, parsed, err := conf.ParseOrdered(actual)
+	if err != nil || name != "n" || !reflect.DeepEqual(parsed, opts) {
+		t.Errorf("** ParseOrdered(%s) = %q, %v, %v", actual, name, parsed, err)
+	}
+}
+
+func TestConfiguration_Build_errors(t *testing.T) {
+	var tests = []struct {
+		conf  Configuration
+		name  string
+		opts  map[string]string
+		error string
+	}{
+		{Configuration{}, "n", nil, `name "n": cannot be represented in the tag without NamePosition`},
+		{Configuration{}, "", M{"": "x"}, `empty key: cannot be represented in the tag`},
+		{Configuration{ContinuationKey: "+"}, "", M{"+": "x"}, `key "+": cannot be represented in the tag, it is the ContinuationKey`},
+		{Configuration{FoldKeys: true}, "", M{"A": ""}, `key "A": cannot be represented in the tag with FoldKeys`},
+		{Configuration{}, "", M{"a\u200b": ""}, `key "a\u200b": cannot be represented in the tag, it contains invisible characters`},
+		{Configuration{NamePosition: NameFirst}, "n", M{"\u200b": ""}, `key "\u200b": cannot be represented in the tag, it contains invisible characters`},
+		{Configuration{NamePosition: NameLast, GreedyLastValue: true}, "n", M{"a": "1"}, `name "n": cannot be represented in the tag after values with GreedyLastValue`},
+		{Configuration{KeyAliases: M{"pk": "primaryKey"}}, "", M{"pk": ""}, `key "pk": cannot be represented in the tag, it is an alias of "primaryKey"`},
+		{Configuration{FoldKeys: true, KeyAliases: M{"PK": "primaryKey"}}, "", M{"pk": ""}, `key "pk": cannot be represented in the tag, it is an alias of "primaryKey"`},
+		{Configuration{Multiline: true}, "", M{"a": "x\n  y"}, `value "x\n  y": cannot be represented in the tag, it contains a newline with Multiline`},
+		{Configuration{Multiline: true}, "", M{"a\nb": ""}, `key "a\nb": cannot be represented in the tag, it contains a newline with Multiline`},
+		{Configuration{Multiline: true, AllowStandardEscapes: true, NamePosition: NameFirst, QuotedNames: QuotedNamesError}, "a\nb", nil, `name "a\nb": cannot be represented in the tag, it contains a newline with Multiline`},
+		{Configuration{NamePosition: NameFirst, QuotedNames: QuotedNamesError, EscapableChars: `,`}, "a:b", nil, `name "a:b": cannot be represented in the tag, ':' is not escapable and names cannot be quoted`},
+		{Configuration{NamePosition: NameLast, QuotedNames: QuotedNamesError, EscapableChars: `,`}, "a:b", M{"a": "1"}, `name "a:b": cannot be represented in the tag, ':' is not escapable and names cannot be quoted`},
+	}
+	for _, test := range tests {
+		_, err := test.conf.Build(test.name, test.opts)
+		if err == nil || err.Error() != test.error || !errors.Is(err, ErrUnrepresentable) {
+			t.Errorf("** Build(%q, %v) error %v, wanted %s", test.name, test.opts, err, test.error)
+		}
+	}
+}
@@ -0,0 +1,120 @@
+package tagparser
+
+import (
+	"container/list"
+	"sync"
+)
+
+// Cache is a memory-bounded LRU cache of parse results keyed by the raw tag
+// string, for workloads that repeatedly parse identical tags coming from
+// external sources rather than from struct types. It is safe for concurrent
+// use.
+//
+// The returned option maps are shared between callers and must not be
+// modified.
+type Cache struct {
+	maxEntries int
+	maxBytes   int
+
+	mu      sync.Mutex
+	lru     list.List // of *cacheEntry, most recently used first
+	entries map[cacheKey]*list.Element
+	stats   CacheStats
+}
+
+// CacheStats describes the state and effectiveness of a Cache.
+type CacheStats struct {
+	Hits      uint64
+	Misses    uint64
+	Evictions uint64
+
+	// Entries is the current number of cached results.
+	Entries int
+	// Bytes is the estimated memory used by the cached results.
+	Bytes int
+}
+
+type cacheKey struct {
+	conf *Configuration
+	tag  string
+}
+
+type cacheEntry struct {
+	key   cacheKey
+	name  string
+	opts  map[string]string
+	err   error
+	bytes int
+}
+
+// Approximate per-entry and per-option overhead of the cache bookkeeping and
+// the option map, used for memory estimates.
+const (
+	cacheEntryOverhead  = 160
+	cacheOptionOverhead = 48
+)
+
+// NewCache returns a cache holding at most maxEntries results and at most
+// (approximately) maxBytes bytes of results. Zero or negative limits mean
+// no limit.
+func NewCache(maxEntries, maxBytes int) *Cache {
+	return &Cache{
+		maxEntries: maxEntries,
+		maxBytes:   maxBytes,
+		entries:    make(map[cacheKey]*list.Element),
+	}
+}
+
+// ParseString returns the cached result of conf.Parse(tag), parsing the tag
+// on a cache miss. Results are keyed by the conf pointer, so pass the same
+// *Configuration every time (e.g. a package-level variable).
+func (c *Cache) ParseString(conf *Configuration, tag string) (name string, opts map[string]string, err error) {
+	key := cacheKey{conf, tag}
+	c.mu.Lock()
+	if elem, ok := c.entries[key]; ok {
+		c.lru.MoveToFront(elem)
+		c.stats.Hits++
+		e := elem.Value.(*cacheEntry)
+		c.mu.Unlock()
+		return e.name, e.opts, e.err
+	}
+	c.stats.Misses++
+	c.mu.Unlock()
+
+	name, opts, err = conf.Parse(tag)
+
+	e := &cacheEntry{key: key, name: name, opts: opts, err: err}
+	e.bytes = cacheEntryOverhead + len(tag) + len(name) + len(opts)*cacheOptionOverhead
+	for k, v := range opts {
+		e.bytes += len(k) + len(v)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if _, ok := c.entries[key]; !ok { // might have been added concurrently
+		c.entries[key] = c.lru.PushFront(e)
+		c.stats.Entries++
+		c.stats.Bytes += e.bytes
+		c.evict()
+	}
+	return name, opts, err
+}
+
+// evict removes least recently used entries until the cache is within limits.
+// The most recent entry is always kept.
+func (c *Cache) evict() {
+	for c.stats.Entries > 1 && (c.maxEntries > 0 && c.stats.Entries > c.maxEntries || c.maxBytes > 0 && c.stats.Bytes > c.maxBytes) {
+		e := c.lru.Remove(c.lru.Back()).(*cacheEntry)
+		delete(c.entries, e.key)
+		c.stats.Entries--
+		c.stats.Bytes -= e.bytes
+		c.stats.Evictions++
+	}
+}
+
+// Stats returns the current statistics of the cache.
+func (c *Cache) Stats() CacheStats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.stats
+}
@@ -0,0 +1,97 @@
+package tagparser
+
+import (
+	"fmt"
+	"reflect"
+	"sync"
+	"testing"
+)
+
+func TestCache_ParseString(t *testing.T) {
+	conf := &Configuration{NamePosition: NameFirst}
+	c := NewCache(0, 0)
+	for i := 0; i < 3; i++ {
+		name, opts, err := c.ParseString(conf, `alfa,bravo:charlie`)
+		if name != "alfa" || !reflect.DeepEqual(opts, M{"bravo": "charlie"}) || err != nil {
+			t.Fatalf("** ParseString = %q, %q, %v", name, opts, err)
+		}
+	}
+	_, _, err := c.ParseString(conf, `alfa,:bravo`)
+	if err == nil || err.Error() != `empty key (at 6)` {
+		t.Errorf("** err = %v", err)
+	}
+	_, _, err = c.ParseString(conf, `alfa,:bravo`)
+	if err == nil || err.Error() != `empty key (at 6)` {
+		t.Errorf("** cached err = %v", err)
+	}
+
+	// different configuration is a different key
+	name, _, _ := c.ParseString(&Configuration{}, `alfa,bravo:charlie`)
+	if name != "" {
+		t.Errorf("** name = %q, wanted empty", name)
+	}
+
+	stats := c.Stats()
+	if stats.Hits != 3 || stats.Misses != 3 || stats.Entries != 3 || stats.Evictions != 0 {
+		t.Errorf("** stats = %+v", stats)
+	}
+	if expected := 3*cacheEntryOverhead + 18 + 4 + cacheOptionOverhead + 12 + 11 + 4 + 18 + 2*cacheOptionOverhead + 16; stats.Bytes != expected {
+		t.Errorf("** stats.Bytes = %d, wanted %d", stats.Bytes, expected)
+	}
+}
+
+func TestCache_max_entries(t *testing.T) {
+	conf := &Configuration{}
+	c := NewCache(2, 0)
+	c.ParseString(conf, `a`)
+	c.ParseString(conf, `b`)
+	c.ParseString(conf, `a`) // a is now most recently used
+	c.ParseString(conf, `c`) // evicts b
+	c.ParseString(conf, `a`)
+	c.ParseString(conf, `b`)
+	stats := c.Stats()
+	if stats.Hits != 2 || stats.Misses != 4 || stats.Entries != 2 || stats.Evictions != 2 {
+		t.Errorf("** stats = %+v", stats)
+	}
+}
+
+func TestCache_max_bytes(t *testing.T) {
+	conf := &Configuration{}
+	c := NewCache(0, 2*cacheEntryOverhead+100)
+	c.ParseString(conf, `a`)
+	c.ParseString(conf, `b`)
+	c.ParseString(conf, `c`)
+	if stats := c.Stats(); stats.Entries != 2 || stats.Evictions != 1 {
+		t.Errorf("** stats = %+v", stats)
+	}
+
+	// an entry larger than the limit is still kept, alone
+	c.ParseString(conf, fmt.Sprintf("%0200d", 0))
+	if stats := c.Stats(); stats.Entries != 1 || stats.Evictions != 3 {
+		t.Errorf("** stats = %+v", stats)
+	}
+}
+
+func TestCache_concurrent(t *testing.T) {
+	conf := &Configuration{}
+	c := NewCache(10, 0)
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				tag := fmt.Sprintf("k%d:%d", j%20, j%3)
+				_, opts, err := c.ParseString(conf, tag)
+				if err != nil || len(opts) != 1 {
+					t.Errorf("** ParseString(%q) = %q, %v", tag, opts, err)
+				}
+			}
+		}(i)
+	}
+	wg.Wait()
+	stats := c.Stats()
+	if stats.Hits+stats.Misses != 800 || stats.Entries > 10 {
+		t.Errorf("** stats = %+v", stats)
+	}
+}
@@ -0,0 +1,127 @@
+package tagparser
+
+import (
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Case is a naming convention that serializers apply when deriving names
+// from Go field names, e.g. for tags like `*,camel` or `case:snake`.
+type Case int
+
+const (
+	// CaseNone leaves names unchanged.
+	CaseNone Case = iota
+	// CamelCase converts names like `user_id` to `userId`.
+	CamelCase
+	// PascalCase converts names like `user_id` to `UserId`.
+	PascalCase
+	// SnakeCase converts names like `UserID` to `user_id`.
+	SnakeCase
+	// KebabCase converts names like `UserID` to `user-id`.
+	KebabCase
+)
+
+var caseNames = [...]string{
+	CaseNone:   "none",
+	CamelCase:  "camel",
+	PascalCase: "pascal",
+	SnakeCase:  "snake",
+	KebabCase:  "kebab",
+}
+
+// String returns the name of the case as accepted by ParseCase.
+func (c Case) String() string {
+	if c >= 0 && int(c) < len(caseNames) {
+		return caseNames[c]
+	}
+	return "Case(" + strconv.Itoa(int(c)) + ")"
+}
+
+// ParseCase recognizes a casing directive like `camel`, `pascal`, `snake`,
+// `kebab` or `none`, ignoring ASCII case.
+func ParseCase(s string) (Case, bool) {
+	s = FoldKey(s)
+	for c, name := range caseNames {
+		if s == name {
+			return Case(c), true
+		}
+	}
+	return CaseNone, false
+}
+
+// ApplyCase converts the name to the given case. Words are delimited by
+// underscores, dashes, spaces, and changes of letter case, with runs of upper
+// case letters treated as a single word (`HTTPServer` is `http_server` in
+// snake case), and digits sticking to the preceding word.
+func ApplyCase(name string, c Case) string {
+	if c == CaseNone {
+		return name
+	}
+	words := splitWords(name)
+	var b strings.Builder
+	b.Grow(len(name) + len(words))
+	for i, w := range words {
+		switch c {
+		case SnakeCase, KebabCase:
+			if i > 0 {
+				if c == SnakeCase {
+					b.WriteByte('_')
+				} else {
+					b.WriteByte('-')
+				}
+			}
+			b.WriteString(strings.ToLower(w))
+		case CamelCase, PascalCase:
+			if i == 0 && c == CamelCase {
+				b.WriteString(strings.ToLower(w))
+			} else {
+				r, size := utf8.DecodeRuneInString(w)
+				b.WriteRune(unicode.ToUpper(r))
+				b.WriteString(strings.ToLower(w[size:]))
+			}
+		}
+	}
+	return b.String()
+}
+
+// splitWords splits a name into words for ApplyCase.
+func splitWords(name string) []string {
+	var words []string
+	start := -1
+	var prev rune
+	for i, r := range name {
+		if r == '_' || r == '-' || r == ' ' {
+			if start >= 0 {
+				words = append(words, name[start:i])
+				start = -1
+			}
+			continue
+		}
+		if start >= 0 && unicode.IsUpper(r) {
+			if unicode.IsLower(prev) || unicode.IsDigit(prev) {
+				// userId, v2Name
+				words = append(words, name[start:i])
+				start = i
+			} else if unicode.IsUpper(prev) {
+				// HTTPServer: the last upper case letter of a run starts a
+				// new word if followed by a lower case one
+				next, _ := utf8.DecodeRuneInString(name[i+utf8.RuneLen(r):])
+				if unicode.IsLower(next) {
+					words = append(words, name[start:i])
+					start = i
+				}
+			}
+		}
+		if start < 0 {
+			start = i
+		}
+		prev = r
+	}
+	if start >= 0 {
+		words = append(words, name[start:])
+	}
+	return words
+}
@@ -0,0 +1,87 @@
+package tagparser
+
+import "testing"
+
+func TestApplyCase(t *testing.T) {
+	var tests = []struct {
+		name   string
+		camel  string
+		pascal string
+		snake  string
+		kebab  string
+	}{
+		{``, ``, ``, ``, ``},
+		{`id`, `id`, `Id`, `id`, `id`},
+		{`ID`, `id`, `Id`, `id`, `id`},
+		{`UserID`, `userId`, `UserId`, `user_id`, `user-id`},
+		{`userId`, `userId`, `UserId`, `user_id`, `user-id`},
+		{`user_id`, `userId`, `UserId`, `user_id`, `user-id`},
+		{`user-id`, `userId`, `UserId`, `user_id`, `user-id`},
+		{`user id`, `userId`, `UserId`, `user_id`, `user-id`},
+		{`__user__id__`, `userId`, `UserId`, `user_id`, `user-id`},
+		{`HTTPServer`, `httpServer`, `HttpServer`, `http_server`, `http-server`},
+		{`ServeHTTP`, `serveHttp`, `ServeHttp`, `serve_http`, `serve-http`},
+		{`APIKeyV2`, `apiKeyV2`, `ApiKeyV2`, `api_key_v2`, `api-key-v2`},
+		{`v2Name`, `v2Name`, `V2Name`, `v2_name`, `v2-name`},
+		{`Base64Encoded`, `base64Encoded`, `Base64Encoded`, `base64_encoded`, `base64-encoded`},
+		{`ÉtatCivil`, `étatCivil`, `ÉtatCivil`, `état_civil`, `état-civil`},
+		{`X`, `x`, `X`, `x`, `x`},
+	}
+	for _, test := range tests {
+		for _, c := range []struct {
+			c        Case
+			expected string
+		}{
+			{CaseNone, test.name},
+			{CamelCase, test.camel},
+			{PascalCase, test.pascal},
+			{SnakeCase, test.snake},
+			{KebabCase, test.kebab},
+		} {
+			actual := ApplyCase(test.name, c.c)
+			if actual != c.expected {
+				t.Errorf("** ApplyCase(%q, %v) = %q, wanted %q", test.name, c.c, actual, c.expected)
+			}
+		}
+	}
+}
+
+func TestParseCase(t *testing.T) {
+	var tests = []struct {
+		input    string
+		expected Case
+		ok       bool
+	}{
+		{`camel`, CamelCase, true},
+		{`Pascal`, PascalCase, true},
+		{`SNAKE`, SnakeCase, true},
+		{`kebab`, KebabCase, true},
+		{`none`, CaseNone, true},
+		{`screaming`, CaseNone, false},
+		{``, CaseNone, false},
+	}
+	for _, test := range tests {
+		actual, ok := ParseCase(test.input)
+		if actual != test.expected || ok != test.ok {
+			t.Errorf("** ParseCase(%q) = %v, %v, wanted %v, %v", test.input, actual, ok, test.expected, test.ok)
+		}
+	}
+}
+
+func TestCase_String(t *testing.T) {
+	var tests = []struct {
+		c        Case
+		expected string
+	}{
+		{CaseNone, "none"},
+		{CamelCase, "camel"},
+		{KebabCase, "kebab"},
+		{Case(42), "Case(42)"},
+		{Case(-1), "Case(-1)"},
+	}
+	for _, test := range tests {
+		if actual := test.c.String(); actual != test.expected {
+			t.Errorf("** String() = %q, wanted %q", actual, test.expected)
+		}
+	}
+}
@@ -0,0 +1,116 @@
+package main
+
+import (
+	"encoding/csv"
+	"flag"
+	"fmt"
+	"go/parser"
+	"go/token"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/andreyvit/tagparser"
+	"github.com/andreyvit/tagparser/astscan"
+)
+
+// export runs the export verb, writing the options of the struct tags in
+// the Go files at the paths given in args as CSV.
+func export(args []string, stdout, stderr io.Writer) int {
+	flags := flag.NewFlagSet("tagparse export", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	ns := flags.String("ns", "json,xml", "comma-separated `namespaces` to export, as preset or namespace=preset")
+	imports := flags.Bool("imports", false, "also export the namespaces implied by the imports of each file")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	confs := make(map[string]tagparser.Configuration)
+	for _, item := range strings.Split(*ns, ",") {
+		namespace, preset, _ := strings.Cut(item, "=")
+		if preset == "" {
+			preset = namespace
+		}
+		conf, ok := presets[preset]
+		if !ok || namespace == "" {
+			fmt.Fprintf(stderr, "tagparse: unknown preset %q\n", preset)
+			return 2
+		}
+		conf.CollectAllErrors = true
+		confs[namespace] = conf
+	}
+	var dialects map[string]astscan.Dialect
+	if *imports {
+		dialects = astscan.ImportDialects()
+		for path, d := range dialects {
+			d.Conf.CollectAllErrors = true
+			dialects[path] = d
+		}
+	}
+	paths := flags.Args()
+	if len(paths) == 0 {
+		paths = []string{"."}
+	}
+
+	w := csv.NewWriter(stdout)
+	w.Write([]string{"file", "line", "struct", "field", "namespace", "name", "key", "value"})
+	fset := token.NewFileSet()
+	exitCode := 0
+	exportFile := func(path string) {
+		file, err := parser.ParseFile(fset, path, nil, parser.SkipObjectResolution)
+		if err != nil {
+			fmt.Fprintln(stderr, err)
+			exitCode = 2
+			return
+		}
+		p := astscan.Detect(file, dialects, confs)
+		for _, field := range astscan.Fields(file) {
+			results, _ := p.Parse(field.Tag)
+			line := strconv.Itoa(fset.Position(field.Lit.Pos()).Line)
+			for _, namespace := range sortedKeys(results) {
+				r := results[namespace]
+				row := func(key, value string) {
+					w.Write([]string{path, line, field.Struct, field.Name, namespace, r.Name, key, value})
+				}
+				// A tag without options still gets a row for its name.
+				if len(r.Options) == 0 {
+					row("", "")
+				}
+				for _, key := range sortedKeys(r.Options) {
+					row(key, r.Options[key])
+				}
+			}
+		}
+		// Report the problems after the rows, so that they follow the
+		// rows of the file if both go to a terminal.
+		w.Flush()
+		for _, d := range astscan.Check(file, p) {
+			fmt.Fprintln(stderr, d.Format(fset))
+			if exitCode == 0 {
+				exitCode = 1
+			}
+		}
+	}
+	for _, path := range paths {
+		files, err := astscan.Files(path)
+		for _, file := range files {
+			exportFile(file)
+		}
+		if err != nil {
+			fmt.Fprintln(stderr, err)
+			exitCode = 2
+		}
+	}
+	w.Flush()
+	return exitCode
+}
+
+// sortedKeys returns the keys of m in lexical order.
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
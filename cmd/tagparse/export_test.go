@@ -0,0 +1,56 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestExport(t *testing.T) {
+	golden, err := os.ReadFile("testdata/export.csv")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var stdout, stderr strings.Builder
+	code := run([]string{"export", "-imports", "testdata/model.go"}, nil, &stdout, &stderr)
+	if code != 1 || stdout.String() != string(golden) || stderr.String() != "testdata/model.go:15:17: A json: unterminated quote [unterminated-quote]\n" {
+		t.Errorf("** export = %d, stdout:\n%s\nstderr %q, wanted 1, testdata/export.csv and an unterminated quote", code, stdout.String(), stderr.String())
+	}
+}
+
+func TestExport_args(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"a.go":      "package x\n\ntype A struct {\n\tB int `db:\"b;size:1\" json:\"b\"`\n}\n",
+		"notes.txt": "type Bad struct {}",
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	const header = "file,line,struct,field,namespace,name,key,value\n"
+	var tests = []struct {
+		args   []string
+		code   int
+		stdout string
+		stderr string
+	}{
+		{[]string{"export"}, 0, header, ""},
+		{[]string{"export", "-ns", "db=gorm", dir}, 0, header + "a.go,4,A,B,db,,b,\na.go,4,A,B,db,,size,1\n", ""},
+		{[]string{"export", filepath.Join(dir, "notes.txt")}, 2, header, "expected 'package'"},
+		{[]string{"export", filepath.Join(dir, "missing")}, 2, header, "no such file or directory"},
+		{[]string{"export", "-ns", "db=foo"}, 2, "", "tagparse: unknown preset \"foo\"\n"},
+		{[]string{"export", "-x"}, 2, "", "flag provided but not defined"},
+	}
+	for _, test := range tests {
+		var stdout, stderr strings.Builder
+		code := run(test.args, nil, &stdout, &stderr)
+		actual := strings.ReplaceAll(stdout.String(), dir+string(filepath.Separator), "")
+		if code != test.code || actual != test.stdout || !strings.Contains(stderr.String(), test.stderr) {
+			t.Errorf("** run(%q) = %d, stdout %q, stderr %q, wanted %d, %q, %q", test.args, code, actual, stderr.String(), test.code, test.stdout, test.stderr)
+		}
+	}
+}
@@ -0,0 +1,117 @@
+// Command tagparse parses tags and prints the results as JSON, one object per
+// line, for tools that cannot parse the dialect themselves:
+//
+//	tagparse [-preset json] [-testcase] [tag ...]
+//	tagparse anonymize [tag ...]
+//	tagparse export [-ns json,xml,db=gorm] [-imports] [path ...]
+//
+// The tags are taken from the arguments, or from the lines of the standard
+// input if there are none. The -preset flag names the dialect, one of json,
+// xml, gorm, validate, protobuf and msgpack. Each object is a
+// tagjson.ParsedTag, listing all errors of the tag. With -testcase, tags
+// without errors are skipped, and the others are printed as test table
+// entries reproducing the errors, see tagparser.Error.TestCase, for
+// contributing regression tests.
+//
+// The anonymize verb prints the tags with identifiers and values replaced by
+// placeholders instead, see tagparser.Anonymize, so that failing tags can be
+// shared in bug reports.
+//
+// The export verb writes the options of the struct tags in Go source files
+// as CSV instead, one row per option with the columns file, line, struct,
+// field, namespace, name, key and value, for analyzing tag usage in other
+// tools; tags without options get a row with empty key and value. The paths
+// and the -ns and -imports flags are those of tagvet. Rows are ordered by
+// position, namespace and key, and the problems of the tags are printed to
+// the standard error in the go vet format.
+//
+// The exit code is 1 if any parsed tag has errors, and 2 on usage and I/O
+// errors.
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/andreyvit/tagparser"
+	"github.com/andreyvit/tagparser/tagjson"
+)
+
+var presets = map[string]tagparser.Configuration{
+	"json":     tagparser.JSON(),
+	"xml":      tagparser.XML(),
+	"gorm":     tagparser.Gorm(),
+	"validate": tagparser.Validator(),
+	"protobuf": tagparser.Protobuf(),
+	"msgpack":  tagparser.VMihailenco(),
+}
+
+var exit = os.Exit // replaced by tests
+
+func main() {
+	exit(run(os.Args[1:], os.Stdin, os.Stdout, os.Stderr))
+}
+
+func run(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	if len(args) > 0 && args[0] == "export" {
+		return export(args[1:], stdout, stderr)
+	}
+	anonymize := len(args) > 0 && args[0] == "anonymize"
+	if anonymize {
+		args = args[1:]
+	}
+	flags := flag.NewFlagSet("tagparse", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	preset := flags.String("preset", "json", "`dialect` of the tags")
+	testCase := flags.Bool("testcase", false, "print test table entries for the tags with errors")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	conf, ok := presets[*preset]
+	if !ok {
+		fmt.Fprintf(stderr, "tagparse: unknown preset %q\n", *preset)
+		return 2
+	}
+	conf.CollectAllErrors = true
+
+	enc := json.NewEncoder(stdout)
+	enc.SetEscapeHTML(false)
+	exitCode := 0
+	parse := func(tag string) {
+		if anonymize {
+			tag, _ = tagparser.Anonymize(tag)
+			fmt.Fprintln(stdout, tag)
+			return
+		}
+		p, err := tagjson.Parse(conf, tag)
+		if err != nil {
+			exitCode = 1
+		}
+		if *testCase {
+			if err != nil {
+				fmt.Fprintln(stdout, p.Errors[0].TestCase())
+			}
+			return
+		}
+		enc.Encode(p)
+	}
+	if flags.NArg() > 0 {
+		for _, tag := range flags.Args() {
+			parse(tag)
+		}
+		return exitCode
+	}
+	scanner := bufio.NewScanner(stdin)
+	for scanner.Scan() {
+		parse(scanner.Text())
+	}
+	if err := scanner.Err(); err != nil {
+		fmt.Fprintf(stderr, "tagparse: %v\n", err)
+		return 2
+	}
+	return exitCode
+}
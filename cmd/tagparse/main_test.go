@@ -0,0 +1,59 @@
+package main
+
+import (
+	"errors"
+	"os"
+	"strings"
+	"testing"
+)
+
+type errReader struct{}
+
+func (errReader) Read(p []byte) (int, error) {
+	return 0, errors.New("simulated error")
+}
+
+func TestRun(t *testing.T) {
+	var tests = []struct {
+		args   []string
+		stdin  string
+		code   int
+		stdout string
+		stderr string
+	}{
+		{[]string{"a,omitempty"}, "", 0, `{"tag":"a,omitempty","name":"a","hasName":true,"namePos":0,"nameEnd":1,"options":[{"key":"omitempty","value":"","rawKey":"omitempty","rawValue":"","keyPos":2,"keyEnd":11,"valuePos":11,"end":11,"hasValue":false,"wasQuoted":false}]}` + "\n", ""},
+		{[]string{"-preset", "gorm"}, "size:10\n<b>\n", 0, `{"tag":"size:10","name":"","hasName":false,"namePos":0,"nameEnd":0,"options":[{"key":"size","value":"10","rawKey":"size","rawValue":"10","keyPos":0,"keyEnd":4,"valuePos":5,"end":7,"hasValue":true,"wasQuoted":false}]}` + "\n" +
+			`{"tag":"<b>","name":"","hasName":false,"namePos":0,"nameEnd":0,"options":[{"key":"<b>","value":"","rawKey":"<b>","rawValue":"","keyPos":0,"keyEnd":3,"valuePos":3,"end":3,"hasValue":false,"wasQuoted":false}]}` + "\n", ""},
+		{[]string{"a,b", `,\x,'y`}, "", 1, `{"tag":"a,b","name":"a","hasName":true,"namePos":0,"nameEnd":1,"options":[{"key":"b","value":"","rawKey":"b","rawValue":"","keyPos":2,"keyEnd":3,"valuePos":3,"end":3,"hasValue":false,"wasQuoted":false}]}` + "\n" +
+			`{"tag":",\\x,'y","name":"","hasName":true,"namePos":0,"nameEnd":0,"options":[{"key":"x","value":"","rawKey":"\\x","rawValue":"","keyPos":1,"keyEnd":3,"valuePos":3,"end":3,"hasValue":false,"wasQuoted":false},{"key":"y","value":"","rawKey":"'y","rawValue":"","keyPos":4,"keyEnd":6,"valuePos":6,"end":6,"hasValue":false,"wasQuoted":false}],"errors":[{"code":"invalid-escape","pos":2,"message":"invalid escape character","tag":",\\x,'y","suggestion":"use \\\\x for a literal backslash"},{"code":"unterminated-quote","pos":4,"message":"unterminated quote","tag":",\\x,'y","suggestion":"add a closing quote: ,\\x,'y'"}]}` + "\n", ""},
+		{[]string{"anonymize", "id,size:10,primaryKey", "'a"}, "", 0, "xx,xxxx:00,xxxxxxxXxx\n'x\n", ""},
+		{[]string{"anonymize"}, "foo,bar:foo\n", 0, "xxx,xxy:xxx\n", ""},
+		{[]string{"--", "anonymize"}, "", 0, `{"tag":"anonymize","name":"anonymize","hasName":true,"namePos":0,"nameEnd":9,"options":[]}` + "\n", ""},
+		{[]string{"-testcase", "a,b", "a,'b"}, "", 1, "{`regression`, Configuration{NamePosition: NameFirst, KeyValueSeparator: '\\u00ff', DuplicateKeys: DuplicateKeysLastWins, SkipMarker: `-`, CollectAllErrors: true}, `a,'b`, \"a\", M{\"b\": \"\"}, `unterminated quote (at 3)`},\n", ""},
+		{[]string{"-preset", "foo"}, "", 2, "", "tagparse: unknown preset \"foo\"\n"},
+		{[]string{"-x"}, "", 2, "", "flag provided but not defined"},
+	}
+	for _, test := range tests {
+		var stdout, stderr strings.Builder
+		code := run(test.args, strings.NewReader(test.stdin), &stdout, &stderr)
+		if code != test.code || stdout.String() != test.stdout || !strings.Contains(stderr.String(), test.stderr) {
+			t.Errorf("** run(%q) = %d, stdout %s, stderr %q, wanted %d, %s, %q", test.args, code, stdout.String(), stderr.String(), test.code, test.stdout, test.stderr)
+		}
+	}
+
+	var stderr strings.Builder
+	if code := run(nil, errReader{}, &strings.Builder{}, &stderr); code != 2 || stderr.String() != "tagparse: simulated error\n" {
+		t.Errorf("** run with a failing stdin = %d, stderr %q", code, stderr.String())
+	}
+}
+
+func TestMain_args(t *testing.T) {
+	defer func(args []string) { os.Args, exit = args, os.Exit }(os.Args)
+	os.Args = []string{"tagparse", "-preset", "foo"}
+	code := -1
+	exit = func(c int) { code = c }
+	main()
+	if code != 2 {
+		t.Errorf("** main exited with %d, wanted 2", code)
+	}
+}
@@ -0,0 +1,16 @@
+package model
+
+import "gorm.io/gorm"
+
+type User struct {
+	gorm.Model
+	ID    int    `json:"id" gorm:"primaryKey;column:user_id"`
+	Email string `json:"email,omitempty" xml:"email,attr" gorm:"size:255;uniqueIndex"`
+	Notes string `json:"-"`
+	Bio   string `json:"bio,\"quoted\"" gorm:"comment:says \"hi\", twice"`
+	Plain int
+}
+
+var _ = struct {
+	A int `json:"a,'x"`
+}{}
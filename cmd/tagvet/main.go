@@ -0,0 +1,124 @@
+// Command tagvet reports malformed struct tags in Go source files, like
+// unterminated quotes, duplicate keys and empty keys:
+//
+//	tagvet [-ns json,xml,db=gorm] [-imports] [path ...]
+//
+// Each path is a Go file, a directory, or a directory followed by /... to
+// include its subdirectories, skipping testdata, vendor and hidden ones; the
+// default is the current directory. The -ns flag lists the namespaces to
+// check, each either the name of a preset (json, xml, gorm, validate,
+// protobuf, msgpack) or `namespace=preset` for namespaces following the
+// syntax of a preset.
+//
+// With -imports, each file is also checked for the namespaces implied by its
+// imports, like gorm for gorm.io/gorm (see astscan.ImportDialects); the
+// namespaces listed with -ns take precedence.
+//
+// All problems of a tag are reported. Duplicate keys are reported even for
+// dialects where the last one wins, since they are almost always a mistake;
+// validate is the exception, as validations may repeat.
+//
+// Problems are printed in the go vet format. The exit code is 1 if any are
+// found, and 2 on usage and syntax errors.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"go/parser"
+	"go/token"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/andreyvit/tagparser"
+	"github.com/andreyvit/tagparser/astscan"
+)
+
+var presets = map[string]tagparser.Configuration{
+	"json":     tagparser.JSON(),
+	"xml":      tagparser.XML(),
+	"gorm":     tagparser.Gorm(),
+	"validate": tagparser.Validator(),
+	"protobuf": tagparser.Protobuf(),
+	"msgpack":  tagparser.VMihailenco(),
+}
+
+var exit = os.Exit // replaced by tests
+
+func main() {
+	exit(run(os.Args[1:], os.Stdout, os.Stderr))
+}
+
+func run(args []string, stdout, stderr io.Writer) int {
+	flags := flag.NewFlagSet("tagvet", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	ns := flags.String("ns", "json,xml", "comma-separated `namespaces` to check, as preset or namespace=preset")
+	imports := flags.Bool("imports", false, "also check the namespaces implied by the imports of each file")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	confs := make(map[string]tagparser.Configuration)
+	for _, item := range strings.Split(*ns, ",") {
+		namespace, preset, _ := strings.Cut(item, "=")
+		if preset == "" {
+			preset = namespace
+		}
+		conf, ok := presets[preset]
+		if !ok || namespace == "" {
+			fmt.Fprintf(stderr, "tagvet: unknown preset %q\n", preset)
+			return 2
+		}
+		confs[namespace] = strict(conf)
+	}
+	var dialects map[string]astscan.Dialect
+	if *imports {
+		dialects = astscan.ImportDialects()
+		for path, d := range dialects {
+			d.Conf = strict(d.Conf)
+			dialects[path] = d
+		}
+	}
+	paths := flags.Args()
+	if len(paths) == 0 {
+		paths = []string{"."}
+	}
+
+	fset := token.NewFileSet()
+	exitCode := 0
+	check := func(path string) {
+		file, err := parser.ParseFile(fset, path, nil, parser.SkipObjectResolution)
+		if err != nil {
+			fmt.Fprintln(stderr, err)
+			exitCode = 2
+			return
+		}
+		for _, d := range astscan.Check(file, astscan.Detect(file, dialects, confs)) {
+			fmt.Fprintln(stdout, d.Format(fset))
+			if exitCode == 0 {
+				exitCode = 1
+			}
+		}
+	}
+	for _, path := range paths {
+		files, err := astscan.Files(path)
+		for _, file := range files {
+			check(file)
+		}
+		if err != nil {
+			fmt.Fprintln(stderr, err)
+			exitCode = 2
+		}
+	}
+	return exitCode
+}
+
+// strict returns the configuration with duplicate keys reported, unless the
+// dialect collects them, and all errors collected.
+func strict(conf tagparser.Configuration) tagparser.Configuration {
+	if conf.DuplicateKeys != tagparser.DuplicateKeysCollect {
+		conf.DuplicateKeys = tagparser.DuplicateKeysError
+	}
+	conf.CollectAllErrors = true
+	return conf
+}
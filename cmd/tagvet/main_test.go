@@ -0,0 +1,83 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRun(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"user.go":             "package x\n\ntype User struct {\n\tEmail string `json:\"email,omitempty,omitempty\" db:\"email;size:10;size:20\"`\n\tName  string `json:\"name,'x\"`\n}\n",
+		"ok.go":               "package x\n\ntype OK struct {\n\tA int `json:\"a\"`\n}\n",
+		"model/gorm.go":       "package x\n\nimport \"gorm.io/gorm\"\n\ntype M struct {\n\tgorm.Model\n\tA int `gorm:\"size:1;size:2\" db:\"'\"`\n}\n",
+		"notes.txt":           "type Bad struct { A int `json:\",:x\"` }",
+		"testdata/bad.go":     "package x\n\ntype Bad struct {\n\tA int `json:\",:x\"`\n}\n",
+		"sub/bad.go":          "package x\n\ntype Bad struct {\n\tA int `xml:\"a,'\"`\n}\n",
+		".hidden/bad.go":      "package x\n\ntype Bad struct {\n\tA int `json:\",:x\"`\n}\n",
+		"broken/broken.go.in": "",
+	}
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var tests = []struct {
+		args   []string
+		code   int
+		stdout string
+		stderr string
+	}{
+		{[]string{dir}, 1, "user.go:4:38: User.Email json: omitempty: duplicate option key [duplicate-key]\n" +
+			"user.go:5:27: User.Name json: unterminated quote [unterminated-quote]\n", ""},
+		{[]string{dir + "/..."}, 1, "sub/bad.go:4:16: Bad.A xml: unterminated quote [unterminated-quote]\n" +
+			"user.go:4:38: User.Email json: omitempty: duplicate option key [duplicate-key]\n" +
+			"user.go:5:27: User.Name json: unterminated quote [unterminated-quote]\n", ""},
+		{[]string{"-ns", "json,db=gorm", filepath.Join(dir, "user.go")}, 1, "user.go:4:38: User.Email json: omitempty: duplicate option key [duplicate-key]\n" +
+			"user.go:4:67: User.Email db: size: duplicate option key [duplicate-key]\n" +
+			"user.go:5:27: User.Name json: unterminated quote [unterminated-quote]\n", ""},
+		{[]string{"-ns", "db=validate", filepath.Join(dir, "ok.go")}, 0, "", ""},
+		{[]string{"-imports", filepath.Join(dir, "model")}, 1, "model/gorm.go:7:22: M.A gorm: size: duplicate option key [duplicate-key]\n", ""},
+		{[]string{"-imports", "-ns", "gorm=json,db=gorm", filepath.Join(dir, "model")}, 1, "model/gorm.go:7:34: M.A db: unterminated quote [unterminated-quote]\n", ""},
+		{[]string{"-ns", "db=foo", dir}, 2, "", "tagvet: unknown preset \"foo\"\n"},
+		{[]string{"-x"}, 2, "", "flag provided but not defined"},
+		{[]string{filepath.Join(dir, "notes.txt")}, 2, "", "expected 'package'"},
+		{[]string{filepath.Join(dir, "missing")}, 2, "", "no such file or directory"},
+	}
+	for _, test := range tests {
+		var stdout, stderr strings.Builder
+		code := run(test.args, &stdout, &stderr)
+		actual := strings.ReplaceAll(stdout.String(), dir+string(filepath.Separator), "")
+		if code != test.code || actual != test.stdout || !strings.Contains(stderr.String(), test.stderr) {
+			t.Errorf("** run(%q) = %d, stdout %q, stderr %q, wanted %d, %q, %q", test.args, code, actual, stderr.String(), test.code, test.stdout, test.stderr)
+		}
+	}
+}
+
+func TestMain_current_directory(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "ok.go"), []byte("package x\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	wd, _ := os.Getwd()
+	if err := os.Chdir(dir); err != nil {
+		t.Fatal(err)
+	}
+	defer os.Chdir(wd)
+	defer func(args []string) { os.Args, exit = args, os.Exit }(os.Args)
+
+	os.Args = []string{"tagvet"}
+	code := -1
+	exit = func(c int) { code = c }
+	main()
+	if code != 0 {
+		t.Errorf("** main exited with %d, wanted 0", code)
+	}
+}
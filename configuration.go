@@ -0,0 +1,543 @@
+package tagparser
+
+// Configuration defines the tag dialect understood by the parser. The zero
+// value parses tags like ParseFunc and Parse do.
+type Configuration struct {
+	// NamePosition determines which item of the tag, if any, is treated as
+	// a name.
+	NamePosition NamePosition
+
+	// ContinuationKey, if not empty, is an option key whose value is appended
+	// to the value of the preceding item (joined with ContinuationJoiner)
+	// instead of being reported as a separate option, so that long texts can
+	// be split across several items:
+	//
+	//	desc:'A very long description', ++:'that goes on and on.'
+	//
+	// Continuation items are not allowed at the start of the tag.
+	ContinuationKey string
+
+	// ContinuationJoiner is inserted between the joined parts of a value
+	// split using ContinuationKey, e.g. a space or a newline.
+	ContinuationJoiner string
+
+	// GreedyLastValue makes the value of the last item with a colon extend to
+	// the end of the tag, so that free text can be given without quoting:
+	//
+	//	min:1, desc:anything, even commas
+	//
+	// Quotes and escapes keep their meaning within such a value. Note that a
+	// later comma-separated part containing a colon starts a new item, so
+	// the last value itself must not contain unquoted colons after a comma.
+	GreedyLastValue bool
+
+	// NameModifierSeparator, if not zero, separates modifiers attached to the
+	// name, like '/' in `name/omit/strict`. Use SplitName to obtain them.
+	NameModifierSeparator byte
+
+	// NameVariantSeparator, if not zero, separates per-locale variants of the
+	// name, like ';' in `title;de=titel;fr=titre`. Each variant is a locale,
+	// optionally followed by '=' and the name used for that locale; a bare
+	// locale like in `title;en` uses the base name. Use SplitName to obtain
+	// them.
+	NameVariantSeparator byte
+
+	// QuotedNames determines whether names may be quoted, like in
+	// `'weird,name',omitempty`.
+	QuotedNames QuotedNamePolicy
+
+	// QuoteChars lists the characters that quote keys, values and names,
+	// `'` if empty. Set it to `"` or `'"` for tags written by people used to
+	// YAML or JSON, or include a backtick for tags that do not come from Go
+	// source. A quoted part ends at the same character it starts with, so
+	// other quote characters can be used inside it literally. At most three
+	// characters are supported.
+	QuoteChars string
+
+	// EscapableChars, if not empty, lists the only characters that may be
+	// escaped with a backslash outside of quotes, e.g. `,:\'`; escaping any
+	// other character is an error. By default, any character except ASCII
+	// letters and digits may be escaped. Within quotes, the default rule
+	// always applies.
+	EscapableChars string
+
+	// AllowStandardEscapes makes the parser interpret \n, \t, \r, \0, \xNN
+	// (a byte) and \uXXXX (a UTF-8 encoded code point) within quotes, so that
+	// values can contain control characters. A malformed \x or \u sequence is
+	// reported as CodeMalformedEscape. Outside of quotes, letters and digits
+	// still cannot be escaped.
+	AllowStandardEscapes bool
+
+	// ListValues makes the parser recognize bracketed list values like
+	// `roles:[admin, editor, 'a,b']`, where item separators do not end the
+	// item. Such values are returned as written, brackets included, and are
+	// split by Result.Values with the usual quoting and escaping rules, so
+	// the quotes and escapes of the items do not need to be escaped again.
+	// Brackets can be nested; an unclosed bracket is reported as
+	// CodeUnterminatedBracket.
+	ListValues bool
+
+	// NegationPrefix, if not zero, marks negated flags like `!omitempty` or
+	// `-nullable`: the prefix is removed from the key and the value is
+	// reported as "false", so that callers can tell an unset flag from a
+	// cleared one. A negated item cannot have a value of its own
+	// (CodeNegatedValue). Like with DirectivePrefix, a quoted or escaped
+	// prefix does not negate, and names are never negated.
+	NegationPrefix byte
+
+	// DirectivePrefix, if not zero, marks items whose key starts with it (like
+	// `#version:2` or `#strict`) as directives, a meta-channel for dialect
+	// authors. Directives are reported to a separate callback by
+	// ParseDirectivesFunc without the prefix, and are skipped by other parse
+	// funcs. A quoted or escaped prefix does not start a directive, and a
+	// directive is never treated as a name.
+	DirectivePrefix byte
+
+	// Multiline makes the parser accept tags written across several indented
+	// lines by passing them through Dedent first. Error positions then refer
+	// to the dedented tag, which is also returned as Error.Tag.
+	Multiline bool
+
+	// WildcardKey, if not empty, is an option key like `*` or `...` that
+	// designates catch-all behavior, e.g. handling of the remaining fields.
+	// Keys are compared after unquoting, so `'*'` matches too. Parse omits
+	// it from the options, and ParseResult reports it separately as
+	// Result.Wildcard. ParseFunc reports it like any other option.
+	WildcardKey string
+
+	// StripKeyPrefix, if not empty, is removed from the start of option keys
+	// before they are reported, for dialects that namespace options like
+	// `x-experimental`. Keys are stripped after unquoting, and before being
+	// compared to ContinuationKey and WildcardKey. A key consisting only of
+	// the prefix is an empty key error. Error positions still refer to the
+	// original keys.
+	StripKeyPrefix string
+
+	// KeyValueSeparator separates keys from values, ':' if zero. Set it to
+	// '=' for tags like `size=255`.
+	KeyValueSeparator byte
+
+	// ItemSeparator separates items, ',' if zero. Set it to ';' for
+	// gorm-style tags like `index;size:255`.
+	//
+	// The separators must differ from each other, and from quotes and
+	// backslashes. Escaping or quoting a separator makes it a regular
+	// character, as usual.
+	ItemSeparator byte
+
+	// UnicodeWhitespace makes the parser trim Unicode whitespace, like
+	// non-breaking and full-width spaces, at the ends of keys, values and
+	// names, instead of just ASCII whitespace. Trimmed characters are not
+	// reported as invisible characters in keys.
+	UnicodeWhitespace bool
+
+	// KeyValidator, if not nil, is called for every option key after
+	// unquoting; an error is reported at the key with CodeInvalidKey and the
+	// error as the Cause. ValidateIdentifier restricts keys to Go
+	// identifiers.
+	KeyValidator func(key string) error
+
+	// PercentDecodeValues makes the parser decode %XX sequences in values
+	// after unquoting, for dialects that avoid quoting by URL-encoding
+	// awkward characters, like `sep:%2C`. A malformed sequence is kept as is
+	// and reported as an error. See PercentEncodeValue for the reverse.
+	PercentDecodeValues bool
+
+	// ExpandValue, if not nil, is called for every option value after
+	// unquoting and percent-decoding, and after joining continuations, to
+	// expand placeholders like `${HOME}`; the value it returns is reported
+	// instead. It is not called for names, flags without a value and raw
+	// ListValues lists. An error is reported at the value with
+	// CodeExpandValue and the error as the Cause, and the value is kept
+	// unexpanded.
+	ExpandValue func(key, value string) (string, error)
+
+	// FoldKeys makes the parser fold option keys to lower case with FoldKey,
+	// so that `omitEmpty` and `omitempty` are the same key. Repeated keys
+	// spelled differently are reported with CodeDuplicateKeyCase instead of
+	// CodeDuplicateKey.
+	FoldKeys bool
+
+	// KeyNormalizer, if set, rewrites option keys after StripKeyPrefix and
+	// FoldKeys are applied, e.g. to norm.NFC.String from
+	// golang.org/x/text/unicode/norm, so that keys spelled differently
+	// are the same key. Like under FoldKeys, KnownKeys, KeyAliases,
+	// DeprecatedKeys and Schema keys are normalized before they are compared,
+	// and duplicates are detected after normalization.
+	KeyNormalizer func(key string) string
+
+	// DuplicateKeys determines what happens when an option key occurs more
+	// than once. It does not affect ParseFunc, which reports every item.
+	DuplicateKeys DuplicateKeyPolicy
+
+	// KnownKeys, if not empty, lists the recognized option keys; other keys
+	// are reported as errors with ErrUnknownKey cause and CodeUnknownKey,
+	// with a "did you mean" Error.Suggestion for likely typos. Keys are
+	// compared after StripKeyPrefix, FoldKeys and KeyNormalizer are applied,
+	// and under FoldKeys, KnownKeys match regardless of case. The WildcardKey
+	// is always recognized. See Schema for validating values too.
+	KnownKeys []string
+
+	// KeyAliases maps alternative spellings of option keys to the canonical
+	// ones, e.g. {"pk": "primaryKey"}, to stage a migration between
+	// dialects. Keys are mapped after StripKeyPrefix and FoldKeys are
+	// applied, so KnownKeys, duplicate detection and callbacks see the
+	// canonical keys. Under FoldKeys, aliases match regardless of case, and
+	// the canonical keys are folded too.
+	KeyAliases map[string]string
+
+	// DeprecatedKeys maps option keys that still parse, but should no longer
+	// be used, to hints like "use primaryKey instead", which may be empty.
+	// Keys are looked up before KeyAliases are applied, regardless of case
+	// under FoldKeys. Deprecated keys are not errors; they are reported in
+	// Result.Warnings.
+	DeprecatedKeys map[string]string
+
+	// WarnQuotedEmptyValues reports values written as empty quotes, like
+	// `size:''`, in Result.Warnings. The parse funcs return them like a key
+	// without a value, so they are usually a leftover of an edit. See
+	// RawItem.WasQuoted for telling them apart.
+	WarnQuotedEmptyValues bool
+
+	// StrictInvisibleChars reports invisible characters in keys (byte order
+	// mark, zero-width spaces and joiners, non-breaking space) as
+	// CodeInvisibleChar errors. Otherwise, they are reported in
+	// Result.Warnings, and the keys are returned as they are.
+	StrictInvisibleChars bool
+
+	// SkipMarker, if not empty, is a tag that marks a field to be skipped,
+	// like `-` in encoding/json. Only a tag consisting of exactly the marker
+	// sets Result.Skip, so `-,` still means a field named `-`. The marker is
+	// returned as the name as usual.
+	SkipMarker string
+
+	// CollectAllErrors makes parse funcs return all errors found in the tag as
+	// *ErrorList instead of only the first *Error, so that linters can report
+	// every problem in one pass. Only the first error at each position is
+	// kept.
+	CollectAllErrors bool
+
+	// MaxLength, MaxOptions and MaxNestingDepth, if positive, bound the work
+	// spent on tags from untrusted sources. A tag longer than MaxLength bytes
+	// is not parsed at all. Parsing stops at the item that exceeds
+	// MaxOptions items other than the name, and at the bracket that exceeds
+	// MaxNestingDepth levels of ListValues lists or Unnest values. Exceeded
+	// limits are reported with CodeLimitExceeded and ErrLimitExceeded cause.
+	//
+	// Parsing takes time linear in the length of the tag, except that
+	// reporting every case-insensitive duplicate under FoldKeys and
+	// CollectAllErrors rescans the tag for each one, and Unnest recurses
+	// once per nesting level; MaxLength bounds both.
+	MaxLength       int
+	MaxOptions      int
+	MaxNestingDepth int
+
+	// trusted disables validation for ParseTrusted.
+	trusted bool
+
+	// table holds the byte classes of a compiled configuration, see Compile.
+	table *charTable
+
+	// warnings, if not nil, collects Result.Warnings.
+	warnings *[]*Error
+}
+
+// Result is a parsed tag.
+type Result struct {
+	// Name is the name, always empty with NameNone.
+	Name string
+	// Options are the options of the tag except the wildcard.
+	Options map[string]string
+	// Wildcard is the value of the WildcardKey item, if HasWildcard.
+	Wildcard string
+	// HasWildcard is true if the tag contains the WildcardKey item.
+	HasWildcard bool
+	// Skip is true if the tag is the SkipMarker.
+	Skip bool
+	// Warnings are problems that do not make the tag invalid, like
+	// DeprecatedKeys, WarnQuotedEmptyValues and invisible characters in keys
+	// without StrictInvisibleChars, in the order of their positions. They
+	// are *Error values, so that they can be rendered like errors.
+	Warnings []*Error
+	// Tag is the tag the result has been parsed from, used by the Opt
+	// accessors to report positions.
+	Tag string
+	// Conf is the configuration the tag has been parsed with, used by methods
+	// of Result to apply the same dialect rules.
+	Conf Configuration
+}
+
+// SplitValue splits the value of the given option like the package-level
+// SplitValue, honoring the escaping rules of Conf.
+func (r Result) SplitValue(key string, sep byte) ([]string, error) {
+	return splitValue(r.Options[key], sep, &r.Conf)
+}
+
+// NamePosition determines which item of a tag is treated as a name.
+type NamePosition int
+
+const (
+	// NameNone means that no item is treated as a name, like in Parse.
+	NameNone NamePosition = iota
+
+	// NameFirst treats the first item as a name if it does not have a colon,
+	// like in ParseName.
+	NameFirst
+
+	// NameLast treats the last item as a name if it does not have a colon,
+	// for dialects like `omitempty,flat,myname`. A trailing comma means an
+	// empty name, like a leading comma does for NameFirst.
+	NameLast
+)
+
+// QuotedNamePolicy determines whether names may be quoted.
+type QuotedNamePolicy int
+
+const (
+	// QuotedNamesAllow accepts quoted names.
+	QuotedNamesAllow QuotedNamePolicy = iota
+
+	// QuotedNamesError reports quoted names as errors. The unquoted name is
+	// still returned.
+	QuotedNamesError
+)
+
+// DuplicateKeyPolicy determines the handling of repeated option keys.
+type DuplicateKeyPolicy int
+
+const (
+	// DuplicateKeysError reports repeated keys as errors with ErrDuplicateKey
+	// cause, keeping the first value.
+	DuplicateKeysError DuplicateKeyPolicy = iota
+
+	// DuplicateKeysFirstWins silently keeps the first value.
+	DuplicateKeysFirstWins
+
+	// DuplicateKeysLastWins silently keeps the last value.
+	DuplicateKeysLastWins
+
+	// DuplicateKeysCollect keeps all values: ParseMulti and ParseOrdered
+	// return every one of them, while Parse and ParseResult, which can only
+	// hold one value per key, keep the first one.
+	DuplicateKeysCollect
+)
+
+// duplicate returns whether a repeated key should replace the stored value,
+// and the error to report.
+func (p DuplicateKeyPolicy) duplicate() (replace bool, err error) {
+	switch p {
+	case DuplicateKeysError:
+		return false, ErrDuplicateKey
+	case DuplicateKeysLastWins:
+		return true, nil
+	default:
+		return false, nil
+	}
+}
+
+var (
+	nameNone  = Configuration{NamePosition: NameNone}
+	nameFirst = Configuration{NamePosition: NameFirst}
+)
+
+// quotes returns the quote characters, applying the default.
+func (conf *Configuration) quotes() quoteSet {
+	q := conf.QuoteChars
+	if q == "" {
+		return quoteSet{'\'', '\'', '\''}
+	}
+	set := quoteSet{q[0], q[0], q[0]}
+	copy(set[1:], q[1:])
+	return set
+}
+
+// separators returns the key-value and item separators, applying defaults.
+func (conf *Configuration) separators() (kvSep, itemSep byte) {
+	kvSep, itemSep = conf.KeyValueSeparator, conf.ItemSeparator
+	if kvSep == 0 {
+		kvSep = ':'
+	}
+	if itemSep == 0 {
+		itemSep = ','
+	}
+	return
+}
+
+// ParseFunc enumerates items of the tag according to the configuration. The
+// name, if any, is reported as a value with an empty key; with NameLast, it
+// is reported after all other items. See the package-level ParseFunc for the
+// full syntax and details.
+func (conf Configuration) ParseFunc(tag string, callback func(key, value string) error) error {
+	return parseFunc(tag, &conf, ErrorInfo{}, callback, nil, nil)
+}
+
+// ParseFuncWithInfo is like ParseFunc, but returns errors carrying the given
+// description of the tag's origin, e.g. `User.Email json: empty key (at 2)`.
+func (conf Configuration) ParseFuncWithInfo(tag string, info ErrorInfo, callback func(key, value string) error) error {
+	return parseFunc(tag, &conf, info, callback, nil, nil)
+}
+
+// ParseDirectivesFunc is like ParseFunc, but reports directives (see
+// DirectivePrefix) to the directive callback instead of skipping them.
+func (conf Configuration) ParseDirectivesFunc(tag string, callback, directive func(key, value string) error) error {
+	return parseFunc(tag, &conf, ErrorInfo{}, callback, directive, nil)
+}
+
+// Lookup returns the value of the first occurrence of the key in the tag,
+// without parsing the rest of the tag once the key is found. An empty key
+// looks up the name. The error is the one ParseFunc would have reported for
+// the part of the tag parsed so far.
+func (conf Configuration) Lookup(tag, key string) (value string, ok bool, err error) {
+	err = parseFunc(tag, &conf, ErrorInfo{}, func(k, v string) error {
+		if k != key {
+			return nil
+		}
+		value, ok = v, true
+		return ErrStop
+	}, nil, nil)
+	return value, ok, err
+}
+
+// Parse parses the tag according to the configuration, returning the name
+// (always empty with NameNone) and the options. Duplicate keys are handled
+// according to DuplicateKeys, by default reported as errors with
+// ErrDuplicateKey cause.
+func (conf Configuration) Parse(tag string) (name string, opts map[string]string, err error) {
+	r, err := conf.ParseResult(tag)
+	return r.Name, r.Options, err
+}
+
+// ParseTrusted is like Parse, but skips validation (escapes, invisible
+// characters, duplicate keys) for maximum throughput, and does not report
+// errors. Use it only for tags that have already been validated, e.g. at
+// build time. The result for invalid tags is unspecified, but ParseTrusted
+// never panics on them.
+func (conf Configuration) ParseTrusted(tag string) (name string, opts map[string]string) {
+	conf.trusted = true
+	parseFunc(tag, &conf, ErrorInfo{}, func(key, value string) error {
+		if key == "" {
+			name = value
+		} else if key != conf.WildcardKey {
+			if opts == nil {
+				opts = make(map[string]string)
+			}
+			opts[key] = value
+		}
+		return nil
+	}, nil, nil)
+	return
+}
+
+// Option is a key-value pair of a tag, as returned by ParseOrdered.
+type Option struct {
+	Key   string
+	Value string
+}
+
+// ParseOrdered is like Parse, but returns the options in the order they appear
+// in the tag, for tools that re-emit or diff tags. Unlike Parse, it keeps the
+// WildcardKey item among the options. With DuplicateKeysLastWins, the last
+// value of a repeated key is stored at the position of the first one; with
+// DuplicateKeysCollect, every occurrence is returned.
+func (conf Configuration) ParseOrdered(tag string) (name string, opts []Option, err error) {
+	err = parseFunc(tag, &conf, ErrorInfo{}, func(key, value string) error {
+		if key == "" {
+			name = value
+			return nil
+		}
+		if conf.DuplicateKeys != DuplicateKeysCollect {
+			for i, o := range opts {
+				if o.Key == key {
+					replace, err := conf.DuplicateKeys.duplicate()
+					if replace {
+						opts[i].Value = value
+					}
+					return err
+				}
+			}
+		}
+		opts = append(opts, Option{key, value})
+		return nil
+	}, nil, nil)
+	return
+}
+
+// ParseMulti is like Parse, but returns all values of every option key,
+// subject to DuplicateKeys: repeated keys yield several values only with
+// DuplicateKeysCollect. Unlike Parse, it keeps the WildcardKey item among the
+// options.
+func (conf Configuration) ParseMulti(tag string) (name string, opts map[string][]string, err error) {
+	err = parseFunc(tag, &conf, ErrorInfo{}, func(key, value string) error {
+		if key == "" {
+			name = value
+			return nil
+		}
+		if opts == nil {
+			opts = make(map[string][]string)
+		}
+		values, ok := opts[key]
+		if ok && conf.DuplicateKeys != DuplicateKeysCollect {
+			replace, err := conf.DuplicateKeys.duplicate()
+			if replace {
+				values[0] = value
+			}
+			return err
+		}
+		opts[key] = append(values, value)
+		return nil
+	}, nil, nil)
+	return
+}
+
+// ParseResult is like Parse, but also reports the wildcard item (see
+// WildcardKey).
+func (conf Configuration) ParseResult(tag string) (Result, error) {
+	return conf.parseResult(tag, nil, nil)
+}
+
+func (conf Configuration) parseResult(tag string, stats *Stats, schema *Schema) (r Result, err error) {
+	r.Tag, r.Conf = tag, conf
+	r.Skip = conf.SkipMarker != "" && tag == conf.SkipMarker
+	conf.warnings = new([]*Error)
+	defer func() { r.Warnings = *conf.warnings }()
+	err = parseFunc(tag, &conf, ErrorInfo{}, func(key, value string) error {
+		if key == "" {
+			r.Name = value
+			if schema != nil && value != "" {
+				return schema.validateConflict("", r.has)
+			}
+		} else if key == conf.WildcardKey {
+			if r.HasWildcard {
+				replace, err := conf.DuplicateKeys.duplicate()
+				if replace {
+					r.Wildcard = value
+				}
+				return err
+			}
+			r.Wildcard, r.HasWildcard = value, true
+		} else {
+			if r.Options == nil {
+				r.Options = make(map[string]string)
+			}
+			if _, ok := r.Options[key]; ok {
+				replace, err := conf.DuplicateKeys.duplicate()
+				if replace {
+					r.Options[key] = value
+				}
+				return err
+			}
+			r.Options[key] = value
+			if schema != nil {
+				if err := schema.ValidateOption(key, value); err != nil {
+					return err
+				}
+				if err := schema.validateOrder(key, r.has); err != nil {
+					return err
+				}
+				return schema.validateConflict(key, r.has)
+			}
+		}
+		return nil
+	}, nil, stats)
+	return
+}
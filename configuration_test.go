@@ -0,0 +1,309 @@
+package tagparser
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestConfiguration_Parse(t *testing.T) {
+	nameLast := Configuration{NamePosition: NameLast}
+	cont := Configuration{NamePosition: NameFirst, ContinuationKey: "++", ContinuationJoiner: " "}
+	greedy := Configuration{NamePosition: NameFirst, GreedyLastValue: true}
+	var tests = []struct {
+		testName string
+		conf     Configuration
+		tag      string
+		name     string
+		opts     map[string]string
+		error    string
+	}{
+		{`name last: empty`, nameLast, ``, "", nil, ``},
+		{`name last: name only`, nameLast, `alfa`, "alfa", nil, ``},
+		{`name last: trailing name`, nameLast, `omitempty,flat,myname`, "myname", M{"omitempty": "", "flat": ""}, ``},
+		{`name last: trailing comma`, nameLast, `omitempty,`, "", M{"omitempty": ""}, ``},
+		{`name last: trailing key-value`, nameLast, `alfa,bravo:charlie`, "", M{"alfa": "", "bravo": "charlie"}, ``},
+		{`name last: empty items`, nameLast, `alfa,,bravo`, "bravo", M{"alfa": ""}, ``},
+		{`name last: quoted name`, nameLast, `alfa,'bravo,charlie'`, "bravo,charlie", M{"alfa": ""}, ``},
+		{`name last: empty key`, nameLast, `:alfa,bravo`, "bravo", nil, `empty key (at 1)`},
+
+		{`continuation: none`, cont, `alfa,bravo:charlie`, "alfa", M{"bravo": "charlie"}, ``},
+		{`continuation: value`, cont, `alfa,desc:'Long, long',++:'text.',bravo`, "alfa", M{"desc": "Long, long text.", "bravo": ""}, ``},
+		{`continuation: multiple`, cont, `desc:a,++:b,++:c`, "", M{"desc": "a b c"}, ``},
+		{`continuation: name`, cont, `alfa,++:bravo`, "alfa bravo", nil, ``},
+		{`continuation: flag`, cont, `alfa,bravo,++:charlie`, "alfa", M{"bravo": " charlie"}, ``},
+		{`continuation: leading`, cont, `++:alfa,bravo`, "", M{"bravo": ""}, `continuation without preceding item (at 1)`},
+		{`continuation: bad item skipped`, cont, `desc:a,:x,++:b`, "", M{"desc": "a b"}, `empty key (at 8)`},
+		{`quotes: double`, Configuration{QuoteChars: `"`}, `a:"x, 'y'",b:"\""`, "", M{"a": "x, 'y'", "b": `"`}, ``},
+		{`quotes: mixed`, Configuration{QuoteChars: "'\"`"}, "a:'x\"',b:\"'\",c:`\\\\`", "", M{"a": `x"`, "b": "'", "c": `\`}, ``},
+		{`quotes: single is literal`, Configuration{QuoteChars: `"`}, `a:it's`, "", M{"a": "it's"}, ``},
+		{`quotes: unterminated`, Configuration{QuoteChars: "'`"}, "a:`x',b", "", M{"a": "x',b"}, `unterminated quote (at 3)`},
+		{`greedy: commas`, greedy, `alfa,min:1,desc:anything, even commas`, "alfa", M{"min": "1", "desc": "anything, even commas"}, ``},
+		{`greedy: colons`, greedy, `desc: time: 10:00, noon`, "", M{"desc": "time: 10:00, noon"}, ``},
+		{`greedy: quoted and escaped`, greedy, `desc:'a, b', c\, d`, "", M{"desc": "a, b, c, d"}, ``},
+		{`greedy: trailing flags stay`, greedy, `desc:x,bravo:y, charlie`, "", M{"desc": "x", "bravo": "y, charlie"}, ``},
+		{`greedy: no values`, greedy, `alfa,bravo,charlie`, "alfa", M{"bravo": "", "charlie": ""}, ``},
+		{`greedy: quoted colon ignored`, greedy, `desc:a, b,'c:d'`, "", M{"desc": "a, b,c:d"}, `invalid quote (at 11)`},
+		{`quoted names: allowed`, Configuration{NamePosition: NameFirst}, `'alfa,bravo',charlie`, "alfa,bravo", M{"charlie": ""}, ``},
+		{`quoted names: error`, Configuration{NamePosition: NameFirst, QuotedNames: QuotedNamesError}, ` 'alfa,bravo',charlie`, "alfa,bravo", M{"charlie": ""}, `quoted name (at 2)`},
+		{`quoted names: error, bare`, Configuration{NamePosition: NameFirst, QuotedNames: QuotedNamesError}, `alfa,'charlie'`, "alfa", M{"charlie": ""}, ``},
+		{`quoted names: error, empty`, Configuration{NamePosition: NameFirst, QuotedNames: QuotedNamesError}, `,charlie`, "", M{"charlie": ""}, ``},
+		{`quoted names: error, last`, Configuration{NamePosition: NameLast, QuotedNames: QuotedNamesError}, `charlie,'alfa'`, "alfa", M{"charlie": ""}, `quoted name (at 9)`},
+		{`escapable: allowed`, Configuration{EscapableChars: `,:`}, `alfa\,bravo:charlie\:delta`, "", M{"alfa,bravo": "charlie:delta"}, ``},
+		{`escapable: disallowed`, Configuration{EscapableChars: `,:`}, `alfa\ bravo`, "", M{"alfa bravo": ""}, `invalid escape character (at 6)`},
+		{`escapable: quoted`, Configuration{EscapableChars: `,:`}, `alfa:'bravo\'s'`, "", M{"alfa": "bravo's"}, ``},
+		{`escapable: quoted alnum`, Configuration{EscapableChars: `,:`}, `alfa:'bravo\s'`, "", M{"alfa": "bravos"}, `invalid escape character (at 13)`},
+		{`directives: skipped`, Configuration{NamePosition: NameFirst, DirectivePrefix: '#'}, `alfa,#version:2,bravo, #strict`, "alfa", M{"bravo": ""}, ``},
+		{`directives: not a name`, Configuration{NamePosition: NameFirst, DirectivePrefix: '#'}, `#strict,bravo`, "", M{"bravo": ""}, ``},
+		{`directives: escaped and quoted`, Configuration{DirectivePrefix: '#'}, `\#alfa,'#bravo'`, "", M{"#alfa": "", "#bravo": ""}, ``},
+		{`directives: empty`, Configuration{DirectivePrefix: '#'}, `#:x,alfa`, "", M{"alfa": ""}, `empty key (at 1)`},
+		{`negation: flags`, Configuration{NamePosition: NameFirst, NegationPrefix: '!'}, `alfa,!bravo, !charlie,delta`, "alfa", M{"bravo": "false", "charlie": "false", "delta": ""}, ``},
+		{`negation: not a name`, Configuration{NamePosition: NameFirst, NegationPrefix: '-'}, `-,-bravo`, "-", M{"bravo": "false"}, ``},
+		{`negation: escaped and quoted`, Configuration{NegationPrefix: '!'}, `\!alfa,'!bravo'`, "", M{"!alfa": "", "!bravo": ""}, ``},
+		{`negation: value`, Configuration{NegationPrefix: '!'}, `!alfa:1,bravo`, "", M{"bravo": ""}, `negated key with a value (at 6)`},
+		{`negation: empty`, Configuration{NegationPrefix: '!'}, `!,alfa`, "", M{"alfa": ""}, `empty key (at 1)`},
+		{`negation: duplicate`, Configuration{NegationPrefix: '!'}, `alfa,!alfa`, "", M{"alfa": ""}, `alfa: duplicate option key (at 6)`},
+		{`negation: folded duplicate`, Configuration{NegationPrefix: '!', FoldKeys: true}, `Alfa,!alfa`, "", M{"alfa": ""}, `alfa: duplicate option key differing only in case: "Alfa" (at 1) and "alfa" (at 6) (at 6)`},
+		{`lists: raw`, Configuration{NamePosition: NameFirst, ListValues: true}, `alfa,roles: [admin, 'a,b', c\,d] ,bravo:[[x],y]`, "alfa", M{"roles": `[admin, 'a,b', c\,d]`, "bravo": "[[x],y]"}, ``},
+		{`lists: not at start`, Configuration{ListValues: true}, `alfa:x[1,2],[bravo,charlie]`, "", M{"alfa": "x[1", "2]": "", "[bravo": "", "charlie]": ""}, ``},
+		{`lists: quoted`, Configuration{ListValues: true}, `alfa:'[x,y]',bravo:['],']`, "", M{"alfa": "[x,y]", "bravo": "['],']"}, ``},
+		{`lists: unterminated`, Configuration{ListValues: true}, `alfa:[x,[y],bravo`, "", M{"alfa": "[x,[y],bravo"}, `unterminated bracket (at 6)`},
+		{`continuation: duplicate`, cont, `desc:a,desc:b,++:c`, "", M{"desc": "a"}, `desc: duplicate option key (at 8)`},
+		{`wildcard: omitted`, Configuration{NamePosition: NameFirst, WildcardKey: "*"}, `alfa,*:rest,bravo`, "alfa", M{"bravo": ""}, ``},
+		{`wildcard: duplicate`, Configuration{WildcardKey: "..."}, `...,alfa,...:x`, "", M{"alfa": ""}, `...: duplicate option key (at 10)`},
+		{`strip prefix: stripped`, Configuration{NamePosition: NameFirst, StripKeyPrefix: "x-"}, `x-alfa,x-bravo:1,'x-charlie',delta`, "x-alfa", M{"bravo": "1", "charlie": "", "delta": ""}, ``},
+		{`strip prefix: duplicate`, Configuration{StripKeyPrefix: "x-"}, `x-alfa,alfa`, "", M{"alfa": ""}, `alfa: duplicate option key (at 8)`},
+		{`strip prefix: prefix only`, Configuration{StripKeyPrefix: "x-"}, `alfa, x-:1`, "", M{"alfa": ""}, `empty key (at 6)`},
+		{`strip prefix: wildcard`, Configuration{StripKeyPrefix: "x-", WildcardKey: "*"}, `alfa,x-*`, "", M{"alfa": ""}, ``},
+		{`separators: gorm`, Configuration{ItemSeparator: ';'}, `index;size:255;default:'a;b';type:a,b`, "", M{"index": "", "size": "255", "default": "a;b", "type": "a,b"}, ``},
+		{`separators: equals`, Configuration{NamePosition: NameFirst, KeyValueSeparator: '='}, `alfa,bravo=1,charlie=a:b=c,delta\=x`, "alfa", M{"bravo": "1", "charlie": "a:b=c", "delta=x": ""}, ``},
+		{`separators: greedy`, Configuration{KeyValueSeparator: '=', ItemSeparator: ';', GreedyLastValue: true}, `alfa=1;desc=a;b: c`, "", M{"alfa": "1", "desc": "a;b: c"}, ``},
+		{`percent: decoded`, Configuration{NamePosition: NameFirst, PercentDecodeValues: true}, `a%2Cb,sep:%2c,path:'%2F x',esc:\%41,plain:x`, "a%2Cb", M{"sep": ",", "path": "/ x", "esc": "A", "plain": "x"}, ``},
+		{`percent: malformed`, Configuration{PercentDecodeValues: true}, `alfa:%41%4,bravo:'\'%'%zz`, "", M{"alfa": "A%4", "bravo": "'%%zz"}, `invalid percent-encoding (at 9)`},
+		{`percent: malformed later`, Configuration{PercentDecodeValues: true}, `alfa:'%'%41\%%zz`, "", M{"alfa": "%A%%zz"}, `invalid percent-encoding (at 7)`},
+		{`percent: malformed escaped`, Configuration{PercentDecodeValues: true}, `alfa:%41\%z`, "", M{"alfa": "A%z"}, `invalid percent-encoding (at 10)`},
+		{`percent: standard escape`, Configuration{PercentDecodeValues: true, AllowStandardEscapes: true}, `a:'\x25'`, "", M{"a": "%"}, `invalid percent-encoding (at 4)`},
+		{`percent: standard escape before raw percent`, Configuration{PercentDecodeValues: true, AllowStandardEscapes: true}, `a:'\x25zz',b:%`, "", M{"a": "%zz", "b": "%"}, `invalid percent-encoding (at 4)`},
+		{`percent: standard escape decoded`, Configuration{PercentDecodeValues: true, AllowStandardEscapes: true}, `a:'\x2541\u0025'`, "", M{"a": "A%"}, `invalid percent-encoding (at 10)`},
+		{`duplicates: first wins`, Configuration{DuplicateKeys: DuplicateKeysFirstWins, WildcardKey: "*"}, `alfa:1,alfa:2,*:x,*:y`, "", M{"alfa": "1"}, ``},
+		{`duplicates: last wins`, Configuration{DuplicateKeys: DuplicateKeysLastWins}, `alfa:1,bravo,alfa:2`, "", M{"alfa": "2", "bravo": ""}, ``},
+		{`duplicates: collect`, Configuration{DuplicateKeys: DuplicateKeysCollect}, `alfa:1,alfa:2`, "", M{"alfa": "1"}, ``},
+		{`unicode whitespace: trimmed`, Configuration{NamePosition: NameFirst, UnicodeWhitespace: true}, "\u3000alfa\u00A0,\u00A0bravo\u00A0:\u3000charlie delta\u2003", "alfa", M{"bravo": "charlie delta"}, ``},
+		{`unicode whitespace: escaped`, Configuration{UnicodeWhitespace: true}, "alfa:x\\\u00A0,bravo:y\\\\\u00A0", "", M{"alfa": "x\u00A0", "bravo": `y\`}, ``},
+		{`unicode whitespace: invisible inside`, Configuration{UnicodeWhitespace: true, StrictInvisibleChars: true}, "\u00A0al\u00A0fa\u00A0", "", M{"al\u00A0fa": ""}, `invisible character in key (at 5)`},
+		{`unicode whitespace: off`, Configuration{StrictInvisibleChars: true}, "\u00A0alfa", "", M{"\u00A0alfa": ""}, `invisible character in key (at 1)`},
+		{`multiline: indented`, Configuration{NamePosition: NameFirst, Multiline: true}, "alfa,\n\t\tbravo:'charlie\n\t\tdelta',\n\t\techo", "alfa", M{"bravo": "charlie delta", "echo": ""}, ``},
+		{`multiline: error position`, Configuration{Multiline: true}, "alfa,\n\t\t:bravo", "", M{"alfa": ""}, `empty key (at 6)`},
+	}
+	for _, test := range tests {
+		t.Run(test.testName, func(t *testing.T) {
+			name, opts, err := test.conf.Parse(test.tag)
+			if err != nil {
+				ae := err.Error()
+				if test.error == "" {
+					t.Errorf("** Parse(%q) error %q, wanted no error", test.tag, ae)
+				} else if ae != test.error {
+					t.Errorf("** Parse(%q) error %q, wanted error %q", test.tag, ae, test.error)
+				}
+			} else if test.error != "" {
+				t.Errorf("** Parse(%q) no error, wanted error %q", test.tag, test.error)
+			}
+			if name != test.name {
+				t.Errorf("** Parse(%q) name = %q, wanted %q", test.tag, name, test.name)
+			}
+			if !reflect.DeepEqual(opts, test.opts) {
+				t.Errorf("** Parse(%q) opts = %q, wanted %q", test.tag, opts, test.opts)
+			}
+		})
+	}
+}
+
+func TestConfiguration_ParseDirectivesFunc(t *testing.T) {
+	conf := Configuration{NamePosition: NameLast, DirectivePrefix: '#', ContinuationKey: "++", ContinuationJoiner: " "}
+	var items, directives []string
+	err := conf.ParseDirectivesFunc(`alfa:x, #version:2, ++:y, #strict, #bad, bravo`, func(key, value string) error {
+		items = append(items, key, value)
+		return nil
+	}, func(key, value string) error {
+		directives = append(directives, key, value)
+		if key == "bad" {
+			return errSimulated
+		}
+		return nil
+	})
+	const expErr = `bad: simulated error (at 35)`
+	if err == nil || err.Error() != expErr {
+		t.Errorf("** err = %v, wanted %v", err, expErr)
+	}
+	if expected := []string{"alfa", "x y", "", "bravo"}; !reflect.DeepEqual(items, expected) {
+		t.Errorf("** items = %q, wanted %q", items, expected)
+	}
+	if expected := []string{"version", "2", "strict", "", "bad", ""}; !reflect.DeepEqual(directives, expected) {
+		t.Errorf("** directives = %q, wanted %q", directives, expected)
+	}
+}
+
+func TestConfiguration_ParseResult_duplicateWildcard(t *testing.T) {
+	conf := Configuration{WildcardKey: "*", DuplicateKeys: DuplicateKeysLastWins}
+	r, err := conf.ParseResult(`*:a,*:b`)
+	if expected := (Result{Wildcard: "b", HasWildcard: true, Tag: `*:a,*:b`, Conf: conf}); err != nil || !reflect.DeepEqual(r, expected) {
+		t.Errorf("** ParseResult = %+v, %v, wanted %+v", r, err, expected)
+	}
+}
+
+func TestConfiguration_ParseResult(t *testing.T) {
+	conf := Configuration{NamePosition: NameFirst, WildcardKey: "*"}
+	var tests = []struct {
+		tag      string
+		expected Result
+	}{
+		{`alfa,bravo`, Result{Name: "alfa", Options: M{"bravo": ""}, Conf: conf}},
+		{`alfa,*,bravo`, Result{Name: "alfa", Options: M{"bravo": ""}, HasWildcard: true, Conf: conf}},
+		{`,*:inline`, Result{Wildcard: "inline", HasWildcard: true, Conf: conf}},
+		{`alfa,'*':x`, Result{Name: "alfa", Wildcard: "x", HasWildcard: true, Conf: conf}},
+	}
+	for _, test := range tests {
+		actual, err := conf.ParseResult(test.tag)
+		test.expected.Tag = test.tag
+		if err != nil {
+			t.Errorf("** ParseResult(%q) error %v", test.tag, err)
+		}
+		if !reflect.DeepEqual(actual, test.expected) {
+			t.Errorf("** ParseResult(%q) = %+v, wanted %+v", test.tag, actual, test.expected)
+		}
+	}
+}
+
+func TestConfiguration_ParseTrusted(t *testing.T) {
+	conf := Configuration{NamePosition: NameFirst, WildcardKey: "*"}
+	for _, tag := range []string{``, `alfa`, `alfa,bravo:charlie,delta:'e, f',g\,h,*:rest`} {
+		name, opts := conf.ParseTrusted(tag)
+		en, eo, err := conf.Parse(tag)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if name != en || !reflect.DeepEqual(opts, eo) {
+			t.Errorf("** ParseTrusted(%q) = %q, %v, wanted %q, %v", tag, name, opts, en, eo)
+		}
+	}
+
+	var tests = []struct {
+		tag  string
+		name string
+		opts map[string]string
+	}{
+		{`alfa,b\q:1,b\q:2`, "alfa", M{"bq": "2"}},
+		{"alfa,b\u200bc,d\\", "alfa", M{"b\u200bc": "", "d": ""}},
+		{`'alfa,:x,\`, "alfa,:x,", nil},
+	}
+	for _, test := range tests {
+		name, opts := conf.ParseTrusted(test.tag)
+		if name != test.name || !reflect.DeepEqual(opts, test.opts) {
+			t.Errorf("** ParseTrusted(%q) = %q, %v, wanted %q, %v", test.tag, name, opts, test.name, test.opts)
+		}
+	}
+}
+
+func TestConfiguration_ParseOrdered(t *testing.T) {
+	var tests = []struct {
+		conf     Configuration
+		tag      string
+		name     string
+		expected []Option
+		error    string
+	}{
+		{Configuration{}, ``, "", nil, ``},
+		{Configuration{NamePosition: NameFirst}, `alfa,zulu:1,bravo,mike:'x,y'`, "alfa", []Option{{"zulu", "1"}, {"bravo", ""}, {"mike", "x,y"}}, ``},
+		{Configuration{NamePosition: NameLast, WildcardKey: "*"}, `zulu,*:rest,alfa`, "alfa", []Option{{"zulu", ""}, {"*", "rest"}}, ``},
+		{Configuration{}, `zulu,alfa,zulu:2`, "", []Option{{"zulu", ""}, {"alfa", ""}}, `zulu: duplicate option key (at 11)`},
+		{Configuration{DuplicateKeys: DuplicateKeysFirstWins}, `zulu,alfa,zulu:2`, "", []Option{{"zulu", ""}, {"alfa", ""}}, ``},
+		{Configuration{DuplicateKeys: DuplicateKeysLastWins}, `zulu,alfa,zulu:2`, "", []Option{{"zulu", "2"}, {"alfa", ""}}, ``},
+		{Configuration{DuplicateKeys: DuplicateKeysCollect}, `zulu,alfa,zulu:2`, "", []Option{{"zulu", ""}, {"alfa", ""}, {"zulu", "2"}}, ``},
+	}
+	for _, test := range tests {
+		name, opts, err := test.conf.ParseOrdered(test.tag)
+		if err != nil {
+			if ae := err.Error(); ae != test.error {
+				t.Errorf("** ParseOrdered(%q) error %q, wanted %q", test.tag, ae, test.error)
+			}
+		} else if test.error != "" {
+			t.Errorf("** ParseOrdered(%q) no error, wanted %q", test.tag, test.error)
+		}
+		if name != test.name || !reflect.DeepEqual(opts, test.expected) {
+			t.Errorf("** ParseOrdered(%q) = %q, %v, wanted %q, %v", test.tag, name, opts, test.name, test.expected)
+		}
+	}
+}
+
+func TestConfiguration_ParseMulti(t *testing.T) {
+	var tests = []struct {
+		conf     Configuration
+		tag      string
+		name     string
+		expected map[string][]string
+		error    string
+	}{
+		{Configuration{}, ``, "", nil, ``},
+		{Configuration{NamePosition: NameFirst, WildcardKey: "*"}, `alfa,bravo:1,*`, "alfa", map[string][]string{"bravo": {"1"}, "*": {""}}, ``},
+		{Configuration{}, `alfa:1,alfa:2`, "", map[string][]string{"alfa": {"1"}}, `alfa: duplicate option key (at 8)`},
+		{Configuration{DuplicateKeys: DuplicateKeysFirstWins}, `alfa:1,alfa:2`, "", map[string][]string{"alfa": {"1"}}, ``},
+		{Configuration{DuplicateKeys: DuplicateKeysLastWins}, `alfa:1,alfa:2`, "", map[string][]string{"alfa": {"2"}}, ``},
+		{Configuration{DuplicateKeys: DuplicateKeysCollect}, `alfa:1,bravo,alfa:2`, "", map[string][]string{"alfa": {"1", "2"}, "bravo": {""}}, ``},
+	}
+	for _, test := range tests {
+		name, opts, err := test.conf.ParseMulti(test.tag)
+		if err != nil {
+			if ae := err.Error(); ae != test.error {
+				t.Errorf("** ParseMulti(%q) error %q, wanted %q", test.tag, ae, test.error)
+			}
+		} else if test.error != "" {
+			t.Errorf("** ParseMulti(%q) no error, wanted %q", test.tag, test.error)
+		}
+		if name != test.name || !reflect.DeepEqual(opts, test.expected) {
+			t.Errorf("** ParseMulti(%q) = %q, %v, wanted %q, %v", test.tag, name, opts, test.name, test.expected)
+		}
+	}
+}
+
+func TestConfiguration_ParseFunc_NameLast_order(t *testing.T) {
+	var items []string
+	err := Configuration{NamePosition: NameLast}.ParseFunc(`alfa,bravo:charlie,delta`, func(key, value string) error {
+		items = append(items, key, value)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []string{"alfa", "", "bravo", "charlie", "", "delta"}
+	if !reflect.DeepEqual(items, expected) {
+		t.Errorf("** items = %q, wanted %q", items, expected)
+	}
+}
+
+func TestConfiguration_Lookup(t *testing.T) {
+	conf := Configuration{NamePosition: NameFirst, ContinuationKey: "+"}
+	var tests = []struct {
+		tag   string
+		key   string
+		value string
+		ok    bool
+		error string
+	}{
+		{`name,a:1,b:2`, "b", "2", true, ``},
+		{`name,a:1,a:2`, "a", "1", true, ``},
+		{`name,a,b,'x`, "a", "", true, ``},
+		{`name,a:x,+:y,b`, "a", "xy", true, ``},
+		{`name,a:1`, "", "name", true, ``},
+		{`name,a:1`, "b", "", false, ``},
+		{`name,:x,a:1,'b`, "a", "1", true, `empty key (at 6)`},
+	}
+	for _, test := range tests {
+		value, ok, err := conf.Lookup(test.tag, test.key)
+		var errStr string
+		if err != nil {
+			errStr = err.Error()
+		}
+		if value != test.value || ok != test.ok || errStr != test.error {
+			t.Errorf("** Lookup(%q, %q) = %q, %v, %q, wanted %q, %v, %q", test.tag, test.key, value, ok, errStr, test.value, test.ok, test.error)
+		}
+	}
+}
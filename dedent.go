@@ -0,0 +1,74 @@
+package tagparser
+
+import "strings"
+
+// Dedent turns a tag written across several indented lines, like in a raw
+// string literal, into a single line: common leading indentation and trailing
+// whitespace are removed from each line, blank lines are dropped, and the
+// remaining lines are joined with a space. A line ending with an unescaped
+// backslash is joined to the next one without a space and without the
+// backslash, e.g. to split a long word.
+//
+// Escaped trailing whitespace, like in `a:x\ `, is kept along with its
+// backslash, so such a line is not a continuation.
+//
+// The first line is not considered when computing the common indentation,
+// since it usually starts right after the opening quote. Tags without
+// newlines are returned as is.
+func Dedent(tag string) string {
+	if strings.IndexByte(tag, '\n') < 0 {
+		return tag
+	}
+	lines := strings.Split(tag, "\n")
+	prefix, found := "", false
+	for _, line := range lines[1:] {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		n := len(line) - len(strings.TrimLeft(line, " \t"))
+		if !found {
+			prefix, found = line[:n], true
+		} else {
+			i := 0
+			for i < len(prefix) && i < n && prefix[i] == line[i] {
+				i++
+			}
+			prefix = prefix[:i]
+		}
+	}
+
+	var b strings.Builder
+	b.Grow(len(tag))
+	sep := ""
+	for i, line := range lines {
+		line = trimLineEnd(line)
+		if line == "" {
+			continue
+		}
+		if i > 0 {
+			line = line[len(prefix):]
+		}
+		if b.Len() > 0 {
+			b.WriteString(sep)
+		}
+		n := len(line) - len(strings.TrimRight(line, `\`))
+		if n%2 == 1 {
+			b.WriteString(line[:len(line)-1])
+			sep = ""
+		} else {
+			b.WriteString(line)
+			sep = " "
+		}
+	}
+	return b.String()
+}
+
+// trimLineEnd removes trailing whitespace from a line, except for a space or
+// tab escaped with a backslash.
+func trimLineEnd(line string) string {
+	t := strings.TrimRight(line, " \t\r")
+	if n := len(t) - len(strings.TrimRight(t, `\`)); n%2 == 1 && len(t) < len(line) {
+		return line[:len(t)+1]
+	}
+	return t
+}
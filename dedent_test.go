@@ -0,0 +1,27 @@
+package tagparser
+
+import "testing"
+
+func TestDedent(t *testing.T) {
+	var tests = []struct {
+		tag      string
+		expected string
+	}{
+		{``, ``},
+		{`a, b`, `a, b`},
+		{"a,\n\tb,\n\tc", `a, b, c`},
+		{"\n\t\ta,\n\t\tb\n\t", `a, b`},
+		{"a,\n    desc:'long\n      text'", `a, desc:'long   text'`},
+		{"a,\n\tpat:abc\\\n\tdef", `a, pat:abcdef`},
+		{"a,\n\tpat:abc\\\\\n\tdef", `a, pat:abc\\ def`},
+		{"a,  \r\n\t b,\n\n\t  c", `a, b,  c`},
+		{"a,\n\t b,\n  c", "a, \t b,   c"},
+		{"a:x\\ \n\tb", `a:x\  b`},
+		{"a:x\\\t \n\tb:y\\\\ \n\tc", "a:x\\\t b:y\\\\ c"},
+	}
+	for _, test := range tests {
+		if actual := Dedent(test.tag); actual != test.expected {
+			t.Errorf("** Dedent(%q) = %q, wanted %q", test.tag, actual, test.expected)
+		}
+	}
+}
@@ -0,0 +1,407 @@
+package tagparser
+
+import (
+	"errors"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// ErrorCode identifies the kind of a parse error. Codes, together with
+// Error.Pos, are the stable part of errors returned by this package: they
+// will not change between versions, while messages might. Match on codes
+// rather than on message strings; see CodeForMessage for migrating existing
+// code.
+type ErrorCode string
+
+const (
+	// CodeUnterminatedQuote: a quote is opened but never closed.
+	CodeUnterminatedQuote ErrorCode = "unterminated-quote"
+	// CodeInvalidQuote: a quoted part is followed by non-whitespace text.
+	CodeInvalidQuote ErrorCode = "invalid-quote"
+	// CodeUnterminatedEscape: the tag ends with a backslash.
+	CodeUnterminatedEscape ErrorCode = "unterminated-escape"
+	// CodeInvalidEscape: a character outside Configuration.EscapableChars is
+	// escaped.
+	CodeInvalidEscape ErrorCode = "invalid-escape"
+	// CodeMalformedEscape: a \x or \u escape sequence under
+	// Configuration.AllowStandardEscapes lacks hex digits or encodes a
+	// surrogate; Pos is the first offending character.
+	CodeMalformedEscape ErrorCode = "malformed-escape"
+	// CodeEmptyKey: an item has a value but no key.
+	CodeEmptyKey ErrorCode = "empty-key"
+	// CodeDuplicateKey: a key occurs more than once; Cause is ErrDuplicateKey.
+	CodeDuplicateKey ErrorCode = "duplicate-key"
+	// CodeDuplicateKeyCase: under Configuration.FoldKeys, a key occurs more
+	// than once with different spellings; Cause is *DuplicateKeyCaseError.
+	CodeDuplicateKeyCase ErrorCode = "duplicate-key-case"
+	// CodeNegatedValue: an item negated with Configuration.NegationPrefix has
+	// a value.
+	CodeNegatedValue ErrorCode = "negated-value"
+	// CodeInvisibleChar: a key contains an invisible character. Only
+	// reported in Result.Warnings unless
+	// Configuration.StrictInvisibleChars is set.
+	CodeInvisibleChar ErrorCode = "invisible-char"
+	// CodeQuotedName: a name is quoted under QuotedNamesError.
+	CodeQuotedName ErrorCode = "quoted-name"
+	// CodeOrphanContinuation: a continuation item has no preceding item.
+	CodeOrphanContinuation ErrorCode = "orphan-continuation"
+	// CodeCallback: a callback returned an error, which is stored in Cause.
+	CodeCallback ErrorCode = "callback"
+	// CodeMalformedStructTag: a struct tag does not consist of `key:"value"`
+	// pairs, see StructTagParser.
+	CodeMalformedStructTag ErrorCode = "malformed-struct-tag"
+	// CodeExpandValue: Configuration.ExpandValue returned an error, which is
+	// stored in Cause.
+	CodeExpandValue ErrorCode = "expand-value"
+	// CodeInvalidPercent: a value has a malformed %XX sequence under
+	// Configuration.PercentDecodeValues.
+	CodeInvalidPercent ErrorCode = "invalid-percent"
+	// CodeUnterminatedBracket: a bracket is opened but never closed in a
+	// list value under Configuration.ListValues, or in a value passed to
+	// Unnest.
+	CodeUnterminatedBracket ErrorCode = "unterminated-bracket"
+	// CodeInvalidBracket: a bracketed part of a value passed to Unnest is
+	// followed by non-whitespace text, or closed with a mismatched bracket,
+	// or a list value split by Result.Values has nested brackets.
+	CodeInvalidBracket ErrorCode = "invalid-bracket"
+	// CodeInvalidKey: Configuration.KeyValidator rejected a key; Cause is the
+	// error it returned.
+	CodeInvalidKey ErrorCode = "invalid-key"
+	// CodeLimitExceeded: the tag exceeds Configuration.MaxLength, MaxOptions
+	// or MaxNestingDepth; Cause is ErrLimitExceeded.
+	CodeLimitExceeded ErrorCode = "limit-exceeded"
+	// CodeDeprecatedKey: a key is listed in Configuration.DeprecatedKeys;
+	// Cause is ErrDeprecatedKey. Only reported in Result.Warnings.
+	CodeDeprecatedKey ErrorCode = "deprecated-key"
+	// CodeQuotedEmptyValue: a value is written as empty quotes under
+	// Configuration.WarnQuotedEmptyValues; Cause is ErrQuotedEmptyValue.
+	// Only reported in Result.Warnings.
+	CodeQuotedEmptyValue ErrorCode = "quoted-empty-value"
+	// CodeUnknownKey: a key is not listed in Configuration.KnownKeys, with
+	// ErrUnknownKey cause, or in a Schema, with *SchemaError cause.
+	CodeUnknownKey ErrorCode = "unknown-key"
+	// CodeMissingKey: a key or name required by a Schema is missing; Cause is
+	// *SchemaError.
+	CodeMissingKey ErrorCode = "missing-key"
+	// CodeInvalidValue: a value does not match its KeySpec; Cause is
+	// *SchemaError.
+	CodeInvalidValue ErrorCode = "invalid-value"
+	// CodeConflictingKeys: two keys, or a key and the name, of the same
+	// Schema.Exclusive group are both present; Cause is *SchemaError.
+	CodeConflictingKeys ErrorCode = "conflicting-keys"
+	// CodeKeyOrder: a key comes after a key listed later in a Schema with
+	// Ordered; Cause is *SchemaError.
+	CodeKeyOrder ErrorCode = "key-order"
+)
+
+var errorMessages = map[ErrorCode]string{
+	CodeUnterminatedQuote:   "unterminated quote",
+	CodeInvalidQuote:        "invalid quote",
+	CodeUnterminatedEscape:  "unterminated escape sequence",
+	CodeInvalidEscape:       "invalid escape character",
+	CodeMalformedEscape:     "malformed escape sequence",
+	CodeEmptyKey:            "empty key",
+	CodeDuplicateKeyCase:    "duplicate option key differing only in case",
+	CodeNegatedValue:        "negated key with a value",
+	CodeInvisibleChar:       "invisible character in key",
+	CodeQuotedName:          "quoted name",
+	CodeOrphanContinuation:  "continuation without preceding item",
+	CodeMalformedStructTag:  "malformed struct tag",
+	CodeInvalidPercent:      "invalid percent-encoding",
+	CodeUnterminatedBracket: "unterminated bracket",
+	CodeInvalidBracket:      "invalid bracket",
+}
+
+// Message returns the current message for syntax error codes, or an empty
+// string for CodeDuplicateKey, CodeCallback, schema and unknown codes, whose
+// messages come from Error.Cause. The message of CodeDuplicateKeyCase is the
+// prefix of its Cause's message.
+func (c ErrorCode) Message() string {
+	return errorMessages[c]
+}
+
+// CodeForMessage maps an error message produced by this package to its code,
+// to help migrate code that matches on message strings. It accepts either
+// Error.Msg or the full Error() text, including the ErrorInfo prefix and the
+// position suffix. Returns an empty string for unrecognized messages.
+func CodeForMessage(msg string) ErrorCode {
+	if i := strings.LastIndex(msg, " (at "); i >= 0 && strings.HasSuffix(msg, ")") {
+		if _, err := strconv.Atoi(msg[i+5 : len(msg)-1]); err == nil {
+			msg = msg[:i]
+		}
+	}
+	if strings.Contains(msg, CodeDuplicateKeyCase.Message()) {
+		return CodeDuplicateKeyCase
+	}
+	if msg == ErrDuplicateKey.Error() || strings.HasSuffix(msg, ": "+ErrDuplicateKey.Error()) {
+		return CodeDuplicateKey
+	}
+	if strings.HasSuffix(msg, ": "+ErrLimitExceeded.Error()) {
+		return CodeLimitExceeded
+	}
+	for code, m := range errorMessages {
+		if msg == m || strings.HasSuffix(msg, ": "+m) {
+			return code
+		}
+	}
+	return ""
+}
+
+// ErrorStyle selects the output format of Error.Format.
+type ErrorStyle int
+
+const (
+	// ErrorCompact is the format of Error(), e.g.
+	// `User.Email json: empty key (at 2)`.
+	ErrorCompact ErrorStyle = iota
+	// ErrorGoVet mimics compiler and go vet diagnostics, e.g.
+	// `User.Email json:1:2: empty key [empty-key]`. The tag is treated as
+	// a single line; the location is "tag" when Info is empty.
+	ErrorGoVet
+	// ErrorJSON is a single-line JSON object with code, pos (0-based),
+	// message, tag and any non-empty Info fields and suggestion.
+	ErrorJSON
+	// ErrorVerbose is the compact message followed by the tag with a caret
+	// under the error position and the suggestion, if any, for humans
+	// reading logs and test failures. Only the line containing the error is
+	// shown for multi-line tags, shortened to some context around the error
+	// if it is long:
+	//
+	//	unterminated quote (at 3)
+	//	    a,'b,c
+	//	      ^
+	//	    hint: add a closing quote: a,'b',c
+	ErrorVerbose
+)
+
+// Format renders the error in the given style. The Compact style is
+// equivalent to Error().
+func (e *Error) Format(style ErrorStyle) string {
+	switch style {
+	case ErrorGoVet:
+		loc := e.Info.String()
+		if loc == "" {
+			loc = "tag"
+		}
+		return loc + ":1:" + strconv.Itoa(e.Pos+1) + ": " + e.message() + " [" + string(e.Code) + "]"
+	case ErrorJSON:
+		b := []byte(`{"code":`)
+		b = appendJSONString(b, string(e.Code))
+		b = append(b, `,"pos":`...)
+		b = strconv.AppendInt(b, int64(e.Pos), 10)
+		b = append(b, `,"message":`...)
+		b = appendJSONString(b, e.message())
+		b = append(b, `,"tag":`...)
+		b = appendJSONString(b, e.Tag)
+		if e.Info.Struct != "" {
+			b = append(b, `,"struct":`...)
+			b = appendJSONString(b, e.Info.Struct)
+		}
+		if e.Info.Field != "" {
+			b = append(b, `,"field":`...)
+			b = appendJSONString(b, e.Info.Field)
+		}
+		if e.Info.Namespace != "" {
+			b = append(b, `,"namespace":`...)
+			b = appendJSONString(b, e.Info.Namespace)
+		}
+		if e.Suggestion != "" {
+			b = append(b, `,"suggestion":`...)
+			b = appendJSONString(b, e.Suggestion)
+		}
+		return string(append(b, '}'))
+	case ErrorVerbose:
+		var b strings.Builder
+		b.WriteString(e.Error())
+		before, after := snippet(e.Tag, e.Pos)
+		b.WriteString("\n    ")
+		b.WriteString(before)
+		b.WriteString(after)
+		b.WriteString("\n    ")
+		for _, c := range before {
+			if c == '\t' {
+				b.WriteByte('\t')
+			} else {
+				b.WriteByte(' ')
+			}
+		}
+		b.WriteByte('^')
+		if e.Suggestion != "" {
+			b.WriteString("\n    hint: ")
+			b.WriteString(e.Suggestion)
+		}
+		return b.String()
+	default:
+		return e.Error()
+	}
+}
+
+// MarshalJSON implements json.Marshaler with the ErrorJSON style.
+func (e *Error) MarshalJSON() ([]byte, error) {
+	return []byte(e.Format(ErrorJSON)), nil
+}
+
+// snippetContext is the number of characters ErrorVerbose shows on each side
+// of the error position in long lines.
+const snippetContext = 40
+
+// snippet returns the line of the tag containing pos, split at pos, and with
+// text farther than snippetContext characters from pos replaced by "...".
+func snippet(tag string, pos int) (before, after string) {
+	if pos > len(tag) {
+		pos = len(tag)
+	}
+	before, after = tag[:pos], tag[pos:]
+	if i := strings.LastIndexByte(before, '\n'); i >= 0 {
+		before = before[i+1:]
+	}
+	if i := strings.IndexByte(after, '\n'); i >= 0 {
+		after = after[:i]
+	}
+	if n := utf8.RuneCountInString(before); n > snippetContext {
+		for ; n > snippetContext; n-- {
+			_, size := utf8.DecodeRuneInString(before)
+			before = before[size:]
+		}
+		before = "..." + before
+	}
+	n := 0
+	for i := range after {
+		if n == snippetContext {
+			after = after[:i] + "..."
+			break
+		}
+		n++
+	}
+	return before, after
+}
+
+// message returns the error message without the info prefix and position.
+func (e *Error) message() string {
+	if e.Cause == nil {
+		return e.Msg
+	} else if e.Msg == "" {
+		return e.Cause.Error()
+	} else {
+		return e.Msg + ": " + e.Cause.Error()
+	}
+}
+
+// appendJSONString appends s to b as a JSON string.
+func appendJSONString(b []byte, s string) []byte {
+	const hex = "0123456789abcdef"
+	b = append(b, '"')
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case c == '"' || c == '\\':
+			b = append(b, '\\', c)
+		case c == '\n':
+			b = append(b, '\\', 'n')
+		case c == '\t':
+			b = append(b, '\\', 't')
+		case c < 0x20:
+			b = append(b, '\\', 'u', '0', '0', hex[c>>4], hex[c&0xF])
+		case c >= utf8.RuneSelf:
+			// Like encoding/json, replace invalid UTF-8 so that the
+			// output is valid JSON.
+			r, n := utf8.DecodeRuneInString(s[i:])
+			if r == utf8.RuneError && n == 1 {
+				b = append(b, `\ufffd`...)
+			} else {
+				b = append(b, s[i:i+n]...)
+				i += n - 1
+			}
+		default:
+			b = append(b, c)
+		}
+	}
+	return append(b, '"')
+}
+
+// ErrorList is the error returned under Configuration.CollectAllErrors,
+// listing every error found in the tag in the order of positions.
+type ErrorList struct {
+	Errors []*Error
+}
+
+// Error returns the messages of all errors separated by semicolons.
+func (l *ErrorList) Error() string {
+	var b strings.Builder
+	for i, e := range l.Errors {
+		if i > 0 {
+			b.WriteString("; ")
+		}
+		b.WriteString(e.Error())
+	}
+	return b.String()
+}
+
+// Format renders every error in the given style, one per line (or, with
+// ErrorVerbose, one per block of lines).
+func (l *ErrorList) Format(style ErrorStyle) string {
+	var b strings.Builder
+	for i, e := range l.Errors {
+		if i > 0 {
+			b.WriteByte('\n')
+		}
+		b.WriteString(e.Format(style))
+	}
+	return b.String()
+}
+
+// FormatError renders an error returned by this package in the given style,
+// like Error.Format and ErrorList.Format do, e.g. for CLI diagnostics. Other
+// errors, including nil, are rendered with Error() or as an empty string.
+func FormatError(err error, style ErrorStyle) string {
+	var list *ErrorList
+	var e *Error
+	if errors.As(err, &list) {
+		return list.Format(style)
+	} else if errors.As(err, &e) {
+		return e.Format(style)
+	} else if err != nil {
+		return err.Error()
+	}
+	return ""
+}
+
+// Unwrap returns the errors, so that errors.Is and errors.As examine each
+// of them.
+func (l *ErrorList) Unwrap() []error {
+	errs := make([]error, len(l.Errors))
+	for i, e := range l.Errors {
+		errs[i] = e
+	}
+	return errs
+}
+
+// sort orders the errors by position, since callbacks for items with
+// continuations are delayed.
+func (l *ErrorList) sort() {
+	sort.SliceStable(l.Errors, func(i, j int) bool {
+		return l.Errors[i].Pos < l.Errors[j].Pos
+	})
+}
+
+// addError records e in err, which is nil, *Error or *ErrorList. Without
+// collect, callers only add the first error; with collect, the first error
+// at each position is kept.
+func addError(err error, e *Error, collect bool) error {
+	if !collect {
+		return e
+	}
+	list, _ := err.(*ErrorList)
+	if list == nil {
+		list = &ErrorList{}
+	}
+	for _, prev := range list.Errors {
+		if prev.Pos == e.Pos {
+			return list // e.g. an unterminated quote is also a misplaced one
+		}
+	}
+	list.Errors = append(list.Errors, e)
+	return list
+}
@@ -0,0 +1,214 @@
+package tagparser
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestErrorCode(t *testing.T) {
+	errSimulated := errors.New("simulated")
+	var tests = []struct {
+		tag  string
+		conf Configuration
+		code ErrorCode
+		pos  int
+	}{
+		{`a,'b`, Configuration{}, CodeUnterminatedQuote, 2},
+		{`a,b'c'`, Configuration{}, CodeInvalidQuote, 3},
+		{`a,b\`, Configuration{}, CodeUnterminatedEscape, 3},
+		{`a,\b`, Configuration{}, CodeInvalidEscape, 3},
+		{`a,:b`, Configuration{}, CodeEmptyKey, 2},
+		{`a,a`, Configuration{}, CodeDuplicateKey, 2},
+		{"a,b\u200bc", Configuration{StrictInvisibleChars: true}, CodeInvisibleChar, 3},
+		{`'a',b`, Configuration{NamePosition: NameFirst, QuotedNames: QuotedNamesError}, CodeQuotedName, 0},
+		{`...:x`, Configuration{ContinuationKey: "..."}, CodeOrphanContinuation, 0},
+		{`fail`, Configuration{}, CodeCallback, 0},
+	}
+	for _, test := range tests {
+		var err error
+		if test.code == CodeCallback {
+			err = test.conf.ParseFunc(test.tag, func(key, value string) error { return errSimulated })
+		} else {
+			_, _, err = test.conf.Parse(test.tag)
+		}
+		var e *Error
+		if !errors.As(err, &e) {
+			t.Errorf("** Parse(%q) error %v, wanted *Error", test.tag, err)
+			continue
+		}
+		if e.Code != test.code || e.Pos != test.pos {
+			t.Errorf("** Parse(%q) code %q at %d, wanted %q at %d", test.tag, e.Code, e.Pos, test.code, test.pos)
+		}
+		if m := e.Code.Message(); m != "" && m != e.Msg {
+			t.Errorf("** Parse(%q) Msg = %q, wanted %q", test.tag, e.Msg, m)
+		}
+		if c := CodeForMessage(e.Error()); test.code != CodeCallback && c != test.code {
+			t.Errorf("** CodeForMessage(%q) = %q, wanted %q", e.Error(), c, test.code)
+		}
+	}
+}
+
+func TestCodeForMessage(t *testing.T) {
+	var tests = []struct {
+		msg      string
+		expected ErrorCode
+	}{
+		{`empty key`, CodeEmptyKey},
+		{`empty key (at 2)`, CodeEmptyKey},
+		{`User.Email json: empty key (at 2)`, CodeEmptyKey},
+		{`duplicate option key`, CodeDuplicateKey},
+		{`a: duplicate option key (at 3)`, CodeDuplicateKey},
+		{`invalid quote (at x)`, ""},
+		{`something else`, ""},
+	}
+	for _, test := range tests {
+		if actual := CodeForMessage(test.msg); actual != test.expected {
+			t.Errorf("** CodeForMessage(%q) = %q, wanted %q", test.msg, actual, test.expected)
+		}
+	}
+}
+
+func TestError_Format(t *testing.T) {
+	var tests = []struct {
+		err      *Error
+		style    ErrorStyle
+		expected string
+	}{
+		{&Error{Tag: `a,:b`, Pos: 2, Msg: "empty key", Code: CodeEmptyKey}, ErrorCompact, `empty key (at 3)`},
+		{&Error{Tag: `a,:b`, Pos: 2, Msg: "empty key", Code: CodeEmptyKey}, ErrorGoVet, `tag:1:3: empty key [empty-key]`},
+		{&Error{Tag: `a,:b`, Pos: 2, Msg: "empty key", Code: CodeEmptyKey, Info: ErrorInfo{Struct: "User", Field: "Email", Namespace: "json"}}, ErrorGoVet, `User.Email json:1:3: empty key [empty-key]`},
+		{&Error{Tag: `a,:b`, Pos: 2, Msg: "empty key", Code: CodeEmptyKey}, ErrorJSON, `{"code":"empty-key","pos":2,"message":"empty key","tag":"a,:b"}`},
+		{&Error{Tag: "x\"\\\n\t\x01", Pos: 0, Msg: "a", Cause: ErrDuplicateKey, Code: CodeDuplicateKey, Info: ErrorInfo{Struct: "S", Field: "F", Namespace: "n"}}, ErrorJSON,
+			`{"code":"duplicate-key","pos":0,"message":"a: duplicate option key","tag":"x\"\\\n\t\u0001","struct":"S","field":"F","namespace":"n"}`},
+		{&Error{Tag: "\xffa\u00e9\xe2\x80,<\u2603>\xe9", Pos: 1, Msg: "a", Code: CodeEmptyKey}, ErrorJSON, "{\"code\":\"empty-key\",\"pos\":1,\"message\":\"a\",\"tag\":\"\\ufffda\u00e9\\ufffd\\ufffd,<\u2603>\\ufffd\"}"},
+	}
+	for _, test := range tests {
+		if actual := test.err.Format(test.style); actual != test.expected {
+			t.Errorf("** Format(%d) = %s, wanted %s", test.style, actual, test.expected)
+		}
+	}
+}
+
+func TestConfiguration_CollectAllErrors(t *testing.T) {
+	var tests = []struct {
+		conf  Configuration
+		tag   string
+		error string
+	}{
+		{Configuration{}, `a,b`, ``},
+		{Configuration{}, `a,:b,\x,c'`, `empty key (at 3); invalid escape character (at 7); unterminated quote (at 10)`},
+		{Configuration{}, `a,a,a`, `a: duplicate option key (at 3); a: duplicate option key (at 5)`},
+		{Configuration{ContinuationKey: "+", PercentDecodeValues: true}, `+:x,a,a:%zz,+:y`, `continuation without preceding item (at 1); a: duplicate option key (at 7); invalid percent-encoding (at 9)`},
+	}
+	for _, test := range tests {
+		test.conf.CollectAllErrors = true
+		_, _, err := test.conf.Parse(test.tag)
+		if err == nil {
+			if test.error != "" {
+				t.Errorf("** Parse(%q) error nil, wanted %q", test.tag, test.error)
+			}
+			continue
+		}
+		var list *ErrorList
+		if !errors.As(err, &list) || err.Error() != test.error {
+			t.Errorf("** Parse(%q) error %v, wanted *ErrorList %q", test.tag, err, test.error)
+		}
+	}
+
+	_, _, err := Configuration{CollectAllErrors: true}.Parse(`a,a,:b`)
+	var e *Error
+	if !errors.Is(err, ErrDuplicateKey) || !errors.As(err, &e) || e.Code != CodeDuplicateKey {
+		t.Errorf("** Parse error %v does not unwrap to the duplicate key *Error", err)
+	}
+}
+
+func TestError_Suggestion(t *testing.T) {
+	var tests = []struct {
+		tag        string
+		conf       Configuration
+		suggestion string
+	}{
+		{`a,'b,c`, Configuration{}, `add a closing quote: a,'b',c`},
+		{`a,'b\,c  `, Configuration{}, `add a closing quote: a,'b\,c'  `},
+		{`a;'b ;c`, Configuration{ItemSeparator: ';'}, `add a closing quote: a;'b' ;c`},
+		{`a,"b,c`, Configuration{QuoteChars: `'"`}, `add a closing quote: a,"b",c`},
+		{`a,b'c'`, Configuration{}, `quote the entire key or value instead`},
+		{`a,b\`, Configuration{}, `use \\ for a literal backslash`},
+		{`a,\d`, Configuration{}, `use \\d for a literal backslash`},
+		{`a,b\-c`, Configuration{EscapableChars: `,`}, `quote the text to escape -, or use \\- for a literal backslash`},
+		{`a,:b`, Configuration{}, `add a key before the separator, or remove the item`},
+		{"a,b\u200bc", Configuration{StrictInvisibleChars: true}, "remove the invisible characters: a,bc"},
+		{"a,b\u200bc,x:\u200b,\u200bd", Configuration{StrictInvisibleChars: true, CollectAllErrors: true}, "remove the invisible characters: a,bc,x:\u200b,d"},
+		{`...:x`, Configuration{ContinuationKey: "..."}, ``},
+		{`a,a`, Configuration{}, ``},
+	}
+	for _, test := range tests {
+		_, _, err := test.conf.Parse(test.tag)
+		var e *Error
+		if !errors.As(err, &e) || e.Suggestion != test.suggestion {
+			t.Errorf("** Parse(%q) error %#v, wanted suggestion %q", test.tag, err, test.suggestion)
+		}
+	}
+}
+
+func TestError_Format_verbose(t *testing.T) {
+	_, err := Parse("\ta,'b,c")
+	const expected = "unterminated quote (at 4)\n" +
+		"    \ta,'b,c\n" +
+		"    \t  ^\n" +
+		"    hint: add a closing quote: \ta,'b',c"
+	if actual := err.(*Error).Format(ErrorVerbose); actual != expected {
+		t.Errorf("** Format(ErrorVerbose) = %q, wanted %q", actual, expected)
+	}
+	e := &Error{Tag: `a`, Pos: 5, Msg: "custom"}
+	if actual, expected := e.Format(ErrorVerbose), "custom (at 6)\n    a\n     ^"; actual != expected {
+		t.Errorf("** Format(ErrorVerbose) = %q, wanted %q", actual, expected)
+	}
+	if actual, expected := err.(*Error).Format(ErrorJSON), `{"code":"unterminated-quote","pos":3,"message":"unterminated quote","tag":"\ta,'b,c","suggestion":"add a closing quote: \ta,'b',c"}`; actual != expected {
+		t.Errorf("** Format(ErrorJSON) = %s, wanted %s", actual, expected)
+	}
+	if data, jerr := err.(*Error).MarshalJSON(); jerr != nil || string(data) != err.(*Error).Format(ErrorJSON) {
+		t.Errorf("** MarshalJSON = %s, %v", data, jerr)
+	}
+}
+
+func TestError_Format_verboseSnippet(t *testing.T) {
+	long := strings.Repeat("é", 50)
+	var tests = []struct {
+		tag      string
+		pos      int
+		expected string
+	}{
+		{"a:x,\n:b,\nc", 5, "e (at 6)\n    :b,\n    ^"},
+		{"a:x,\n b,'c\n", 8, "e (at 9)\n     b,'c\n       ^"},
+		{"a:x,\n", 4, "e (at 5)\n    a:x,\n        ^"},
+		{long + ":'x", len(long) + 1, "e (at 102)\n    ..." + long[:78] + ":'x\n    " + strings.Repeat(" ", 43) + "^"},
+		{"a,'" + long, 2, "e (at 3)\n    a,'" + long[:78] + "...\n      ^"},
+	}
+	for _, test := range tests {
+		e := &Error{Tag: test.tag, Pos: test.pos, Msg: "e"}
+		if actual := e.Format(ErrorVerbose); actual != test.expected {
+			t.Errorf("** Format(ErrorVerbose) of %q at %d = %q, wanted %q", test.tag, test.pos, actual, test.expected)
+		}
+	}
+}
+
+func TestFormatError(t *testing.T) {
+	conf := Configuration{CollectAllErrors: true}
+	_, _, err := conf.Parse(`:a,:b`)
+	if actual, expected := FormatError(err, ErrorGoVet), "tag:1:1: empty key [empty-key]\ntag:1:4: empty key [empty-key]"; actual != expected {
+		t.Errorf("** FormatError(list) = %q, wanted %q", actual, expected)
+	}
+	_, err = Parse(`:a`)
+	if actual, expected := FormatError(fmt.Errorf("wrapped: %w", err), ErrorVerbose), "empty key (at 1)\n    :a\n    ^\n    hint: add a key before the separator, or remove the item"; actual != expected {
+		t.Errorf("** FormatError(error) = %q, wanted %q", actual, expected)
+	}
+	if actual, expected := FormatError(errors.New("other"), ErrorJSON), "other"; actual != expected {
+		t.Errorf("** FormatError(other) = %q, wanted %q", actual, expected)
+	}
+	if actual := FormatError(nil, ErrorCompact); actual != "" {
+		t.Errorf("** FormatError(nil) = %q, wanted empty", actual)
+	}
+}
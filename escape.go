@@ -0,0 +1,51 @@
+package tagparser
+
+import "unicode/utf8"
+
+// decodeEscape decodes the standard escape sequence s starts with (the part
+// after the backslash), returning the decoded character and the length of
+// the sequence. For a malformed \x or \u sequence, it returns the negated
+// offset of the offending character; for other characters, 0.
+//
+// A \xNN sequence decodes to a byte, not a code point; it is returned as a
+// negative rune so that appendRune can tell the two apart.
+func decodeEscape(s string) (r rune, n int) {
+	var digits int
+	switch s[0] {
+	case 'n':
+		return '\n', 1
+	case 't':
+		return '\t', 1
+	case 'r':
+		return '\r', 1
+	case '0':
+		return 0, 1
+	case 'x':
+		digits = 2
+	case 'u':
+		digits = 4
+	default:
+		return 0, 0
+	}
+	for i := 1; i <= digits; i++ {
+		if i >= len(s) || !isHex(s[i]) {
+			return 0, -i
+		}
+		r = r<<4 | rune(unhex(s[i]))
+	}
+	if s[0] == 'x' {
+		return -1 - r, digits + 1
+	}
+	if utf8.ValidRune(r) {
+		return r, digits + 1
+	}
+	return 0, -1 // surrogate half
+}
+
+// appendRune appends the character decoded by decodeEscape to b.
+func appendRune(b []byte, r rune) []byte {
+	if r < 0 {
+		return append(b, byte(-1-r))
+	}
+	return utf8.AppendRune(b, r)
+}
@@ -0,0 +1,47 @@
+package tagparser
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestConfiguration_AllowStandardEscapes(t *testing.T) {
+	conf := Configuration{AllowStandardEscapes: true}
+	var tests = []struct {
+		tag      string
+		expected string
+		error    string
+	}{
+		{`a:'x\ny'`, "x\ny", ``},
+		{`a:'\t\r\0\\\''`, "\t\r\x00\\'", ``},
+		{`a:'\x41\xff'`, "A\xff", ``},
+		{`a:'é€'`, "é€", ``},
+		{`a:'\u0027\u00e9'`, "'é", ``},
+		{`a:'\x4'`, "x4", `malformed escape sequence (at 7)`},
+		{`a:'\u12'`, "u12", `malformed escape sequence (at 8)`},
+		{`a:'\ud800'`, "ud800", `malformed escape sequence (at 6)`},
+		{`a:'\x`, "x", `malformed escape sequence (at 6)`},
+		{`a:'\q'`, "q", `invalid escape character (at 5)`},
+		{`a:x\n`, "xn", `invalid escape character (at 5)`},
+	}
+	for _, test := range tests {
+		_, opts, err := conf.Parse(test.tag)
+		if actual := opts["a"]; actual != test.expected {
+			t.Errorf("** Parse(%q) a = %q, wanted %q", test.tag, actual, test.expected)
+		}
+		if err != nil {
+			var e *Error
+			if ae := err.Error(); ae != test.error || !errors.As(err, &e) {
+				t.Errorf("** Parse(%q) error %q, wanted %q", test.tag, ae, test.error)
+			} else if e.Code == CodeMalformedEscape && e.Suggestion == "" {
+				t.Errorf("** Parse(%q) error without suggestion", test.tag)
+			}
+		} else if test.error != "" {
+			t.Errorf("** Parse(%q) no error, wanted %q", test.tag, test.error)
+		}
+	}
+
+	if _, opts, _ := (Configuration{}).Parse(`a:'\x41'`); opts["a"] != "x41" {
+		t.Errorf("** Parse without AllowStandardEscapes a = %q, wanted %q", opts["a"], "x41")
+	}
+}
@@ -0,0 +1,55 @@
+package tagparser_test
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/andreyvit/tagparser"
+)
+
+// CacheTag is a typed representation of a caching tag like
+// `cache:"ttl:5m,swr:30s,jitter:10s,key:'user:{id}'"`.
+type CacheTag struct {
+	TTL         time.Duration
+	SWR         time.Duration
+	Jitter      time.Duration
+	KeyTemplate string
+}
+
+var errUnknownCacheOption = errors.New("unknown cache option")
+
+// ParseCacheTag shows how to build a typed tag vocabulary on top of ParseFunc.
+// Conversion errors returned by the callback are reported with the position
+// of the offending option.
+func ParseCacheTag(tag string) (CacheTag, error) {
+	var c CacheTag
+	err := tagparser.ParseFunc(tag, func(key, value string) error {
+		var err error
+		switch key {
+		case "ttl":
+			c.TTL, err = time.ParseDuration(value)
+		case "swr":
+			c.SWR, err = time.ParseDuration(value)
+		case "jitter":
+			c.Jitter, err = time.ParseDuration(value)
+		case "key":
+			c.KeyTemplate = value
+		default:
+			err = errUnknownCacheOption
+		}
+		return err
+	})
+	return c, err
+}
+
+func Example_cacheTag() {
+	c, err := ParseCacheTag(`ttl:5m, swr:30s, jitter:10s, key:'user:{id}'`)
+	fmt.Printf("%+v %v\n", c, err)
+
+	_, err = ParseCacheTag(`ttl:5m, swr:soon`)
+	fmt.Println(err)
+
+	// Output: {TTL:5m0s SWR:30s Jitter:10s KeyTemplate:user:{id}} <nil>
+	// swr: time: invalid duration "soon" (at 8)
+}
@@ -0,0 +1,15 @@
+package tagparser
+
+// expandValue returns the value of the item expanded with ExpandValue,
+// reporting an error at the value if expansion fails.
+func (s *scanner) expandValue(it item, key, value string) string {
+	expanded, err := s.conf.ExpandValue(key, value)
+	if err != nil {
+		if s.err == nil || s.conf.CollectAllErrors {
+			pos, _ := trimmedSpan(&s.conf, s.tag, it.valueStart, it.valueEnd)
+			s.err = addError(s.err, &Error{Tag: s.tag, Pos: pos, Msg: key, Cause: err, Info: s.info, Code: CodeExpandValue}, s.conf.CollectAllErrors)
+		}
+		return value
+	}
+	return expanded
+}
@@ -0,0 +1,63 @@
+package tagparser
+
+import (
+	"errors"
+	"os"
+	"reflect"
+	"testing"
+)
+
+func TestConfiguration_ExpandValue(t *testing.T) {
+	errUndefined := errors.New("undefined variable")
+	vars := map[string]string{"HOME": "/home/alfa", "N": "10"}
+	expand := func(key, value string) (string, error) {
+		var err error
+		value = os.Expand(value, func(name string) string {
+			v, ok := vars[name]
+			if !ok && err == nil {
+				err = errUndefined
+			}
+			return v
+		})
+		return value, err
+	}
+	cont := Configuration{NamePosition: NameFirst, ExpandValue: expand, ContinuationKey: "+", ContinuationJoiner: "/"}
+	var tests = []struct {
+		conf  Configuration
+		tag   string
+		name  string
+		opts  map[string]string
+		error string
+	}{
+		{cont, `${HOME},dir:'${HOME}/x',max:$N,flag`, "${HOME}", M{"dir": "/home/alfa/x", "max": "10", "flag": ""}, ``},
+		{cont, `a,dir: ${HOME},+:'$N'`, "a", M{"dir": "/home/alfa/10"}, ``},
+		{cont, `a,dir: ${USER}/x ,max:$N`, "a", M{"dir": "${USER}/x", "max": "10"}, `dir: undefined variable (at 8)`},
+		{Configuration{ExpandValue: expand, PercentDecodeValues: true}, `a:%24N`, "", M{"a": "10"}, ``},
+		{Configuration{ExpandValue: expand, ListValues: true, NegationPrefix: '!'}, `a:[$N],!b`, "", M{"a": "[$N]", "b": "false"}, ``},
+		{Configuration{ExpandValue: expand, CollectAllErrors: true}, `a:$X,b:$Y`, "", M{"a": "$X", "b": "$Y"}, `a: undefined variable (at 3); b: undefined variable (at 8)`},
+	}
+	for _, test := range tests {
+		name, opts, err := test.conf.Parse(test.tag)
+		if err != nil {
+			ae := err.Error()
+			if test.error == "" {
+				t.Errorf("** Parse(%q) error %q, wanted no error", test.tag, ae)
+			} else if ae != test.error {
+				t.Errorf("** Parse(%q) error %q, wanted error %q", test.tag, ae, test.error)
+			} else if !errors.Is(err, errUndefined) {
+				t.Errorf("** Parse(%q) error %#v, wanted errUndefined cause", test.tag, err)
+			}
+		} else if test.error != "" {
+			t.Errorf("** Parse(%q) no error, wanted error %q", test.tag, test.error)
+		}
+		if name != test.name || !reflect.DeepEqual(opts, test.opts) {
+			t.Errorf("** Parse(%q) = %q, %q, wanted %q, %q", test.tag, name, opts, test.name, test.opts)
+		}
+	}
+
+	var e *Error
+	_, _, err := cont.Parse(`a,b:$X`)
+	if !errors.As(err, &e) || e.Code != CodeExpandValue {
+		t.Errorf("** Parse error %#v, wanted CodeExpandValue", err)
+	}
+}
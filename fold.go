@@ -0,0 +1,133 @@
+package tagparser
+
+import (
+	"fmt"
+	"strings"
+)
+
+// FoldKey returns s with ASCII letters converted to lower case, leaving all
+// other bytes intact. Unlike strings.ToLower, it never applies Unicode case
+// mapping, so keys fold identically regardless of language rules (no
+// Turkish dotless i surprises). FoldKey does not allocate if s has no upper
+// case ASCII letters.
+func FoldKey(s string) string {
+	n := len(s)
+	i := 0
+	for i < n && !(s[i] >= 'A' && s[i] <= 'Z') {
+		i++
+	}
+	if i == n {
+		return s
+	}
+	b := []byte(s)
+	for ; i < n; i++ {
+		if c := b[i]; c >= 'A' && c <= 'Z' {
+			b[i] = c + ('a' - 'A')
+		}
+	}
+	return string(b)
+}
+
+// foldKey returns the key as the parser reports it: folded with FoldKey under
+// FoldKeys, then passed through KeyNormalizer, if any.
+func (conf *Configuration) foldKey(key string) string {
+	if conf.FoldKeys {
+		key = FoldKey(key)
+	}
+	if conf.KeyNormalizer != nil {
+		key = conf.KeyNormalizer(key)
+	}
+	return key
+}
+
+// normalizesKeys reports whether the configuration rewrites keys, so that
+// the keys it lists have to be rewritten too before they are compared.
+func (conf *Configuration) normalizesKeys() bool {
+	return conf.FoldKeys || conf.KeyNormalizer != nil
+}
+
+// lookupKey returns the value for key in one of the key maps, like
+// KeyAliases. Under FoldKeys or KeyNormalizer, the map keys are rewritten
+// too, so that {"PK": "primaryKey"} matches `pk`.
+func (conf *Configuration) lookupKey(m map[string]string, key string) (string, bool) {
+	if v, ok := m[key]; ok || !conf.normalizesKeys() {
+		return v, ok
+	}
+	for k, v := range m {
+		if conf.keyEqual(k, key) {
+			return v, true
+		}
+	}
+	return "", false
+}
+
+// keyEqual reports whether k, a key listed in the configuration, matches the
+// parsed key, ignoring the case of k under FoldKeys. Unlike comparing with
+// FoldKey(k), it does not allocate, except with KeyNormalizer.
+func (conf *Configuration) keyEqual(k, key string) bool {
+	if conf.KeyNormalizer != nil {
+		return conf.foldKey(k) == key
+	}
+	if !conf.FoldKeys || len(k) != len(key) {
+		return k == key
+	}
+	for i := 0; i < len(k); i++ {
+		c := k[i]
+		if c >= 'A' && c <= 'Z' {
+			c += 'a' - 'A'
+		}
+		if c != key[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// DuplicateKeyCaseError is the Cause of CodeDuplicateKeyCase errors, reported
+// under Configuration.FoldKeys for repeated keys spelled differently, like
+// `omitEmpty` and `omitempty`, which are almost always typos rather than
+// intentional overrides. It unwraps to ErrDuplicateKey.
+type DuplicateKeyCaseError struct {
+	// First and Second are the spellings of the key, in tag order.
+	First, Second string
+	// FirstPos and SecondPos are 0-based positions of the keys within the tag.
+	FirstPos, SecondPos int
+}
+
+func (e *DuplicateKeyCaseError) Error() string {
+	return fmt.Sprintf("%s: %q (at %d) and %q (at %d)", CodeDuplicateKeyCase.Message(), e.First, e.FirstPos+1, e.Second, e.SecondPos+1)
+}
+
+func (e *DuplicateKeyCaseError) Unwrap() error {
+	return ErrDuplicateKey
+}
+
+// caseDuplicate rescans the tag to find the earlier occurrence of the key at
+// pos, returning an error if the two are spelled differently.
+func (s *scanner) caseDuplicate(pos int) *DuplicateKeyCaseError {
+	t := scanner{tag: s.tag, conf: s.conf}
+	var keys []string
+	var positions []int
+	var result *DuplicateKeyCaseError
+	t.scan(func(it item) {
+		if it.isName || it.isDirective || result != nil {
+			return
+		}
+		key := t.unquote(it.keyStart, it.keyEnd)
+		if it.isNegated {
+			key = key[1:]
+		}
+		key = strings.TrimPrefix(key, s.conf.StripKeyPrefix)
+		if it.keyStart == pos {
+			for j, k := range keys {
+				if k != key && FoldKey(k) == FoldKey(key) {
+					result = &DuplicateKeyCaseError{k, key, positions[j], pos}
+					return
+				}
+			}
+		}
+		keys = append(keys, key)
+		positions = append(positions, it.keyStart)
+	})
+	return result
+}
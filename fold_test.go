@@ -0,0 +1,121 @@
+package tagparser
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestFoldKey(t *testing.T) {
+	var tests = []struct {
+		input    string
+		expected string
+	}{
+		{``, ``},
+		{`omitempty`, `omitempty`},
+		{`OmitEmpty`, `omitempty`},
+		{`OMITEMPTY_1`, `omitempty_1`},
+		{"İD", "İd"},     // Turkish capital dotted I is left alone
+		{"ÉTAT", "État"}, // so is any other non-ASCII letter
+	}
+	for _, test := range tests {
+		actual := FoldKey(test.input)
+		if actual != test.expected {
+			t.Errorf("** FoldKey(%q) = %q, wanted %q", test.input, actual, test.expected)
+		}
+	}
+}
+
+func TestFoldKey_no_alloc(t *testing.T) {
+	allocs := testing.AllocsPerRun(10, func() {
+		FoldKey("omitempty")
+	})
+	if allocs != 0 {
+		t.Errorf("** FoldKey allocates %v times, wanted 0", allocs)
+	}
+}
+
+func TestConfiguration_FoldKeys(t *testing.T) {
+	var tests = []struct {
+		conf  Configuration
+		tag   string
+		opts  map[string]string
+		code  ErrorCode
+		error string
+	}{
+		{Configuration{FoldKeys: true}, `OmitEmpty,Size:10`, M{"omitempty": "", "size": "10"}, "", ``},
+		{Configuration{FoldKeys: true}, `omitempty,size:1,omitempty`, M{"omitempty": "", "size": "1"}, CodeDuplicateKey, `omitempty: duplicate option key (at 18)`},
+		{Configuration{FoldKeys: true}, `OmitEmpty,size:1, omitEmpty`, M{"omitempty": "", "size": "1"}, CodeDuplicateKeyCase,
+			`omitempty: duplicate option key differing only in case: "OmitEmpty" (at 1) and "omitEmpty" (at 18) (at 18)`},
+		{Configuration{NamePosition: NameFirst, FoldKeys: true, DirectivePrefix: '#', StripKeyPrefix: "x-"}, `Name,#Size,x-Size:1,size:2`, M{"size": "1"}, CodeDuplicateKeyCase,
+			`size: duplicate option key differing only in case: "Size" (at 12) and "size" (at 21) (at 21)`},
+		{Configuration{FoldKeys: true, ContinuationKey: "++"}, `Desc:a,desc:b,++:c,bravo`, M{"desc": "a", "bravo": ""}, CodeDuplicateKeyCase,
+			`desc: duplicate option key differing only in case: "Desc" (at 1) and "desc" (at 8) (at 8)`},
+	}
+	for _, test := range tests {
+		_, opts, err := test.conf.Parse(test.tag)
+		if err != nil {
+			if ae := err.Error(); ae != test.error {
+				t.Errorf("** Parse(%q) error %q, wanted %q", test.tag, ae, test.error)
+			}
+			if c := err.(*Error).Code; c != test.code {
+				t.Errorf("** Parse(%q) code %q, wanted %q", test.tag, c, test.code)
+			}
+			if !errors.Is(err, ErrDuplicateKey) {
+				t.Errorf("** Parse(%q) error %v, wanted %v", test.tag, err, ErrDuplicateKey)
+			}
+			if c := CodeForMessage(err.Error()); c != test.code {
+				t.Errorf("** CodeForMessage(%q) = %q, wanted %q", err.Error(), c, test.code)
+			}
+		} else if test.error != "" {
+			t.Errorf("** Parse(%q) no error, wanted %q", test.tag, test.error)
+		}
+		if !reflect.DeepEqual(opts, test.opts) {
+			t.Errorf("** Parse(%q) = %v, wanted %v", test.tag, opts, test.opts)
+		}
+	}
+}
+
+func TestConfiguration_KeyNormalizer(t *testing.T) {
+	// nfc composes the only decomposed letter the tests use, standing in for
+	// norm.NFC.String.
+	nfc := func(key string) string {
+		return strings.ReplaceAll(key, "e\u0301", "\u00e9")
+	}
+	var tests = []struct {
+		conf  Configuration
+		tag   string
+		opts  map[string]string
+		error string
+	}{
+		{Configuration{KeyNormalizer: nfc}, "caf\u00e9:1,cafe\u0301:2", M{"caf\u00e9": "1"}, "caf\u00e9: duplicate option key (at 9)"},
+		{Configuration{KeyNormalizer: nfc, FoldKeys: true}, "CAFE\u0301:1", M{"caf\u00e9": "1"}, ""},
+		{Configuration{KeyNormalizer: nfc, KnownKeys: []string{"cafe\u0301"}}, "caf\u00e9,tea", M{"caf\u00e9": "", "tea": ""}, "tea: unknown option key (at 7)"},
+		{Configuration{KeyNormalizer: nfc, KeyAliases: M{"cafe\u0301": "coffee"}}, "caf\u00e9:1", M{"coffee": "1"}, ""},
+	}
+	for _, test := range tests {
+		_, opts, err := test.conf.Parse(test.tag)
+		if !reflect.DeepEqual(opts, test.opts) || test.error == "" && err != nil || test.error != "" && (err == nil || err.Error() != test.error) {
+			t.Errorf("** Parse(%q) = %v, %v, wanted %v, %s", test.tag, opts, err, test.opts, test.error)
+		}
+	}
+
+	conf := Configuration{KeyNormalizer: nfc, DeprecatedKeys: M{"cafe\u0301": "use coffee"}}
+	if r, _ := conf.ParseResult("caf\u00e9"); len(r.Warnings) != 1 || r.Warnings[0].Code != CodeDeprecatedKey {
+		t.Errorf("** ParseResult warnings %v, wanted a deprecated key", r.Warnings)
+	}
+	schema := &Schema{Keys: []KeySpec{{Key: "cafe\u0301", Required: true}}}
+	if _, _, err := conf.ParseWithSchema("caf\u00e9", schema); err != nil {
+		t.Errorf("** ParseWithSchema error %v, wanted nil", err)
+	}
+	if _, err := conf.Build("", M{"cafe\u0301": ""}); !errors.Is(err, ErrUnrepresentable) {
+		t.Errorf("** Build error %v, wanted ErrUnrepresentable", err)
+	}
+	if err := (Configuration{KeyNormalizer: strings.ToUpper, WildcardKey: "x"}).Validate(); err == nil || err.Error() != `invalid configuration: KeyNormalizer changes "x", which would never match` {
+		t.Errorf("** Validate error %v", err)
+	}
+	if err := (Configuration{KeyNormalizer: nfc, WildcardKey: "*", ContinuationKey: "+"}).Validate(); err != nil {
+		t.Errorf("** Validate error %v, wanted nil", err)
+	}
+}
@@ -0,0 +1,76 @@
+package tagparser
+
+import "sort"
+
+// Format is gofmt for tags: it parses the tag and writes it back in canonical
+// form, which parses into the same name and options. Whitespace around items
+// and separators is removed, and keys, values and names are written bare when
+// possible and quoted with the first of QuoteChars otherwise, like Build does.
+// Options keep their original order; repeated keys are merged according to
+// DuplicateKeys. Directives and negated flags are kept, list values are
+// written as they are, and ExpandValue is not called.
+//
+// Keys are written as the parser reports them, so FoldKeys lowercases them,
+// KeyAliases replace aliases with the keys they stand for, and StripKeyPrefix
+// removes the prefix. A Multiline tag is joined into a single line.
+//
+// If the tag has errors, Format returns it unchanged along with the error, so
+// that a tidying tool never rewrites a tag it does not understand.
+func (conf Configuration) Format(tag string) (string, error) {
+	return conf.format(tag, false)
+}
+
+// FormatSorted is like Format, but sorts the options by key, after the
+// directives, which keep their order.
+func (conf Configuration) FormatSorted(tag string) (string, error) {
+	return conf.format(tag, true)
+}
+
+func (conf Configuration) format(tag string, sorted bool) (string, error) {
+	name, items, err := conf.formatItems(tag)
+	if err != nil {
+		return tag, err
+	}
+	if sorted {
+		sort.SliceStable(items, func(i, j int) bool {
+			if items[i].isDirective != items[j].isDirective {
+				return items[i].isDirective
+			}
+			return !items[i].isDirective && items[i].Key < items[j].Key
+		})
+	}
+	formatted, err := conf.build(name, items)
+	if err != nil {
+		return tag, err
+	}
+	return formatted, nil
+}
+
+// formatItems parses the tag into the name and the items to build, keeping
+// directives, negated flags and list values, without calling ExpandValue.
+func (conf Configuration) formatItems(tag string) (name string, items []buildItem, err error) {
+	conf.ExpandValue = nil
+	err = parseItems(tag, &conf, ErrorInfo{}, func(key, value string, it item) error {
+		if it.isName {
+			name = value
+			return nil
+		}
+		if conf.DuplicateKeys != DuplicateKeysCollect {
+			for i := range items {
+				if o := &items[i]; !o.isDirective && o.Key == key {
+					replace, err := conf.DuplicateKeys.duplicate()
+					if replace {
+						o.Value, o.isNegated, o.isList = value, it.isNegated, it.isList
+					}
+					return err
+				}
+			}
+		}
+		items = append(items, buildItem{Option: Option{key, value}, isNegated: it.isNegated, isList: it.isList})
+		return nil
+	}, func(key, value string) error {
+		items = append(items, buildItem{Option: Option{key, value}, isDirective: true})
+		return nil
+	}, nil)
+	return name, items, err
+}
@@ -0,0 +1,73 @@
+package tagparser
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestConfiguration_Format(t *testing.T) {
+	nameFirst := Configuration{NamePosition: NameFirst}
+	var tests = []struct {
+		conf     Configuration
+		tag      string
+		expected string
+		sorted   string
+	}{
+		{Configuration{}, ``, ``, ``},
+		{nameFirst, ` name , b : 'x' ,a:'y z', c: 'p,q'`, `name,b:x,a:y z,c:'p,q'`, `name,a:y z,b:x,c:'p,q'`},
+		{nameFirst, ` , a: `, `,a`, `,a`},
+		{nameFirst, `\ n\:`, `' n:'`, `' n:'`},
+		{Configuration{NamePosition: NameLast}, `b:1, a, n`, `b:1,a,n`, `a,b:1,n`},
+		{Configuration{QuoteChars: `"'`}, `a:'it"s', b:"x,y"`, `a:"it\"s",b:"x,y"`, `a:"it\"s",b:"x,y"`},
+		{Configuration{KeyValueSeparator: '=', ItemSeparator: ';'}, `b = 1 ; a=x:y,z`, `b=1;a=x:y,z`, `a=x:y,z;b=1`},
+		{Configuration{NegationPrefix: '!', DirectivePrefix: '#'}, `b:1, !a, '!c', !\!d, #v:2, #\#w`, `b:1,!a,'!c',!\!d,#v:2,#\#w`, `#v:2,#\#w,'!c',!\!d,!a,b:1`},
+		{Configuration{ListValues: true}, `r: [a, 'b,c'], s`, `r:[a, 'b,c'],s`, `r:[a, 'b,c'],s`},
+		{Configuration{ContinuationKey: "+"}, `d:a, +:b`, `d:ab`, `d:ab`},
+		{Configuration{DuplicateKeys: DuplicateKeysLastWins, NegationPrefix: '!'}, `a:1,b,!a`, `!a,b`, `!a,b`},
+		{Configuration{DuplicateKeys: DuplicateKeysFirstWins}, `b:1,a,b:2`, `b:1,a`, `a,b:1`},
+		{Configuration{DuplicateKeys: DuplicateKeysCollect}, `b:2,a,b:1`, `b:2,a,b:1`, `a,b:2,b:1`},
+		{Configuration{FoldKeys: true, KeyAliases: M{"pk": "primarykey"}, StripKeyPrefix: "x-"}, `PK, x-Size:10`, `primarykey,size:10`, `primarykey,size:10`},
+		{Configuration{GreedyLastValue: true}, `a, b:x,c`, `a,b:'x,c'`, `a,b:'x,c'`},
+		{Configuration{PercentDecodeValues: true}, `a:50%25`, `a:50%25`, `a:50%25`},
+		{Configuration{ExpandValue: func(key, value string) (string, error) { return "expanded", nil }}, `a:${X}`, `a:${X}`, `a:${X}`},
+		{Configuration{Multiline: true, NamePosition: NameFirst}, "\n\tn,\n\ta:1,\n\tb\n", `n,a:1,b`, `n,a:1,b`},
+	}
+	for _, test := range tests {
+		actual, err := test.conf.Format(test.tag)
+		if err != nil || actual != test.expected {
+			t.Errorf("** Format(%q) = %s, %v, wanted %s", test.tag, actual, err, test.expected)
+		}
+		sorted, err := test.conf.FormatSorted(test.tag)
+		if err != nil || sorted != test.sorted {
+			t.Errorf("** FormatSorted(%q) = %s, %v, wanted %s", test.tag, sorted, err, test.sorted)
+		}
+		name, opts, _ := test.conf.Parse(test.tag)
+		fname, fopts, err := test.conf.Parse(actual)
+		if err != nil || fname != name || !reflect.DeepEqual(fopts, opts) {
+			t.Errorf("** Parse(%s) = %q, %v, %v, wanted %q, %v", actual, fname, fopts, err, name, opts)
+		}
+	}
+}
+
+func TestConfiguration_Format_errors(t *testing.T) {
+	var tests = []struct {
+		conf  Configuration
+		tag   string
+		error string
+	}{
+		{Configuration{NamePosition: NameFirst}, ` a, 'b`, `unterminated quote (at 5)`},
+		{Configuration{}, `a,a`, `a: duplicate option key (at 3)`},
+		{Configuration{NamePosition: NameLast, GreedyLastValue: true}, `a:x,n`, `name "": cannot be represented in the tag after values with GreedyLastValue`},
+	}
+	for _, test := range tests {
+		actual, err := test.conf.Format(test.tag)
+		if err == nil || err.Error() != test.error || actual != test.tag {
+			t.Errorf("** Format(%q) = %s, %v, wanted the tag and %s", test.tag, actual, err, test.error)
+		}
+	}
+	_, err := Configuration{NamePosition: NameLast, GreedyLastValue: true}.Format(`a:x,n`)
+	if !errors.Is(err, ErrUnrepresentable) {
+		t.Errorf("** Format error %v, wanted ErrUnrepresentable", err)
+	}
+}
@@ -0,0 +1,162 @@
+package tagparser
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Grammar returns the syntax of tags accepted by the configuration in the EBNF
+// notation of the Go language specification, suitable for documentation and
+// for validating third-party implementations of the same dialect.
+//
+// Some rules cannot be expressed in EBNF and are given as comments.
+func (conf Configuration) Grammar() string {
+	var b strings.Builder
+	kvSep, itemSep := conf.separators()
+	ks, is := quoteEBNF(string(kvSep)), quoteEBNF(string(itemSep))
+	if conf.Multiline {
+		b.WriteString("/* lines are dedented and joined before parsing, see Dedent */\n")
+	}
+	if conf.SkipMarker != "" {
+		fmt.Fprintf(&b, "/* a tag of exactly %s marks a skipped field */\n", quoteEBNF(conf.SkipMarker))
+	}
+	switch conf.NamePosition {
+	case NameFirst:
+		fmt.Fprintf(&b, "tag          = [ name | item ] { %s [ item ] } . /* an item without %s is the name */\n", is, ks)
+	case NameLast:
+		fmt.Fprintf(&b, "tag          = { [ item ] %s } [ name | item ] . /* an item without %s is the name */\n", is, ks)
+	default:
+		fmt.Fprintf(&b, "tag          = [ item ] { %s [ item ] } .\n", is)
+	}
+	fmt.Fprintf(&b, "item         = key [ %s value ]", ks)
+	if conf.ContinuationKey != "" {
+		b.WriteString(" | continuation")
+	}
+	if conf.DirectivePrefix != 0 {
+		b.WriteString(" | directive")
+	}
+	if conf.NegationPrefix != 0 {
+		b.WriteString(" | negated")
+	}
+	if conf.WildcardKey != "" {
+		b.WriteString(" | wildcard")
+	}
+	b.WriteString(" .\n")
+	if conf.ContinuationKey != "" {
+		fmt.Fprintf(&b, "continuation = { ws } %s { ws } %s value . /* appended to the previous item's value */\n", quoteEBNF(conf.ContinuationKey), ks)
+	}
+	if conf.DirectivePrefix != 0 {
+		fmt.Fprintf(&b, "directive    = { ws } %s key [ %s value ] .\n", quoteEBNF(string(conf.DirectivePrefix)), ks)
+	}
+	if conf.NegationPrefix != 0 {
+		fmt.Fprintf(&b, "negated      = { ws } %s key . /* value is \"false\" */\n", quoteEBNF(string(conf.NegationPrefix)))
+	}
+	if conf.WildcardKey != "" {
+		fmt.Fprintf(&b, "wildcard     = key [ %s value ] . /* key equal to %s when unquoted; catch-all, not an option */\n", ks, quoteEBNF(conf.WildcardKey))
+	}
+	if conf.NamePosition != NameNone {
+		name := "text"
+		if conf.QuotedNames == QuotedNamesError {
+			name = "{ ws } { bare_char | escape } { ws }"
+		}
+		var notes []string
+		if conf.NameModifierSeparator != 0 {
+			notes = append(notes, fmt.Sprintf("split into modifiers at %s", quoteEBNF(string(conf.NameModifierSeparator))))
+		}
+		if conf.NameVariantSeparator != 0 {
+			notes = append(notes, fmt.Sprintf("split into locale variants at %s", quoteEBNF(string(conf.NameVariantSeparator))))
+		}
+		if len(notes) > 0 {
+			fmt.Fprintf(&b, "name         = %s . /* %s */\n", name, strings.Join(notes, "; "))
+		} else {
+			fmt.Fprintf(&b, "name         = %s .\n", name)
+		}
+	}
+	notes := []string{"must not be empty or contain invisible characters"}
+	if conf.StripKeyPrefix != "" {
+		notes = append(notes, fmt.Sprintf("a leading %s is removed", quoteEBNF(conf.StripKeyPrefix)))
+	}
+	if conf.FoldKeys {
+		notes = append(notes, "ASCII letters are folded to lower case")
+	}
+	if len(conf.KnownKeys) > 0 {
+		notes = append(notes, "one of "+strings.Join(conf.KnownKeys, ", "))
+	}
+	fmt.Fprintf(&b, "key          = text . /* %s */\n", strings.Join(notes, "; "))
+	notes = nil
+	if conf.GreedyLastValue {
+		notes = append(notes, fmt.Sprintf("in the last item with %s, may also contain %s", ks, is))
+	}
+	if conf.PercentDecodeValues {
+		notes = append(notes, "%XX sequences are decoded after unquoting")
+	}
+	value := fmt.Sprintf("text { %s text }", ks)
+	if conf.ListValues {
+		value = "list | " + value
+	}
+	if len(notes) > 0 {
+		fmt.Fprintf(&b, "value        = %s . /* %s */\n", value, strings.Join(notes, "; "))
+	} else {
+		fmt.Fprintf(&b, "value        = %s .\n", value)
+	}
+	if conf.ListValues {
+		fmt.Fprintf(&b, "list         = { ws } \"[\" [ text | list ] { %s [ text | list ] } \"]\" { ws } . /* returned as written, see Result.Values */\n", is)
+	}
+	quoteChars := conf.QuoteChars
+	if quoteChars == "" {
+		quoteChars = "'"
+	}
+	quotedEscape := "escape"
+	if conf.AllowStandardEscapes {
+		quotedEscape = "std_escape | escape"
+	}
+	var alts, quotes []string
+	for i := 0; i < len(quoteChars); i++ {
+		q := quoteEBNF(quoteChars[i : i+1])
+		alts = append(alts, q+" { quoted_char | "+quotedEscape+" } "+q)
+		quotes = append(quotes, q)
+	}
+	quoted := "quoted       = " + strings.Join(alts, " | ") + " .\n"
+	if conf.EscapableChars != "" {
+		b.WriteString("text         = { ws } [ quoted ] { bare_char | bare_escape } { ws } .\n")
+		b.WriteString(quoted)
+		b.WriteString("bare_escape  = `\\` (")
+		for i := 0; i < len(conf.EscapableChars); i++ {
+			if i > 0 {
+				b.WriteString(" |")
+			}
+			b.WriteString(" ")
+			b.WriteString(quoteEBNF(conf.EscapableChars[i : i+1]))
+		}
+		b.WriteString(" ) .\n")
+	} else {
+		b.WriteString("text         = { ws } [ quoted ] { bare_char | escape } { ws } .\n")
+		b.WriteString(quoted)
+	}
+	b.WriteString("escape       = `\\` escaped_char .\n")
+	if conf.UnicodeWhitespace {
+		b.WriteString("ws           = /* any Unicode whitespace, see unicode.IsSpace */ . /* trimmed unless escaped or quoted */\n")
+	} else {
+		b.WriteString("ws           = \" \" | \"\\t\" | \"\\n\" | \"\\v\" | \"\\f\" | \"\\r\" . /* trimmed unless escaped or quoted */\n")
+	}
+	fmt.Fprintf(&b, "bare_char    = /* any byte except %s %s %s `\\` */ .\n", is, ks, strings.Join(quotes, " "))
+	if len(quotes) == 1 {
+		fmt.Fprintf(&b, "quoted_char  = /* any byte except %s `\\` */ .\n", quotes[0])
+	} else {
+		b.WriteString("quoted_char  = /* any byte except the opening quote and `\\` */ .\n")
+	}
+	b.WriteString("escaped_char = /* any byte except ASCII letters and digits */ .\n")
+	if conf.AllowStandardEscapes {
+		b.WriteString("std_escape   = `\\` ( \"n\" | \"t\" | \"r\" | \"0\" | \"x\" hex hex | \"u\" hex hex hex hex ) .\n")
+		b.WriteString("hex          = /* 0-9, a-f, A-F */ .\n")
+	}
+	return b.String()
+}
+
+// quoteEBNF returns s as an EBNF string literal.
+func quoteEBNF(s string) string {
+	if strings.IndexByte(s, '"') < 0 {
+		return `"` + s + `"`
+	}
+	return "`" + s + "`"
+}
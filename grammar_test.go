@@ -0,0 +1,68 @@
+package tagparser
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestConfiguration_Grammar(t *testing.T) {
+	const expected = "tag          = [ item ] { \",\" [ item ] } .\n" +
+		"item         = key [ \":\" value ] .\n" +
+		"key          = text . /* must not be empty or contain invisible characters */\n" +
+		"value        = text { \":\" text } .\n" +
+		"text         = { ws } [ quoted ] { bare_char | escape } { ws } .\n" +
+		"quoted       = \"'\" { quoted_char | escape } \"'\" .\n" +
+		"escape       = `\\` escaped_char .\n" +
+		"ws           = \" \" | \"\\t\" | \"\\n\" | \"\\v\" | \"\\f\" | \"\\r\" . /* trimmed unless escaped or quoted */\n" +
+		"bare_char    = /* any byte except \",\" \":\" \"'\" `\\` */ .\n" +
+		"quoted_char  = /* any byte except \"'\" `\\` */ .\n" +
+		"escaped_char = /* any byte except ASCII letters and digits */ .\n"
+	actual := Configuration{}.Grammar()
+	if actual != expected {
+		t.Errorf("** Grammar() = \n%s\nwanted:\n%s", actual, expected)
+	}
+}
+
+func TestConfiguration_Grammar_variants(t *testing.T) {
+	var tests = []struct {
+		conf     Configuration
+		expected []string
+	}{
+		{Configuration{NamePosition: NameFirst}, []string{"tag          = [ name | item ] { \",\" [ item ] } . /* an item without \":\" is the name */\n", "name         = text .\n"}},
+		{Configuration{NamePosition: NameLast}, []string{"tag          = { [ item ] \",\" } [ name | item ] . /* an item without \":\" is the name */\n", "name         = text .\n"}},
+		{Configuration{ContinuationKey: "++"}, []string{"item         = key [ \":\" value ] | continuation .\n", "continuation = { ws } \"++\" { ws } \":\" value ."}},
+		{Configuration{NamePosition: NameFirst, NameModifierSeparator: '/'}, []string{"name         = text . /* split into modifiers at \"/\" */\n"}},
+		{Configuration{NamePosition: NameLast, QuotedNames: QuotedNamesError}, []string{"name         = { ws } { bare_char | escape } { ws } .\n"}},
+		{Configuration{EscapableChars: `,"`}, []string{"text         = { ws } [ quoted ] { bare_char | bare_escape } { ws } .\n", "bare_escape  = `\\` ( \",\" | `\"` ) .\n"}},
+		{Configuration{DirectivePrefix: '#', ContinuationKey: "+"}, []string{"item         = key [ \":\" value ] | continuation | directive .\n", "directive    = { ws } \"#\" key [ \":\" value ] .\n"}},
+		{Configuration{NegationPrefix: '!'}, []string{"item         = key [ \":\" value ] | negated .\n", "negated      = { ws } \"!\" key . /* value is \"false\" */\n"}},
+		{Configuration{ListValues: true}, []string{"value        = list | text { \":\" text } .\n", "list         = { ws } \"[\" [ text | list ] { \",\" [ text | list ] } \"]\" { ws } . /* returned as written, see Result.Values */\n"}},
+		{Configuration{GreedyLastValue: true}, []string{"value        = text { \":\" text } . /* in the last item with \":\", may also contain \",\" */\n"}},
+		{Configuration{ContinuationKey: `"`}, []string{"continuation = { ws } `\"` { ws } \":\" value ."}},
+		{Configuration{WildcardKey: "*"}, []string{"item         = key [ \":\" value ] | wildcard .\n", "wildcard     = key [ \":\" value ] . /* key equal to \"*\" when unquoted; catch-all, not an option */\n"}},
+		{Configuration{StripKeyPrefix: "x-"}, []string{"key          = text . /* must not be empty or contain invisible characters; a leading \"x-\" is removed */\n"}},
+		{Configuration{NamePosition: NameFirst, KeyValueSeparator: '=', ItemSeparator: ';', GreedyLastValue: true}, []string{
+			"tag          = [ name | item ] { \";\" [ item ] } . /* an item without \"=\" is the name */\n",
+			"item         = key [ \"=\" value ] .\n",
+			"value        = text { \"=\" text } . /* in the last item with \"=\", may also contain \";\" */\n",
+			"bare_char    = /* any byte except \";\" \"=\" \"'\" `\\` */ .\n",
+		}},
+		{Configuration{PercentDecodeValues: true, GreedyLastValue: true}, []string{"value        = text { \":\" text } . /* in the last item with \":\", may also contain \",\"; %XX sequences are decoded after unquoting */\n"}},
+		{Configuration{StripKeyPrefix: "x-", FoldKeys: true}, []string{"key          = text . /* must not be empty or contain invisible characters; a leading \"x-\" is removed; ASCII letters are folded to lower case */\n"}},
+		{Configuration{NamePosition: NameFirst, NameModifierSeparator: '/', NameVariantSeparator: ';'}, []string{"name         = text . /* split into modifiers at \"/\"; split into locale variants at \";\" */\n"}},
+		{Configuration{SkipMarker: "-"}, []string{"/* a tag of exactly \"-\" marks a skipped field */\n"}},
+		{Configuration{KnownKeys: []string{"a", "b"}}, []string{"key          = text . /* must not be empty or contain invisible characters; one of a, b */\n"}},
+		{Configuration{AllowStandardEscapes: true}, []string{"quoted       = \"'\" { quoted_char | std_escape | escape } \"'\" .\n", "std_escape   = `\\` ( \"n\" | \"t\" | \"r\" | \"0\" | \"x\" hex hex | \"u\" hex hex hex hex ) .\n"}},
+		{Configuration{QuoteChars: `'"`}, []string{"quoted       = \"'\" { quoted_char | escape } \"'\" | `\"` { quoted_char | escape } `\"` .\n", "bare_char    = /* any byte except \",\" \":\" \"'\" `\"` `\\` */ .\n", "quoted_char  = /* any byte except the opening quote and `\\` */ .\n"}},
+		{Configuration{UnicodeWhitespace: true}, []string{"ws           = /* any Unicode whitespace, see unicode.IsSpace */ . /* trimmed unless escaped or quoted */\n"}},
+		{Configuration{Multiline: true}, []string{"/* lines are dedented and joined before parsing, see Dedent */\n"}},
+	}
+	for _, test := range tests {
+		actual := test.conf.Grammar()
+		for _, e := range test.expected {
+			if !strings.Contains(actual, e) {
+				t.Errorf("** Grammar() of %+v does not contain %q, got:\n%s", test.conf, e, actual)
+			}
+		}
+	}
+}
@@ -0,0 +1,44 @@
+//go:build go1.23
+
+package tagparser
+
+import "iter"
+
+// All returns an iterator over the items of the tag, like ParseFunc reports
+// them: the name, if any, comes with an empty key. Errors are ignored, and
+// the best guess about malformed items is yielded, like with the other parse
+// funcs; use AllWithError to observe errors. All does not build a map, so
+// duplicate keys are yielded as is.
+//
+//	for key, value := range conf.All(tag) {
+//	    ...
+//	}
+func (conf Configuration) All(tag string) iter.Seq2[string, string] {
+	return func(yield func(key, value string) bool) {
+		parseFunc(tag, &conf, ErrorInfo{}, func(key, value string) error {
+			if !yield(key, value) {
+				return ErrStop
+			}
+			return nil
+		}, nil, nil)
+	}
+}
+
+// AllWithError is like All, but yields items as Options with nil errors,
+// followed by a single empty Option with the error, if the tag has one.
+// Errors come last because parsing never stops at them.
+func (conf Configuration) AllWithError(tag string) iter.Seq2[Option, error] {
+	return func(yield func(Option, error) bool) {
+		done := false
+		err := parseFunc(tag, &conf, ErrorInfo{}, func(key, value string) error {
+			if !yield(Option{key, value}, nil) {
+				done = true
+				return ErrStop
+			}
+			return nil
+		}, nil, nil)
+		if err != nil && !done {
+			yield(Option{}, err)
+		}
+	}
+}
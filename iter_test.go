@@ -0,0 +1,57 @@
+//go:build go1.23
+
+package tagparser
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestConfiguration_All(t *testing.T) {
+	conf := Configuration{NamePosition: NameFirst}
+	var items []string
+	for key, value := range conf.All(`name,a:1,'b,c`) {
+		items = append(items, key+"="+value)
+	}
+	if expected := []string{"=name", "a=1", "b,c="}; !reflect.DeepEqual(items, expected) {
+		t.Errorf("** All = %q, wanted %q", items, expected)
+	}
+
+	items = nil
+	for key := range conf.All(`name,a,b`) {
+		items = append(items, key)
+		if key == "a" {
+			break
+		}
+	}
+	if expected := []string{"", "a"}; !reflect.DeepEqual(items, expected) {
+		t.Errorf("** All with break = %q, wanted %q", items, expected)
+	}
+}
+
+func TestConfiguration_AllWithError(t *testing.T) {
+	var opts []Option
+	var errs []string
+	for o, err := range (Configuration{}).AllWithError(`a:1,:x,b`) {
+		if err != nil {
+			errs = append(errs, err.Error())
+		} else {
+			opts = append(opts, o)
+		}
+	}
+	if expected := []Option{{"a", "1"}, {"b", ""}}; !reflect.DeepEqual(opts, expected) {
+		t.Errorf("** AllWithError options = %v, wanted %v", opts, expected)
+	}
+	if expected := []string{"empty key (at 5)"}; !reflect.DeepEqual(errs, expected) {
+		t.Errorf("** AllWithError errors = %q, wanted %q", errs, expected)
+	}
+
+	var n int
+	for range (Configuration{}).AllWithError(`a,b,:x`) {
+		n++
+		break
+	}
+	if n != 1 {
+		t.Errorf("** AllWithError with break yielded %d times, wanted 1", n)
+	}
+}
@@ -0,0 +1,131 @@
+package tagparser
+
+import (
+	"errors"
+	"unicode"
+)
+
+// ErrUnknownKey is returned as Error.Cause for keys missing from
+// Configuration.KnownKeys.
+var ErrUnknownKey = errors.New("unknown option key")
+
+// checkKnown reports the key at pos if it is not one of KnownKeys.
+func (s *scanner) checkKnown(pos int, key string) {
+	if s.conf.trusted || key == s.conf.WildcardKey || s.err != nil && !s.conf.CollectAllErrors {
+		return
+	}
+	for _, k := range s.conf.KnownKeys {
+		if s.conf.keyEqual(k, key) {
+			return
+		}
+	}
+	var suggestion string
+	if k := closestKey(key, s.conf.KnownKeys); k != "" {
+		suggestion = "did you mean " + k + "?"
+	}
+	s.err = addError(s.err, &Error{Tag: s.tag, Pos: pos, Msg: key, Cause: ErrUnknownKey, Info: s.info, Code: CodeUnknownKey, Suggestion: suggestion}, s.conf.CollectAllErrors)
+}
+
+// ErrDeprecatedKey is the Error.Cause of warnings about keys listed in
+// Configuration.DeprecatedKeys.
+var ErrDeprecatedKey = errors.New("deprecated option key")
+
+// checkDeprecated reports the key at pos as a warning if it is one of
+// DeprecatedKeys.
+func (s *scanner) checkDeprecated(pos int, key string) {
+	if hint, ok := s.conf.lookupKey(s.conf.DeprecatedKeys, key); ok && s.conf.warnings != nil {
+		*s.conf.warnings = append(*s.conf.warnings, &Error{Tag: s.tag, Pos: pos, Msg: key, Cause: ErrDeprecatedKey, Info: s.info, Code: CodeDeprecatedKey, Suggestion: hint})
+	}
+}
+
+// ErrQuotedEmptyValue is the Error.Cause of warnings about values written as
+// empty quotes, see Configuration.WarnQuotedEmptyValues.
+var ErrQuotedEmptyValue = errors.New("quoted empty value")
+
+// checkQuotedEmpty reports the empty value in the given span of the tag as a
+// warning if it has been quoted.
+func (s *scanner) checkQuotedEmpty(start, end int, key string) {
+	ts, te := s.conf.trimSpace(s.tag[start:end])
+	if ts < te && s.conf.quotes().has(s.tag[start+ts]) && s.conf.warnings != nil {
+		*s.conf.warnings = append(*s.conf.warnings, &Error{Tag: s.tag, Pos: start + ts, Msg: key, Cause: ErrQuotedEmptyValue, Info: s.info, Code: CodeQuotedEmptyValue, Suggestion: "use the key alone, which parses the same"})
+	}
+}
+
+// ErrNotIdentifier is returned by ValidateIdentifier.
+var ErrNotIdentifier = errors.New("not a Go identifier")
+
+// ValidateIdentifier returns ErrNotIdentifier unless the key is a valid Go
+// identifier, for use as Configuration.KeyValidator.
+func ValidateIdentifier(key string) error {
+	for i, r := range key {
+		if !(r == '_' || unicode.IsLetter(r) || i > 0 && unicode.IsDigit(r)) {
+			return ErrNotIdentifier
+		}
+	}
+	if key == "" {
+		return ErrNotIdentifier
+	}
+	return nil
+}
+
+// validateKey reports the key at pos if KeyValidator rejects it.
+func (s *scanner) validateKey(pos int, key string) {
+	if s.conf.trusted || key == s.conf.WildcardKey || s.err != nil && !s.conf.CollectAllErrors {
+		return
+	}
+	if err := s.conf.KeyValidator(key); err != nil {
+		s.err = addError(s.err, &Error{Tag: s.tag, Pos: pos, Msg: key, Cause: err, Info: s.info, Code: CodeInvalidKey}, s.conf.CollectAllErrors)
+	}
+}
+
+// closestKey returns the key most similar to the given one, if it is close
+// enough to be a likely typo: at most 2 edits away, and 1 for short keys.
+func closestKey(key string, keys []string) string {
+	best, bestDist := "", 3
+	if len(key) <= 4 {
+		bestDist = 2
+	}
+	for _, k := range keys {
+		if d := editDistance(key, k); d < bestDist {
+			best, bestDist = k, d
+		}
+	}
+	return best
+}
+
+// editDistance returns the number of byte insertions, deletions,
+// substitutions and transpositions of adjacent bytes needed to turn a into b
+// (the optimal string alignment distance).
+func editDistance(a, b string) int {
+	// prev2, prev and cur are the last three rows of the distance matrix.
+	prev2 := make([]int, len(b)+1)
+	prev := make([]int, len(b)+1)
+	cur := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			d := prev[j-1] + cost
+			if x := prev[j] + 1; x < d {
+				d = x
+			}
+			if x := cur[j-1] + 1; x < d {
+				d = x
+			}
+			if i > 1 && j > 1 && a[i-1] == b[j-2] && a[i-2] == b[j-1] {
+				if x := prev2[j-2] + 1; x < d {
+					d = x
+				}
+			}
+			cur[j] = d
+		}
+		prev2, prev, cur = prev, cur, prev2
+	}
+	return prev[len(b)]
+}
@@ -0,0 +1,232 @@
+package tagparser
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestConfiguration_KnownKeys(t *testing.T) {
+	conf := Configuration{NamePosition: NameFirst, WildcardKey: "*", KnownKeys: []string{"omitempty", "string", "min", "max"}}
+	var tests = []struct {
+		tag        string
+		error      string
+		suggestion string
+	}{
+		{`name,omitempty,string,min:1,*`, ``, ``},
+		{`omitempty`, ``, ``},
+		{`name,omitempy`, `omitempy: unknown option key (at 6)`, `did you mean omitempty?`},
+		{`name,omitemtpy`, `omitemtpy: unknown option key (at 6)`, `did you mean omitempty?`},
+		{`name,mni:1`, `mni: unknown option key (at 6)`, `did you mean min?`},
+		{`name,mxx:1`, `mxx: unknown option key (at 6)`, `did you mean max?`},
+		{`name,xyz:1`, `xyz: unknown option key (at 6)`, ``},
+		{`name,inline`, `inline: unknown option key (at 6)`, ``},
+		{`name,:x,inline`, `empty key (at 6)`, `add a key before the separator, or remove the item`},
+	}
+	for _, test := range tests {
+		_, _, err := conf.Parse(test.tag)
+		if test.error == "" {
+			if err != nil {
+				t.Errorf("** Parse(%q) error %v, wanted nil", test.tag, err)
+			}
+			continue
+		}
+		var e *Error
+		if !errors.As(err, &e) || err.Error() != test.error || e.Suggestion != test.suggestion {
+			t.Errorf("** Parse(%q) error %#v, wanted %q with suggestion %q", test.tag, err, test.error, test.suggestion)
+		} else if e.Code != CodeEmptyKey && (e.Code != CodeUnknownKey || !errors.Is(err, ErrUnknownKey)) {
+			t.Errorf("** Parse(%q) error %#v, wanted CodeUnknownKey and ErrUnknownKey", test.tag, err)
+		}
+	}
+
+	conf.CollectAllErrors = true
+	_, _, err := conf.Parse(`a,b:1,c`)
+	if expErr := `b: unknown option key (at 3); c: unknown option key (at 7)`; err == nil || err.Error() != expErr {
+		t.Errorf("** Parse error %v, wanted %s", err, expErr)
+	}
+	if name, opts := conf.ParseTrusted(`a,b`); name != "a" || opts["b"] != "" {
+		t.Errorf("** ParseTrusted = %q, %v", name, opts)
+	}
+}
+
+func TestEditDistance(t *testing.T) {
+	var tests = []struct {
+		a, b     string
+		expected int
+	}{
+		{"", "", 0},
+		{"abc", "", 3},
+		{"", "abc", 3},
+		{"abc", "abc", 0},
+		{"abc", "abd", 1},
+		{"abc", "acb", 1},
+		{"omitempty", "omitempy", 1},
+		{"kitten", "sitting", 3},
+		{"ca", "abc", 3},
+	}
+	for _, test := range tests {
+		if actual := editDistance(test.a, test.b); actual != test.expected {
+			t.Errorf("** editDistance(%q, %q) = %d, wanted %d", test.a, test.b, actual, test.expected)
+		}
+	}
+}
+
+func TestSchemaError_Unwrap(t *testing.T) {
+	err := testSchema.Validate("a", M{"table": "t", "omitempy": ""})
+	if !errors.Is(err, ErrUnknownKey) {
+		t.Errorf("** Validate error %v does not unwrap to ErrUnknownKey", err)
+	}
+	err = testSchema.Validate("a", M{"table": ""})
+	if errors.Is(err, ErrUnknownKey) {
+		t.Errorf("** Validate error %v unwraps to ErrUnknownKey", err)
+	}
+}
+
+func TestConfiguration_KeyValidator(t *testing.T) {
+	conf := Configuration{NamePosition: NameFirst, WildcardKey: "*", KeyValidator: ValidateIdentifier}
+	var tests = []struct {
+		tag   string
+		error string
+	}{
+		{`na-me,_x1,é,*`, ``},
+		{`name,a-b`, `a-b: not a Go identifier (at 6)`},
+		{`name,x,1x :2`, `1x: not a Go identifier (at 8)`},
+		{`name,"a b"`, `"a b": not a Go identifier (at 6)`},
+	}
+	for _, test := range tests {
+		_, _, err := conf.Parse(test.tag)
+		if test.error == "" {
+			if err != nil {
+				t.Errorf("** Parse(%q) error %v, wanted nil", test.tag, err)
+			}
+			continue
+		}
+		var e *Error
+		if !errors.As(err, &e) || err.Error() != test.error {
+			t.Errorf("** Parse(%q) error %v, wanted %q", test.tag, err, test.error)
+		} else if e.Code != CodeInvalidKey || !errors.Is(err, ErrNotIdentifier) {
+			t.Errorf("** Parse(%q) error %#v, wanted CodeInvalidKey and ErrNotIdentifier", test.tag, err)
+		}
+	}
+
+	conf.KnownKeys = []string{"a-b"}
+	_, _, err := conf.Parse(`a,c-d,a-b`)
+	if expErr := `c-d: unknown option key (at 3)`; err == nil || err.Error() != expErr {
+		t.Errorf("** Parse error %v, wanted %s", err, expErr)
+	}
+	conf.CollectAllErrors = true
+	_, _, err = conf.Parse(`a,c-d,a-b`)
+	if expErr := `c-d: unknown option key (at 3); a-b: not a Go identifier (at 7)`; err == nil || err.Error() != expErr {
+		t.Errorf("** Parse error %v, wanted %s", err, expErr)
+	}
+	if _, opts := conf.ParseTrusted(`a,b-c`); opts["b-c"] != "" {
+		t.Errorf("** ParseTrusted = %v", opts)
+	}
+}
+
+func TestValidateIdentifier(t *testing.T) {
+	var tests = []struct {
+		key string
+		ok  bool
+	}{
+		{"x", true},
+		{"_x1", true},
+		{"Ünïcode", true},
+		{"", false},
+		{"1x", false},
+		{"a-b", false},
+		{"a b", false},
+	}
+	for _, test := range tests {
+		if err := ValidateIdentifier(test.key); (err == nil) != test.ok {
+			t.Errorf("** ValidateIdentifier(%q) = %v, wanted ok = %v", test.key, err, test.ok)
+		}
+	}
+}
+
+func TestConfiguration_WarnQuotedEmptyValues(t *testing.T) {
+	conf := Configuration{NamePosition: NameFirst, WarnQuotedEmptyValues: true, DeprecatedKeys: M{"len": ""}}
+	r, err := conf.ParseResult(`'',a:'',b:,c: '' ,len:'x'`)
+	if expected := (M{"a": "", "b": "", "c": "", "len": "x"}); err != nil || r.Name != "" || !reflect.DeepEqual(r.Options, expected) {
+		t.Errorf("** ParseResult = %q, %q, %v, wanted %q", r.Name, r.Options, err, expected)
+	}
+	var warnings []string
+	for _, w := range r.Warnings {
+		warnings = append(warnings, w.Error())
+	}
+	if actual, expected := strings.Join(warnings, "; "), `a: quoted empty value (at 6); c: quoted empty value (at 15); len: deprecated option key (at 19)`; actual != expected {
+		t.Errorf("** ParseResult warnings = %s, wanted %s", actual, expected)
+	}
+	if w := r.Warnings[0]; w.Code != CodeQuotedEmptyValue || !errors.Is(w, ErrQuotedEmptyValue) || w.Suggestion != "use the key alone, which parses the same" {
+		t.Errorf("** ParseResult warning = %+v", w)
+	}
+}
+
+func TestConfiguration_KeyAliases(t *testing.T) {
+	conf := Configuration{
+		NamePosition:   NameFirst,
+		FoldKeys:       true,
+		KnownKeys:      []string{"primarykey", "size", "index"},
+		KeyAliases:     map[string]string{"pk": "primarykey", "len": "size"},
+		DeprecatedKeys: map[string]string{"len": "use size instead", "index": ""},
+	}
+	r, err := conf.ParseResult(`id,PK,len:10`)
+	if expected := (M{"primarykey": "", "size": "10"}); err != nil || !reflect.DeepEqual(r.Options, expected) {
+		t.Errorf("** ParseResult = %q, %v, wanted %q", r.Options, err, expected)
+	}
+	if len(r.Warnings) != 1 || r.Warnings[0].Error() != `len: deprecated option key (at 7)` || r.Warnings[0].Suggestion != "use size instead" || r.Warnings[0].Code != CodeDeprecatedKey || !errors.Is(r.Warnings[0], ErrDeprecatedKey) {
+		t.Errorf("** ParseResult warnings = %v", r.Warnings)
+	}
+
+	r, err = conf.ParseResult(`id,index,pk,primaryKey`)
+	if expErr := `primarykey: duplicate option key (at 13)`; err == nil || err.Error() != expErr {
+		t.Errorf("** ParseResult error %v, wanted %s", err, expErr)
+	}
+	if len(r.Warnings) != 1 || r.Warnings[0].Error() != `index: deprecated option key (at 4)` || r.Warnings[0].Suggestion != "" {
+		t.Errorf("** ParseResult warnings = %v", r.Warnings)
+	}
+
+	var keys []string
+	conf.ParseFunc(`id,pk,len:1`, func(key, value string) error {
+		keys = append(keys, key)
+		return nil
+	})
+	if expected := []string{"", "primarykey", "size"}; !reflect.DeepEqual(keys, expected) {
+		t.Errorf("** ParseFunc keys = %q, wanted %q", keys, expected)
+	}
+
+	if r, _ = conf.ParseResult(`id,size:1`); r.Warnings != nil {
+		t.Errorf("** ParseResult warnings = %v, wanted none", r.Warnings)
+	}
+	conf.DeprecatedKeys = nil
+	if r, _ = conf.ParseResult(`id,len:1`); r.Warnings != nil || r.Options["size"] != "1" {
+		t.Errorf("** ParseResult = %+v", r)
+	}
+}
+
+func TestConfiguration_FoldKeys_camelCase(t *testing.T) {
+	conf := Configuration{
+		FoldKeys:       true,
+		KnownKeys:      []string{"primaryKey", "autoIncrement", "size"},
+		KeyAliases:     map[string]string{"PK": "primaryKey"},
+		DeprecatedKeys: map[string]string{"autoIncrement": "use a sequence instead"},
+	}
+	r, err := conf.ParseResult(`primaryKey,AutoIncrement`)
+	if expected := (M{"primarykey": "", "autoincrement": ""}); err != nil || !reflect.DeepEqual(r.Options, expected) {
+		t.Errorf("** ParseResult = %q, %v, wanted %q", r.Options, err, expected)
+	}
+	if len(r.Warnings) != 1 || r.Warnings[0].Error() != `autoincrement: deprecated option key (at 12)` {
+		t.Errorf("** ParseResult warnings = %v", r.Warnings)
+	}
+
+	_, err = conf.ParseResult(`pk,primaryKey`)
+	if expErr := `primarykey: duplicate option key (at 4)`; err == nil || err.Error() != expErr {
+		t.Errorf("** ParseResult error %v, wanted %s", err, expErr)
+	}
+
+	_, err = conf.ParseResult(`primaryKey,sise`)
+	if expErr := `sise: unknown option key (at 12)`; err == nil || err.Error() != expErr {
+		t.Errorf("** ParseResult error %v, wanted %s", err, expErr)
+	}
+}
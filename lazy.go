@@ -0,0 +1,149 @@
+package tagparser
+
+import "strconv"
+
+// lazyInline is the number of options a Tag stores without allocating.
+const lazyInline = 8
+
+// Tag is a parsed tag with lookup accessors, an alternative to a map for hot
+// paths. A Tag is immutable, so it is safe to cache per struct field and to
+// use concurrently.
+type Tag struct {
+	name   string
+	n      int
+	inline [lazyInline]Option
+	more   []Option
+}
+
+// Lazy tokenizes the tag once into a Tag. Lazy does not allocate for tags of
+// up to 8 options without escapes and quotes (as long as the configuration
+// does not need to rewrite keys or values, e.g. with FoldKeys on upper case
+// keys). Duplicate keys are handled according to DuplicateKeys; the
+// accessors return the first stored value of a key. The WildcardKey item is
+// stored like any other option.
+func (conf Configuration) Lazy(tag string) (Tag, error) {
+	var t Tag
+	err := parseFunc(tag, &conf, ErrorInfo{}, func(key, value string) error {
+		if key == "" {
+			t.name = value
+			return nil
+		}
+		if conf.DuplicateKeys != DuplicateKeysCollect {
+			if i := t.index(key); i >= 0 {
+				replace, err := conf.DuplicateKeys.duplicate()
+				if replace {
+					t.at(i).Value = value
+				}
+				return err
+			}
+		}
+		if t.n < lazyInline {
+			t.inline[t.n] = Option{key, value}
+		} else {
+			t.more = append(t.more, Option{key, value})
+		}
+		t.n++
+		return nil
+	}, nil, nil)
+	return t, err
+}
+
+// ParseOptions is Lazy in the shape of Parse, returning the name separately
+// and the options as a Tag instead of a map, so that code written against
+// Parse can switch to the allocation-free Tag. The Tag also reports the name.
+func (conf Configuration) ParseOptions(tag string) (name string, opts Tag, err error) {
+	opts, err = conf.Lazy(tag)
+	return opts.name, opts, err
+}
+
+func (t *Tag) at(i int) *Option {
+	if i < lazyInline {
+		return &t.inline[i]
+	}
+	return &t.more[i-lazyInline]
+}
+
+func (t *Tag) index(key string) int {
+	for i := 0; i < t.n; i++ {
+		if t.at(i).Key == key {
+			return i
+		}
+	}
+	return -1
+}
+
+// Name returns the name of the tag, always empty with NameNone.
+func (t *Tag) Name() string {
+	return t.name
+}
+
+// Len returns the number of options.
+func (t *Tag) Len() int {
+	return t.n
+}
+
+// Option returns the i-th option in tag order, 0 <= i < Len().
+func (t *Tag) Option(i int) Option {
+	return *t.at(i)
+}
+
+// Range calls f for each option in tag order, stopping if f returns false.
+func (t *Tag) Range(f func(key, value string) bool) {
+	for i := 0; i < t.n; i++ {
+		if o := t.at(i); !f(o.Key, o.Value) {
+			return
+		}
+	}
+}
+
+// Lookup returns the value of the option with the given key, and whether it
+// is present.
+func (t *Tag) Lookup(key string) (string, bool) {
+	if i := t.index(key); i >= 0 {
+		return t.at(i).Value, true
+	}
+	return "", false
+}
+
+// Get returns the value of the option with the given key, or an empty string
+// if it is missing.
+func (t *Tag) Get(key string) string {
+	v, _ := t.Lookup(key)
+	return v
+}
+
+// Has returns whether the option with the given key is present.
+func (t *Tag) Has(key string) bool {
+	return t.index(key) >= 0
+}
+
+// Bool returns the boolean value of the option with the given key: true for
+// a key without a value, the parsed value (see strconv.ParseBool) if it has
+// one, or def if the option is missing or the value is malformed.
+func (t *Tag) Bool(key string, def bool) bool {
+	v, ok := t.Lookup(key)
+	if !ok {
+		return def
+	} else if v == "" {
+		return true
+	}
+	b, err := strconv.ParseBool(v)
+	if err != nil {
+		return def
+	}
+	return b
+}
+
+// Int returns the integer value of the option with the given key, or def if
+// the option is missing or the value is not a decimal integer.
+func (t *Tag) Int(key string, def int) int {
+	v, ok := t.Lookup(key)
+	if !ok {
+		return def
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil {
+		return def
+	}
+	return n
+}
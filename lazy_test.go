@@ -0,0 +1,145 @@
+package tagparser
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestConfiguration_Lazy(t *testing.T) {
+	conf := Configuration{NamePosition: NameFirst}
+	tag, err := conf.Lazy(`email,omitempty,size:255,ratio:x,strict:false,nullable:yes,desc:'a, b'`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var tests = []struct {
+		actual   any
+		expected any
+	}{
+		{tag.Name(), "email"},
+		{tag.Len(), 6},
+		{tag.Option(2), Option{"ratio", "x"}},
+		{tag.Get("desc"), "a, b"},
+		{tag.Get("missing"), ""},
+		{tag.Has("omitempty"), true},
+		{tag.Has("email"), false},
+		{fmt.Sprintln(tag.Lookup("size")), "255 true\n"},
+		{fmt.Sprintln(tag.Lookup("missing")), " false\n"},
+		{tag.Bool("omitempty", false), true},
+		{tag.Bool("strict", true), false},
+		{tag.Bool("nullable", true), true},
+		{tag.Bool("nullable", false), false},
+		{tag.Bool("missing", true), true},
+		{tag.Int("size", 0), 255},
+		{tag.Int("ratio", 7), 7},
+		{tag.Int("missing", 7), 7},
+	}
+	for i, test := range tests {
+		if test.actual != test.expected {
+			t.Errorf("** #%d = %v, wanted %v", i, test.actual, test.expected)
+		}
+	}
+}
+
+func TestConfiguration_ParseOptions(t *testing.T) {
+	name, opts, err := Configuration{NamePosition: NameFirst}.ParseOptions(`email,size:255,'x`)
+	if name != "email" || opts.Name() != "email" || opts.Len() != 2 || opts.Get("size") != "255" || err == nil || err.Error() != "unterminated quote (at 16)" {
+		t.Errorf("** ParseOptions = %q, %+v, %v", name, opts, err)
+	}
+}
+
+func TestConfiguration_Lazy_many(t *testing.T) {
+	var tests = []struct {
+		conf     Configuration
+		tag      string
+		expected string
+		error    string
+	}{
+		{Configuration{}, `a:1,b,c,d,e,f,g,h,i:9,j:10,a:11`, `a=1 b= c= d= e= f= g= h= i=9 j=10`, `a: duplicate option key (at 28)`},
+		{Configuration{DuplicateKeys: DuplicateKeysLastWins}, `a:1,b,c,d,e,f,g,h,i:9,j:10,a:11,j:12`, `a=11 b= c= d= e= f= g= h= i=9 j=12`, ``},
+		{Configuration{DuplicateKeys: DuplicateKeysCollect}, `a:1,a:2`, `a=1 a=2`, ``},
+	}
+	for _, test := range tests {
+		tag, err := test.conf.Lazy(test.tag)
+		if err != nil {
+			if ae := err.Error(); ae != test.error {
+				t.Errorf("** Lazy(%q) error %q, wanted %q", test.tag, ae, test.error)
+			}
+		} else if test.error != "" {
+			t.Errorf("** Lazy(%q) no error, wanted %q", test.tag, test.error)
+		}
+		var actual string
+		for i := 0; i < tag.Len(); i++ {
+			if i > 0 {
+				actual += " "
+			}
+			o := tag.Option(i)
+			actual += o.Key + "=" + o.Value
+		}
+		if actual != test.expected {
+			t.Errorf("** Lazy(%q) = %s, wanted %s", test.tag, actual, test.expected)
+		}
+		if v := tag.Get("a"); v != tag.Option(0).Value {
+			t.Errorf("** Lazy(%q).Get(a) = %q", test.tag, v)
+		}
+	}
+}
+
+func TestConfiguration_Lazy_no_alloc(t *testing.T) {
+	conf := Configuration{NamePosition: NameFirst}
+	allocs := testing.AllocsPerRun(10, func() {
+		tag, _ := conf.Lazy(`email,omitempty,size:255,desc:x`)
+		tag.Int("size", 0)
+	})
+	if allocs != 0 {
+		t.Errorf("** Lazy allocates %v times, wanted 0", allocs)
+	}
+}
+
+func TestTag_Range(t *testing.T) {
+	tag, _ := Configuration{}.Lazy(`a,b,c,d,e,f,g,h,i,j`)
+	var keys []string
+	tag.Range(func(key, value string) bool {
+		keys = append(keys, key)
+		return key != "i"
+	})
+	if actual := strings.Join(keys, ","); actual != "a,b,c,d,e,f,g,h,i" {
+		t.Errorf("** Range keys = %s", actual)
+	}
+	var empty Tag
+	if empty.Len() != 0 || empty.Get("a") != "" {
+		t.Errorf("** zero Tag = %+v", empty)
+	}
+}
+
+const gormTag = `column:user_id;type:bigint;not null;index:idx_user;default:0;comment:'owner of the record'`
+
+func BenchmarkParse_gorm(b *testing.B) {
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		_, opts, err := Gorm().Parse(gormTag)
+		if err != nil || opts["index"] != "idx_user" {
+			b.Fatal(opts, err)
+		}
+	}
+}
+
+func BenchmarkParseOptions_gorm(b *testing.B) {
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		_, opts, err := Gorm().ParseOptions(gormTag)
+		if err != nil || opts.Get("index") != "idx_user" {
+			b.Fatal(opts.Len(), err)
+		}
+	}
+}
+
+func BenchmarkLazy_gorm(b *testing.B) {
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		tag, err := Gorm().Lazy(gormTag)
+		if err != nil || tag.Get("index") != "idx_user" {
+			b.Fatal(tag.Len(), err)
+		}
+	}
+}
@@ -0,0 +1,28 @@
+package tagparser
+
+import "sort"
+
+// ParseLenient is like Configuration.ParseLenient with NamePosition set to
+// NameFirst, like ParseName.
+func ParseLenient(tag string) (Result, []*Error) {
+	return nameFirst.ParseLenient(tag)
+}
+
+// ParseLenient parses the tag for display-oriented tools, like documentation
+// sites and editor hovers, that must show something for any input. It never
+// fails: the result is the best guess described in ParseFunc, and every
+// problem found, errors and warnings alike, is returned in the order of
+// positions, which is also stored in Result.Warnings.
+//
+// Strict parse funcs are not affected; use them to reject invalid tags.
+func (conf Configuration) ParseLenient(tag string) (Result, []*Error) {
+	conf.CollectAllErrors = true
+	r, err := conf.parseResult(tag, nil, nil)
+	if list, ok := err.(*ErrorList); ok {
+		r.Warnings = append(r.Warnings, list.Errors...)
+	}
+	sort.SliceStable(r.Warnings, func(i, j int) bool {
+		return r.Warnings[i].Pos < r.Warnings[j].Pos
+	})
+	return r, r.Warnings
+}
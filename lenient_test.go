@@ -0,0 +1,35 @@
+package tagparser
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseLenient(t *testing.T) {
+	var tests = []struct {
+		conf     Configuration
+		tag      string
+		name     string
+		opts     map[string]string
+		problems []string
+	}{
+		{nameFirst, `a,b:'c`, "a", M{"b": "c"}, []string{`unterminated quote (at 5)`}},
+		{nameFirst, `a,:x,b:\q,c`, "a", M{"b": "q", "c": ""}, []string{`empty key (at 3)`, `invalid escape character (at 9)`}},
+		{Configuration{NamePosition: NameFirst, DeprecatedKeys: M{"old": "use new"}}, `a,old,:x`, "a", M{"old": ""}, []string{`old: deprecated option key (at 3)`, `empty key (at 7)`}},
+		{Configuration{NamePosition: NameFirst, MaxOptions: 1}, `a,b,c`, "a", M{"b": ""}, []string{`more than 1 options: limit exceeded (at 5)`}},
+		{nameFirst, `a,b`, "a", M{"b": ""}, nil},
+	}
+	for _, test := range tests {
+		r, problems := test.conf.ParseLenient(test.tag)
+		var actual []string
+		for _, p := range problems {
+			actual = append(actual, p.Error())
+		}
+		if r.Name != test.name || !reflect.DeepEqual(r.Options, test.opts) || !reflect.DeepEqual(actual, test.problems) || len(r.Warnings) != len(problems) {
+			t.Errorf("** ParseLenient(%q) = %q, %v, %q, wanted %q, %v, %q", test.tag, r.Name, r.Options, actual, test.name, test.opts, test.problems)
+		}
+	}
+	if r, problems := ParseLenient(`a,'b`); r.Name != "a" || len(problems) != 1 {
+		t.Errorf("** ParseLenient = %+v, %v", r, problems)
+	}
+}
@@ -0,0 +1,15 @@
+package tagparser
+
+import "errors"
+
+// ErrLimitExceeded is the Error.Cause of CodeLimitExceeded errors, see
+// Configuration.MaxLength.
+var ErrLimitExceeded = errors.New("limit exceeded")
+
+// failLimit reports an exceeded limit at pos and stops parsing.
+func (s *scanner) failLimit(pos int, msg string) {
+	s.limited = true
+	if s.err == nil || s.conf.CollectAllErrors {
+		s.err = addError(s.err, &Error{Tag: s.tag, Pos: pos, Msg: msg, Cause: ErrLimitExceeded, Info: s.info, Code: CodeLimitExceeded}, s.conf.CollectAllErrors)
+	}
+}
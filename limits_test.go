@@ -0,0 +1,73 @@
+package tagparser
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestConfiguration_limits(t *testing.T) {
+	var tests = []struct {
+		conf  Configuration
+		tag   string
+		name  string
+		opts  map[string]string
+		error string
+	}{
+		{Configuration{MaxLength: 5}, `a,b:1`, "", M{"a": "", "b": "1"}, ``},
+		{Configuration{MaxLength: 5}, `a,b:12`, "", nil, `tag longer than 5 bytes: limit exceeded (at 6)`},
+		{Configuration{MaxLength: 5, Multiline: true}, "a,\n\tb", "", M{"a": "", "b": ""}, ``},
+		{Configuration{NamePosition: NameFirst, MaxOptions: 2}, `name,a,b`, "name", M{"a": "", "b": ""}, ``},
+		{Configuration{NamePosition: NameFirst, MaxOptions: 2}, `name,a,b,c,'d`, "name", M{"a": "", "b": ""}, `more than 2 options: limit exceeded (at 10)`},
+		{Configuration{NamePosition: NameLast, MaxOptions: 1}, `a,b,name`, "", M{"a": ""}, `more than 1 options: limit exceeded (at 3)`},
+		{Configuration{MaxOptions: 1, ContinuationKey: "+"}, `a:x,+:y`, "", M{"a": "x"}, `more than 1 options: limit exceeded (at 5)`},
+		{Configuration{MaxOptions: 1, ContinuationKey: "+"}, `a:x,b`, "", M{"a": "x"}, `more than 1 options: limit exceeded (at 5)`},
+		{Configuration{MaxOptions: 1, CollectAllErrors: true}, `:x,a,b`, "", nil, `empty key (at 1); more than 1 options: limit exceeded (at 4)`},
+		{Configuration{ListValues: true, MaxNestingDepth: 2}, `a:[[x]],b:[y]`, "", M{"a": "[[x]]", "b": "[y]"}, ``},
+		{Configuration{ListValues: true, MaxNestingDepth: 2}, `a:[[[x]]],b:[y]`, "", nil, `brackets nested deeper than 2: limit exceeded (at 5)`},
+	}
+	for _, test := range tests {
+		name, opts, err := test.conf.Parse(test.tag)
+		var errStr string
+		if err != nil {
+			errStr = err.Error()
+			if !errors.Is(err, ErrLimitExceeded) && !strings.HasPrefix(errStr, "empty key") {
+				t.Errorf("** Parse(%q) error %#v, wanted ErrLimitExceeded", test.tag, err)
+			}
+		}
+		if name != test.name || !reflect.DeepEqual(opts, test.opts) || errStr != test.error {
+			t.Errorf("** Parse(%q) = %q, %q, %q, wanted %q, %q, %q", test.tag, name, opts, errStr, test.name, test.opts, test.error)
+		}
+	}
+
+	_, _, err := Configuration{MaxOptions: 1}.Parse(`a,b`)
+	var e *Error
+	if !errors.As(err, &e) || e.Code != CodeLimitExceeded || CodeForMessage(err.Error()) != CodeLimitExceeded {
+		t.Errorf("** Parse error %#v, wanted CodeLimitExceeded", err)
+	}
+}
+
+func TestConfiguration_Unnest_limits(t *testing.T) {
+	conf := Configuration{MaxNestingDepth: 2}
+	var tests = []struct {
+		value    string
+		expected any
+		error    string
+	}{
+		{`[a,{b:[c]}]`, []any{"a", map[string]any{"b": nil}}, `brackets nested deeper than 2: limit exceeded (at 7)`},
+		{`{a:[b],c:[d,e]}`, map[string]any{"a": []any{"b"}, "c": []any{"d", "e"}}, ``},
+		{`[x,[y],[[z]],w]`, []any{"x", []any{"y"}, []any{nil}}, `brackets nested deeper than 2: limit exceeded (at 9)`},
+		{`[` + strings.Repeat(`[`, 100000), []any{[]any{nil}}, `brackets nested deeper than 2: limit exceeded (at 3)`},
+	}
+	for _, test := range tests {
+		actual, err := conf.Unnest(test.value)
+		var errStr string
+		if err != nil {
+			errStr = err.Error()
+		}
+		if errStr != test.error || !reflect.DeepEqual(actual, test.expected) {
+			t.Errorf("** Unnest(%.20q) = %#v, %q, wanted %#v, %q", test.value, actual, errStr, test.expected, test.error)
+		}
+	}
+}
@@ -0,0 +1,30 @@
+package tagparser
+
+import (
+	"bufio"
+	"io"
+	"strings"
+)
+
+// ParseLines parses a stream of tags, one per line, like a file produced by
+// grep, calling fn with the 1-based line number and the result of
+// conf.ParseResult for every non-empty line. Wrap compressed input in a
+// decompressing reader, like gzip.NewReader, first.
+//
+// Parse errors are passed to fn and do not stop the processing; the returned
+// error is a read error.
+func ParseLines(r io.Reader, conf Configuration, fn func(lineNo int, result Result, err error)) error {
+	br := bufio.NewReader(r)
+	for lineNo := 1; ; lineNo++ {
+		line, err := br.ReadString('\n')
+		if tag := strings.TrimSuffix(strings.TrimSuffix(line, "\n"), "\r"); tag != "" {
+			result, perr := conf.ParseResult(tag)
+			fn(lineNo, result, perr)
+		}
+		if err == io.EOF {
+			return nil
+		} else if err != nil {
+			return err
+		}
+	}
+}
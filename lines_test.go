@@ -0,0 +1,42 @@
+package tagparser
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+	"testing/iotest"
+)
+
+func TestParseLines(t *testing.T) {
+	const input = "alfa,bravo:1\r\n\n:x\ncharlie"
+	expected := []string{
+		`1 alfa map[bravo:1] <nil>`,
+		`3  map[] empty key (at 1)`,
+		`4 charlie map[] <nil>`,
+	}
+
+	var actual []string
+	err := ParseLines(strings.NewReader(input), Configuration{NamePosition: NameFirst}, func(lineNo int, result Result, err error) {
+		actual = append(actual, fmt.Sprintf("%d %s %v %v", lineNo, result.Name, result.Options, err))
+	})
+	if err != nil {
+		t.Errorf("** ParseLines error %v", err)
+	}
+	if !reflect.DeepEqual(actual, expected) {
+		t.Errorf("** ParseLines =\n%s\nwanted:\n%s", strings.Join(actual, "\n"), strings.Join(expected, "\n"))
+	}
+}
+
+func TestParseLines_errors(t *testing.T) {
+	errRead := errors.New("read failed")
+	var lines int
+	fn := func(lineNo int, result Result, err error) { lines++ }
+
+	err := ParseLines(io.MultiReader(strings.NewReader("alfa\nbravo"), iotest.ErrReader(errRead)), Configuration{}, fn)
+	if err != errRead || lines != 2 {
+		t.Errorf("** ParseLines error %v after %d lines, wanted %v after 2", err, lines, errRead)
+	}
+}
@@ -0,0 +1,37 @@
+package tagparser
+
+// Values returns the items of a list-valued option, or nil if the option is
+// missing. Under Configuration.ListValues, a bracketed value like
+// `[admin, editor, 'a,b']` is split into its items; any other value is split
+// at the item separator like SplitValue does, so `roles:'admin,editor'`
+// works too. Nested brackets are not split and are reported as
+// CodeInvalidBracket.
+//
+// The error, if present, is *Error positioned within the value.
+func (r Result) Values(key string) ([]string, error) {
+	value, ok := r.Options[key]
+	if !ok {
+		return nil, nil
+	}
+	_, itemSep := r.Conf.separators()
+	ts, te := r.Conf.trimSpace(value)
+	if !r.Conf.ListValues || ts == te || value[ts] != '[' {
+		return splitValue(value, itemSep, &r.Conf)
+	}
+	u := unnester{s: scanner{tag: value[:te], conf: r.Conf}, i: ts}
+	u.kvSep, u.itemSep = r.Conf.separators()
+	items := make([]string, 0)
+	u.items(0, func() {
+		start := u.i
+		end := u.text(1, false)
+		item := u.s.unquote(start, end)
+		if r.Conf.PercentDecodeValues {
+			item = u.s.percentDecode(item, start, end)
+		}
+		items = append(items, item)
+	})
+	if u.i < te {
+		u.s.fail(u.i, CodeInvalidBracket)
+	}
+	return items, u.s.result()
+}
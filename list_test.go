@@ -0,0 +1,46 @@
+package tagparser
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestResult_Values(t *testing.T) {
+	lists := Configuration{ListValues: true}
+	var tests = []struct {
+		conf     Configuration
+		tag      string
+		expected []string
+		error    string
+	}{
+		{lists, `x:[admin,editor,viewer]`, []string{"admin", "editor", "viewer"}, ``},
+		{lists, `x: [ admin , 'a,b' , c\,d, \' ] `, []string{"admin", "a,b", "c,d", "'"}, ``},
+		{lists, `x:[]`, []string{}, ``},
+		{lists, `x:[a,,b,]`, []string{"a", "", "b"}, ``},
+		{lists, `x:'admin,editor'`, []string{"admin", "editor"}, ``},
+		{lists, `x`, nil, ``},
+		{lists, `y`, nil, ``},
+		{lists, `x:[a,[b],c]`, []string{"a", "[b"}, `invalid bracket (at 7)`},
+		{lists, `x:[a] b`, []string{"a"}, `invalid bracket (at 5)`},
+		{Configuration{ListValues: true, KeyValueSeparator: '=', ItemSeparator: ';', PercentDecodeValues: true}, `x=[a%3B;b%zz]`, []string{"a;", "b%zz"}, `invalid percent-encoding (at 8)`},
+		{Configuration{ListValues: true, KeyValueSeparator: '='}, `x=[a:b,c]`, []string{"a:b", "c"}, ``},
+		{Configuration{}, `x:'[a,b]'`, []string{"[a", "b]"}, ``},
+	}
+	for _, test := range tests {
+		r, err := test.conf.ParseResult(test.tag)
+		if err != nil {
+			t.Fatalf("** ParseResult(%q) error %v", test.tag, err)
+		}
+		actual, err := r.Values("x")
+		if err != nil {
+			if ae := err.Error(); ae != test.error {
+				t.Errorf("** Values(%q) error %q, wanted %q", test.tag, ae, test.error)
+			}
+		} else if test.error != "" {
+			t.Errorf("** Values(%q) no error, wanted %q", test.tag, test.error)
+		}
+		if !reflect.DeepEqual(actual, test.expected) {
+			t.Errorf("** Values(%q) = %q, wanted %q", test.tag, actual, test.expected)
+		}
+	}
+}
@@ -0,0 +1,108 @@
+package tagparser
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// ErrConflict is returned by Merge with ErrorOnConflict strategy when base and
+// overlay disagree on the value of some keys.
+var ErrConflict = errors.New("conflicting option values")
+
+// MergeStrategy determines how Merge resolves a key that has different values
+// in base and overlay.
+type MergeStrategy int
+
+const (
+	// PreferBase keeps the value from base.
+	PreferBase MergeStrategy = iota
+
+	// PreferOverlay takes the value from overlay.
+	PreferOverlay
+
+	// ErrorOnConflict keeps the value from base and makes Merge return an
+	// error wrapping ErrConflict.
+	ErrorOnConflict
+
+	// CombineValues joins the base and overlay values with
+	// MergePolicy.Separator.
+	CombineValues
+)
+
+// MergePolicy configures Merge.
+type MergePolicy struct {
+	Strategy MergeStrategy
+
+	// Separator is used to join values with CombineValues strategy.
+	Separator string
+
+	// IgnoreEmptyOverlay makes empty overlay values, like that of a bare
+	// `size` flag, keep the base value instead of being a conflict.
+	IgnoreEmptyOverlay bool
+}
+
+// Conflict describes a key that has different values in base and overlay.
+type Conflict struct {
+	Key     string
+	Base    string
+	Overlay string
+}
+
+// Merge combines two option maps, e.g. a generated tag and user edits overlaid
+// on top of it. Keys present in only one of the maps are copied as is; keys
+// with equal values are not considered conflicting. Conflicts are resolved
+// according to the policy and returned sorted by key regardless of the
+// strategy. The inputs are not modified.
+//
+// With ErrorOnConflict, the merged options (preferring base) are returned
+// alongside the error, in line with the parse funcs of this package.
+func Merge(base, overlay map[string]string, policy MergePolicy) (map[string]string, []Conflict, error) {
+	result := make(map[string]string, len(base)+len(overlay))
+	for k, v := range base {
+		result[k] = v
+	}
+	var conflicts []Conflict
+	for k, ov := range overlay {
+		bv, ok := base[k]
+		if !ok || bv == ov {
+			result[k] = ov
+			continue
+		}
+		if ov == "" && policy.IgnoreEmptyOverlay {
+			continue
+		}
+		conflicts = append(conflicts, Conflict{k, bv, ov})
+		switch policy.Strategy {
+		case PreferOverlay:
+			result[k] = ov
+		case CombineValues:
+			result[k] = bv + policy.Separator + ov
+		}
+	}
+	sort.Slice(conflicts, func(i, j int) bool {
+		return conflicts[i].Key < conflicts[j].Key
+	})
+
+	var err error
+	if policy.Strategy == ErrorOnConflict && len(conflicts) > 0 {
+		keys := make([]string, len(conflicts))
+		for i, c := range conflicts {
+			keys[i] = c.Key
+		}
+		err = fmt.Errorf("%w: %s", ErrConflict, strings.Join(keys, ", "))
+	}
+	return result, conflicts, err
+}
+
+// ParseWithDefaults is like Parse, but fills in options missing from the tag
+// from defaults, as every consumer of per-field options ends up doing. Options
+// present in the tag win, except that a key given without a value keeps its
+// default value, so that `size` means "size, with the default value" when
+// defaults has one. The defaults are not modified; the result is never nil.
+func (conf Configuration) ParseWithDefaults(tag string, defaults map[string]string) (name string, opts map[string]string, err error) {
+	name, opts, err = conf.Parse(tag)
+	opts, _, _ = Merge(defaults, opts, MergePolicy{Strategy: PreferOverlay, IgnoreEmptyOverlay: true})
+	return name, opts, err
+}
@@ -0,0 +1,90 @@
+package tagparser
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestMerge(t *testing.T) {
+	base := M{"alfa": "1", "bravo": "2", "charlie": "3"}
+	overlay := M{"alfa": "", "bravo": "20", "charlie": "3", "delta": "4", "echo": ""}
+	conflicts := []Conflict{{"alfa", "1", ""}, {"bravo", "2", "20"}}
+	var tests = []struct {
+		testName  string
+		policy    MergePolicy
+		opts      map[string]string
+		conflicts []Conflict
+		error     string
+	}{
+		{`prefer base`, MergePolicy{Strategy: PreferBase}, M{"alfa": "1", "bravo": "2", "charlie": "3", "delta": "4", "echo": ""}, conflicts, ``},
+		{`prefer overlay`, MergePolicy{Strategy: PreferOverlay}, M{"alfa": "", "bravo": "20", "charlie": "3", "delta": "4", "echo": ""}, conflicts, ``},
+		{`error on conflict`, MergePolicy{Strategy: ErrorOnConflict}, M{"alfa": "1", "bravo": "2", "charlie": "3", "delta": "4", "echo": ""}, conflicts, `conflicting option values: alfa, bravo`},
+		{`combine`, MergePolicy{Strategy: CombineValues, Separator: "|"}, M{"alfa": "1|", "bravo": "2|20", "charlie": "3", "delta": "4", "echo": ""}, conflicts, ``},
+		{`ignore empty overlay`, MergePolicy{Strategy: PreferOverlay, IgnoreEmptyOverlay: true}, M{"alfa": "1", "bravo": "20", "charlie": "3", "delta": "4", "echo": ""}, conflicts[1:], ``},
+	}
+	for _, test := range tests {
+		t.Run(test.testName, func(t *testing.T) {
+			opts, conflicts, err := Merge(base, overlay, test.policy)
+			if err != nil {
+				if ae := err.Error(); ae != test.error {
+					t.Errorf("** error %q, wanted %q", ae, test.error)
+				}
+			} else if test.error != "" {
+				t.Errorf("** no error, wanted %q", test.error)
+			}
+			if !reflect.DeepEqual(opts, test.opts) {
+				t.Errorf("** opts = %q, wanted %q", opts, test.opts)
+			}
+			if !reflect.DeepEqual(conflicts, test.conflicts) {
+				t.Errorf("** conflicts = %q, wanted %q", conflicts, test.conflicts)
+			}
+		})
+	}
+}
+
+func TestMerge_conflicts_sorted(t *testing.T) {
+	_, conflicts, err := Merge(M{"b": "1", "a": "1", "c": "1"}, M{"c": "2", "a": "2", "b": "2"}, MergePolicy{Strategy: ErrorOnConflict})
+	if !errors.Is(err, ErrConflict) {
+		t.Errorf("** err = %v, wanted %v", err, ErrConflict)
+	}
+	if err.Error() != "conflicting option values: a, b, c" {
+		t.Errorf("** err = %v", err)
+	}
+	if len(conflicts) != 3 || conflicts[0].Key != "a" || conflicts[2].Key != "c" {
+		t.Errorf("** conflicts = %v", conflicts)
+	}
+}
+
+func TestConfiguration_ParseWithDefaults(t *testing.T) {
+	defaults := M{"size": "255", "omitempty": "", "index": "true"}
+	var tests = []struct {
+		conf  Configuration
+		tag   string
+		name  string
+		opts  map[string]string
+		error string
+	}{
+		{Configuration{}, ``, "", M{"size": "255", "omitempty": "", "index": "true"}, ``},
+		{Configuration{NamePosition: NameFirst}, `email,size:100,unique`, "email", M{"size": "100", "omitempty": "", "index": "true", "unique": ""}, ``},
+		{Configuration{}, `size,index:`, "", M{"size": "255", "omitempty": "", "index": "true"}, ``},
+		{Configuration{NegationPrefix: '!'}, `!index`, "", M{"size": "255", "omitempty": "", "index": "false"}, ``},
+		{Configuration{}, `size:1,:x`, "", M{"size": "1", "omitempty": "", "index": "true"}, `empty key (at 8)`},
+	}
+	for _, test := range tests {
+		name, opts, err := test.conf.ParseWithDefaults(test.tag, defaults)
+		if err != nil {
+			if ae := err.Error(); ae != test.error {
+				t.Errorf("** ParseWithDefaults(%q) error %q, wanted %q", test.tag, ae, test.error)
+			}
+		} else if test.error != "" {
+			t.Errorf("** ParseWithDefaults(%q) no error, wanted %q", test.tag, test.error)
+		}
+		if name != test.name || !reflect.DeepEqual(opts, test.opts) {
+			t.Errorf("** ParseWithDefaults(%q) = %q, %q, wanted %q, %q", test.tag, name, opts, test.name, test.opts)
+		}
+	}
+	if defaults["size"] != "255" || len(defaults) != 3 {
+		t.Errorf("** ParseWithDefaults modified defaults: %q", defaults)
+	}
+}
@@ -0,0 +1,97 @@
+package tagparser
+
+import (
+	"strconv"
+	"strings"
+)
+
+// NameKind tells apart the conventional meanings of the name of a tag, see
+// Result.NameKind.
+type NameKind int
+
+const (
+	// NameDefault means the tag gives no name, like `` or `,omitempty`, so
+	// the default one, usually the field name, applies.
+	NameDefault NameKind = iota
+	// NameExplicit means the tag gives a name, including `-` in `-,`.
+	NameExplicit
+	// NameSkipped means the tag is the SkipMarker, like `-`, so the field is
+	// skipped.
+	NameSkipped
+)
+
+// String returns the name of the constant without the Name prefix, e.g.
+// "skipped".
+func (k NameKind) String() string {
+	switch k {
+	case NameDefault:
+		return "default"
+	case NameExplicit:
+		return "explicit"
+	case NameSkipped:
+		return "skipped"
+	default:
+		return "NameKind(" + strconv.Itoa(int(k)) + ")"
+	}
+}
+
+// NameKind classifies the name of the result: NameSkipped if the tag is the
+// SkipMarker, NameDefault if the name is empty, and NameExplicit otherwise.
+// An empty quoted name is NameDefault too.
+func (r Result) NameKind() NameKind {
+	if r.Skip {
+		return NameSkipped
+	} else if r.Name == "" {
+		return NameDefault
+	}
+	return NameExplicit
+}
+
+// NameResult is a name split into its parts according to the configuration.
+type NameResult struct {
+	// Name is the name without modifiers.
+	Name string
+
+	// Modifiers are the parts of the name following
+	// Configuration.NameModifierSeparator, e.g. {"omit"} for `name/omit`.
+	Modifiers []string
+
+	// Variants map locales to names according to
+	// Configuration.NameVariantSeparator, e.g. {"de": "titel"} for
+	// `title;de=titel`.
+	Variants map[string]string
+}
+
+// SplitName splits a name returned by Parse into the base name and the parts
+// attached to it according to the configuration. The separators are reserved
+// within names: there is no way to escape them, since escapes are already
+// processed when the name is returned.
+//
+// Modifiers are split off first, so variants go before them, like in
+// `title;de=titel/omit`.
+func (conf Configuration) SplitName(name string) NameResult {
+	var r NameResult
+	if conf.NameModifierSeparator != 0 {
+		parts := strings.Split(name, string(conf.NameModifierSeparator))
+		name = parts[0]
+		if len(parts) > 1 {
+			r.Modifiers = parts[1:]
+		}
+	}
+	if conf.NameVariantSeparator != 0 {
+		parts := strings.Split(name, string(conf.NameVariantSeparator))
+		name = parts[0]
+		if len(parts) > 1 {
+			r.Variants = make(map[string]string, len(parts)-1)
+			for _, part := range parts[1:] {
+				locale, variant, ok := strings.Cut(part, "=")
+				if !ok {
+					variant = name
+				}
+				r.Variants[locale] = variant
+			}
+		}
+	}
+	r.Name = name
+	return r
+}
@@ -0,0 +1,74 @@
+package tagparser
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestConfiguration_SplitName(t *testing.T) {
+	slash := Configuration{NameModifierSeparator: '/'}
+	var tests = []struct {
+		conf     Configuration
+		name     string
+		expected NameResult
+	}{
+		{Configuration{}, `name/omit`, NameResult{Name: "name/omit"}},
+		{slash, ``, NameResult{}},
+		{slash, `name`, NameResult{Name: "name"}},
+		{slash, `name/omit`, NameResult{Name: "name", Modifiers: []string{"omit"}}},
+		{slash, `name/omit/strict`, NameResult{Name: "name", Modifiers: []string{"omit", "strict"}}},
+		{slash, `/omit`, NameResult{Name: "", Modifiers: []string{"omit"}}},
+		{Configuration{NameModifierSeparator: '!'}, `name!strict`, NameResult{Name: "name", Modifiers: []string{"strict"}}},
+		{Configuration{NameVariantSeparator: ';'}, `title;de=titel;fr=titre`, NameResult{Name: "title", Variants: M{"de": "titel", "fr": "titre"}}},
+		{Configuration{NameVariantSeparator: '#'}, `title#en`, NameResult{Name: "title", Variants: M{"en": "title"}}},
+		{Configuration{NameVariantSeparator: ';', NameModifierSeparator: '/'}, `title;de=titel/omit`, NameResult{Name: "title", Modifiers: []string{"omit"}, Variants: M{"de": "titel"}}},
+		{Configuration{}, `title;de=titel`, NameResult{Name: "title;de=titel"}},
+	}
+	for _, test := range tests {
+		actual := test.conf.SplitName(test.name)
+		if !reflect.DeepEqual(actual, test.expected) {
+			t.Errorf("** SplitName(%q) = %+v, wanted %+v", test.name, actual, test.expected)
+		}
+	}
+}
+
+func TestParseJSONName(t *testing.T) {
+	var tests = []struct {
+		tag  string
+		name string
+		kind NameKind
+		opts map[string]string
+	}{
+		{``, "", NameDefault, nil},
+		{`,omitempty`, "", NameDefault, M{"omitempty": ""}},
+		{`'',string`, "", NameDefault, M{"string": ""}},
+		{`-`, "-", NameSkipped, nil},
+		{`-,`, "-", NameExplicit, nil},
+		{`-,omitempty`, "-", NameExplicit, M{"omitempty": ""}},
+		{` -`, "-", NameExplicit, nil},
+		{`email,omitempty`, "email", NameExplicit, M{"omitempty": ""}},
+	}
+	for _, test := range tests {
+		name, kind, opts, err := ParseJSONName(test.tag)
+		if name != test.name || kind != test.kind || !reflect.DeepEqual(opts, test.opts) || err != nil {
+			t.Errorf("** ParseJSONName(%q) = %q, %v, %q, %v, wanted %q, %v, %q", test.tag, name, kind, opts, err, test.name, test.kind, test.opts)
+		}
+	}
+}
+
+func TestNameKind_String(t *testing.T) {
+	var tests = []struct {
+		k        NameKind
+		expected string
+	}{
+		{NameDefault, "default"},
+		{NameExplicit, "explicit"},
+		{NameSkipped, "skipped"},
+		{NameKind(7), "NameKind(7)"},
+	}
+	for _, test := range tests {
+		if actual := test.k.String(); actual != test.expected {
+			t.Errorf("** String() = %q, wanted %q", actual, test.expected)
+		}
+	}
+}
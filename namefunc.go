@@ -0,0 +1,25 @@
+package tagparser
+
+// ParseNameOptsFunc is like ParseNameFunc, but reports the name to a
+// separate callback along with its byte offset within the tag (excluding
+// surrounding whitespace), so that onOpt never sees an empty key.
+func ParseNameOptsFunc(tag string, onName func(name string, pos int) error, onOpt func(key, value string) error) error {
+	return nameFirst.ParseNameOptsFunc(tag, onName, onOpt)
+}
+
+// ParseNameOptsFunc is like the package-level ParseNameOptsFunc, but parses
+// the tag according to the configuration. onName is not called under
+// NameNone, and is called for an empty name, like `,omitempty`. With
+// Multiline, the offset refers to the dedented tag.
+func (conf Configuration) ParseNameOptsFunc(tag string, onName func(name string, pos int) error, onOpt func(key, value string) error) error {
+	if conf.Multiline {
+		tag, conf.Multiline = Dedent(tag), false
+	}
+	return parseItems(tag, &conf, ErrorInfo{}, func(key, value string, it item) error {
+		if it.isName {
+			pos, _ := trimmedSpan(&conf, tag, it.keyStart, it.keyEnd)
+			return onName(value, pos)
+		}
+		return onOpt(key, value)
+	}, nil, nil)
+}
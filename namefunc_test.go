@@ -0,0 +1,65 @@
+package tagparser
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestParseNameOptsFunc(t *testing.T) {
+	var tests = []struct {
+		conf     Configuration
+		tag      string
+		expected string
+		error    string
+	}{
+		{nameFirst, ``, ``, ``},
+		{nameFirst, ` email , omitempty,size:10`, `name(email)@1 omitempty= size=10`, ``},
+		{nameFirst, `,omitempty`, `name()@0 omitempty=`, ``},
+		{nameFirst, `'a,b',:x,c`, `name(a,b)@0 c=`, `empty key (at 7)`},
+		{Configuration{NamePosition: NameLast}, `a:1, b`, `a=1 name(b)@5`, ``},
+		{nameNone, `a,b:2`, `a= b=2`, ``},
+		{Configuration{NamePosition: NameFirst, Multiline: true}, "\n\tname,\n\ta\n", `name(name)@0 a=`, ``},
+	}
+	for _, test := range tests {
+		var items []string
+		err := test.conf.ParseNameOptsFunc(test.tag, func(name string, pos int) error {
+			items = append(items, fmt.Sprintf("name(%s)@%d", name, pos))
+			return nil
+		}, func(key, value string) error {
+			items = append(items, key+"="+value)
+			return nil
+		})
+		if actual := strings.Join(items, " "); actual != test.expected {
+			t.Errorf("** ParseNameOptsFunc(%q) = %q, wanted %q", test.tag, actual, test.expected)
+		}
+		if err != nil {
+			if ae := err.Error(); ae != test.error {
+				t.Errorf("** ParseNameOptsFunc(%q) error %q, wanted %q", test.tag, ae, test.error)
+			}
+		} else if test.error != "" {
+			t.Errorf("** ParseNameOptsFunc(%q) no error, wanted %q", test.tag, test.error)
+		}
+	}
+}
+
+func TestParseNameOptsFunc_errors(t *testing.T) {
+	errSimulated := errors.New("simulated")
+	err := ParseNameOptsFunc(` x,a`, func(name string, pos int) error {
+		return errSimulated
+	}, func(key, value string) error {
+		return nil
+	})
+	if expErr := "simulated (at 1)"; !errors.Is(err, errSimulated) || err.Error() != expErr {
+		t.Errorf("** ParseNameOptsFunc error %v, wanted %s", err, expErr)
+	}
+	err = ParseNameOptsFunc(`x,a`, func(name string, pos int) error {
+		return nil
+	}, func(key, value string) error {
+		return errSimulated
+	})
+	if expErr := "a: simulated (at 3)"; !errors.Is(err, errSimulated) || err.Error() != expErr {
+		t.Errorf("** ParseNameOptsFunc error %v, wanted %s", err, expErr)
+	}
+}
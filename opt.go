@@ -0,0 +1,113 @@
+package tagparser
+
+import (
+	"strconv"
+	"strings"
+	"time"
+)
+
+// OptBool returns the boolean value of the option: true for a key without a
+// value, the parsed value (see strconv.ParseBool) if it has one, or def if
+// the option is missing. A malformed value yields def and an *Error with
+// CodeInvalidValue positioned at the value within Tag.
+func (r Result) OptBool(key string, def bool) (bool, error) {
+	v, ok := r.Options[key]
+	if !ok {
+		return def, nil
+	} else if v == "" {
+		return true, nil
+	}
+	b, err := strconv.ParseBool(v)
+	if err != nil {
+		return def, r.invalidValue(key, v, "invalid boolean value "+strconv.Quote(v))
+	}
+	return b, nil
+}
+
+// OptInt is like OptBool, but returns a decimal integer value.
+func (r Result) OptInt(key string, def int) (int, error) {
+	v, ok := r.Options[key]
+	if !ok {
+		return def, nil
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil {
+		return def, r.invalidValue(key, v, "invalid integer value "+strconv.Quote(v))
+	}
+	return n, nil
+}
+
+// OptUint is like OptBool, but returns a non-negative decimal integer value.
+func (r Result) OptUint(key string, def uint) (uint, error) {
+	v, ok := r.Options[key]
+	if !ok {
+		return def, nil
+	}
+	n, err := strconv.ParseUint(v, 10, 0)
+	if err != nil {
+		return def, r.invalidValue(key, v, "invalid unsigned integer value "+strconv.Quote(v))
+	}
+	return uint(n), nil
+}
+
+// OptFloat is like OptBool, but returns a floating-point value.
+func (r Result) OptFloat(key string, def float64) (float64, error) {
+	v, ok := r.Options[key]
+	if !ok {
+		return def, nil
+	}
+	f, err := strconv.ParseFloat(v, 64)
+	if err != nil {
+		return def, r.invalidValue(key, v, "invalid number value "+strconv.Quote(v))
+	}
+	return f, nil
+}
+
+// OptDuration is like OptBool, but returns a duration like `5m` (see
+// time.ParseDuration).
+func (r Result) OptDuration(key string, def time.Duration) (time.Duration, error) {
+	v, ok := r.Options[key]
+	if !ok {
+		return def, nil
+	}
+	d, err := time.ParseDuration(v)
+	if err != nil {
+		return def, r.invalidValue(key, v, "invalid duration value "+strconv.Quote(v))
+	}
+	return d, nil
+}
+
+// OptEnum is like OptBool, but returns a value that must be one of allowed.
+func (r Result) OptEnum(key string, def string, allowed ...string) (string, error) {
+	v, ok := r.Options[key]
+	if !ok {
+		return def, nil
+	}
+	for _, a := range allowed {
+		if v == a {
+			return v, nil
+		}
+	}
+	return def, r.invalidValue(key, v, "invalid value "+strconv.Quote(v)+", wanted one of "+strings.Join(allowed, ", "))
+}
+
+// invalidValue returns an error for the value of the key, positioned at the
+// occurrence of the option in Tag.
+func (r Result) invalidValue(key, value, msg string) error {
+	pos := -1
+	r.Conf.ParseFuncPos(r.Tag, func(k, v string, keyPos, valuePos, end int) error {
+		if pos < 0 && k == key && v == value {
+			pos = valuePos
+		}
+		return nil
+	})
+	if pos < 0 {
+		pos = 0
+	}
+	tag := r.Tag
+	if r.Conf.Multiline {
+		tag = Dedent(tag)
+	}
+	se := &SchemaError{Code: CodeInvalidValue, Key: key, Value: value, Msg: msg}
+	return &Error{Tag: tag, Pos: pos, Cause: se, Code: CodeInvalidValue}
+}
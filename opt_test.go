@@ -0,0 +1,80 @@
+package tagparser
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestResult_Opt(t *testing.T) {
+	r, err := Configuration{NamePosition: NameFirst}.ParseResult(`name,b,f:false,i:-2,u:3,x:1.5,d:5m,e:json,bad: oops`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	check := func(what string, actual, expected any, err error) {
+		t.Helper()
+		if err != nil || actual != expected {
+			t.Errorf("** %s = %v, %v, wanted %v", what, actual, err, expected)
+		}
+	}
+	b, err := r.OptBool("b", false)
+	check("OptBool(b)", b, true, err)
+	b, err = r.OptBool("f", true)
+	check("OptBool(f)", b, false, err)
+	b, err = r.OptBool("missing", true)
+	check("OptBool(missing)", b, true, err)
+	i, err := r.OptInt("i", 0)
+	check("OptInt", i, -2, err)
+	i, err = r.OptInt("missing", 7)
+	check("OptInt(missing)", i, 7, err)
+	u, err := r.OptUint("u", 0)
+	check("OptUint", u, uint(3), err)
+	u, err = r.OptUint("missing", 7)
+	check("OptUint(missing)", u, uint(7), err)
+	f, err := r.OptFloat("x", 0)
+	check("OptFloat", f, 1.5, err)
+	f, err = r.OptFloat("missing", 7)
+	check("OptFloat(missing)", f, 7.0, err)
+	d, err := r.OptDuration("d", 0)
+	check("OptDuration", d, 5*time.Minute, err)
+	d, err = r.OptDuration("missing", time.Second)
+	check("OptDuration(missing)", d, time.Second, err)
+	e, err := r.OptEnum("e", "text", "json", "text")
+	check("OptEnum", e, "json", err)
+	e, err = r.OptEnum("missing", "text", "json", "text")
+	check("OptEnum(missing)", e, "text", err)
+
+	var tests = []struct {
+		what  string
+		fn    func() error
+		error string
+	}{
+		{"OptBool", func() error { _, err := r.OptBool("bad", false); return err }, `bad: invalid boolean value "oops" (at 48)`},
+		{"OptInt", func() error { _, err := r.OptInt("bad", 0); return err }, `bad: invalid integer value "oops" (at 48)`},
+		{"OptUint", func() error { _, err := r.OptUint("i", 0); return err }, `i: invalid unsigned integer value "-2" (at 18)`},
+		{"OptFloat", func() error { _, err := r.OptFloat("bad", 0); return err }, `bad: invalid number value "oops" (at 48)`},
+		{"OptDuration", func() error { _, err := r.OptDuration("bad", 0); return err }, `bad: invalid duration value "oops" (at 48)`},
+		{"OptEnum", func() error { _, err := r.OptEnum("bad", "", "json", "text"); return err }, `bad: invalid value "oops", wanted one of json, text (at 48)`},
+	}
+	for _, test := range tests {
+		err := test.fn()
+		var e *Error
+		if !errors.As(err, &e) || e.Code != CodeInvalidValue || err.Error() != test.error {
+			t.Errorf("** %s error %v, wanted %s", test.what, err, test.error)
+		}
+	}
+}
+
+func TestResult_Opt_positions(t *testing.T) {
+	r, _ := Configuration{Multiline: true, DuplicateKeys: DuplicateKeysLastWins}.ParseResult("\n\ta:1,\n\ta:x\n")
+	_, err := r.OptInt("a", 0)
+	if expErr := `a: invalid integer value "x" (at 8)`; err == nil || err.Error() != expErr || err.(*Error).Tag != "a:1, a:x" {
+		t.Errorf("** OptInt error %#v, wanted %s", err, expErr)
+	}
+
+	r = Result{Options: M{"a": "x"}}
+	_, err = r.OptInt("a", 0)
+	if expErr := `a: invalid integer value "x" (at 1)`; err == nil || err.Error() != expErr {
+		t.Errorf("** OptInt error %v, wanted %s", err, expErr)
+	}
+}
@@ -0,0 +1,245 @@
+package tagparser
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrInvalidConfiguration is returned by New and Configuration.Validate for
+// incompatible settings.
+var ErrInvalidConfiguration = errors.New("invalid configuration")
+
+// ConfigOption is a functional option for New, an alternative to setting
+// Configuration fields directly that lets APIs accept
+// ...tagparser.ConfigOption.
+type ConfigOption func(conf *Configuration)
+
+// New returns a configuration with the given options applied to the zero
+// value, validated with Validate.
+func New(opts ...ConfigOption) (Configuration, error) {
+	var conf Configuration
+	for _, opt := range opts {
+		opt(&conf)
+	}
+	return conf, conf.Validate()
+}
+
+// WithNamePosition sets NamePosition.
+func WithNamePosition(p NamePosition) ConfigOption {
+	return func(conf *Configuration) { conf.NamePosition = p }
+}
+
+// WithSeparators sets KeyValueSeparator and ItemSeparator.
+func WithSeparators(kvSep, itemSep byte) ConfigOption {
+	return func(conf *Configuration) { conf.KeyValueSeparator, conf.ItemSeparator = kvSep, itemSep }
+}
+
+// WithDuplicatePolicy sets DuplicateKeys.
+func WithDuplicatePolicy(p DuplicateKeyPolicy) ConfigOption {
+	return func(conf *Configuration) { conf.DuplicateKeys = p }
+}
+
+// WithAllowedKeys sets KnownKeys.
+func WithAllowedKeys(keys ...string) ConfigOption {
+	return func(conf *Configuration) { conf.KnownKeys = keys }
+}
+
+// WithKeyAliases sets KeyAliases.
+func WithKeyAliases(aliases map[string]string) ConfigOption {
+	return func(conf *Configuration) { conf.KeyAliases = aliases }
+}
+
+// WithDeprecatedKeys sets DeprecatedKeys.
+func WithDeprecatedKeys(keys map[string]string) ConfigOption {
+	return func(conf *Configuration) { conf.DeprecatedKeys = keys }
+}
+
+// WithWarnQuotedEmptyValues enables WarnQuotedEmptyValues.
+func WithWarnQuotedEmptyValues() ConfigOption {
+	return func(conf *Configuration) { conf.WarnQuotedEmptyValues = true }
+}
+
+// WithStrictInvisibleChars enables StrictInvisibleChars.
+func WithStrictInvisibleChars() ConfigOption {
+	return func(conf *Configuration) { conf.StrictInvisibleChars = true }
+}
+
+// WithQuotedNames sets QuotedNames.
+func WithQuotedNames(p QuotedNamePolicy) ConfigOption {
+	return func(conf *Configuration) { conf.QuotedNames = p }
+}
+
+// WithQuoteChars sets QuoteChars.
+func WithQuoteChars(chars string) ConfigOption {
+	return func(conf *Configuration) { conf.QuoteChars = chars }
+}
+
+// WithStandardEscapes enables AllowStandardEscapes.
+func WithStandardEscapes() ConfigOption {
+	return func(conf *Configuration) { conf.AllowStandardEscapes = true }
+}
+
+// WithEscapableChars sets EscapableChars.
+func WithEscapableChars(chars string) ConfigOption {
+	return func(conf *Configuration) { conf.EscapableChars = chars }
+}
+
+// WithContinuation sets ContinuationKey and ContinuationJoiner.
+func WithContinuation(key, joiner string) ConfigOption {
+	return func(conf *Configuration) { conf.ContinuationKey, conf.ContinuationJoiner = key, joiner }
+}
+
+// WithDirectivePrefix sets DirectivePrefix.
+func WithDirectivePrefix(prefix byte) ConfigOption {
+	return func(conf *Configuration) { conf.DirectivePrefix = prefix }
+}
+
+// WithListValues sets ListValues.
+func WithListValues() ConfigOption {
+	return func(conf *Configuration) { conf.ListValues = true }
+}
+
+// WithUnicodeWhitespace sets UnicodeWhitespace.
+func WithUnicodeWhitespace() ConfigOption {
+	return func(conf *Configuration) { conf.UnicodeWhitespace = true }
+}
+
+// WithKeyValidator sets KeyValidator.
+func WithKeyValidator(validator func(key string) error) ConfigOption {
+	return func(conf *Configuration) { conf.KeyValidator = validator }
+}
+
+// WithExpandValue sets ExpandValue.
+func WithExpandValue(expand func(key, value string) (string, error)) ConfigOption {
+	return func(conf *Configuration) { conf.ExpandValue = expand }
+}
+
+// WithNegationPrefix sets NegationPrefix.
+func WithNegationPrefix(prefix byte) ConfigOption {
+	return func(conf *Configuration) { conf.NegationPrefix = prefix }
+}
+
+// WithWildcardKey sets WildcardKey.
+func WithWildcardKey(key string) ConfigOption {
+	return func(conf *Configuration) { conf.WildcardKey = key }
+}
+
+// WithSkipMarker sets SkipMarker.
+func WithSkipMarker(marker string) ConfigOption {
+	return func(conf *Configuration) { conf.SkipMarker = marker }
+}
+
+// WithNameSeparators sets NameModifierSeparator and NameVariantSeparator.
+func WithNameSeparators(modifier, variant byte) ConfigOption {
+	return func(conf *Configuration) { conf.NameModifierSeparator, conf.NameVariantSeparator = modifier, variant }
+}
+
+// WithStripKeyPrefix sets StripKeyPrefix.
+func WithStripKeyPrefix(prefix string) ConfigOption {
+	return func(conf *Configuration) { conf.StripKeyPrefix = prefix }
+}
+
+// WithGreedyLastValue enables GreedyLastValue.
+func WithGreedyLastValue() ConfigOption {
+	return func(conf *Configuration) { conf.GreedyLastValue = true }
+}
+
+// WithMultiline enables Multiline.
+func WithMultiline() ConfigOption {
+	return func(conf *Configuration) { conf.Multiline = true }
+}
+
+// WithPercentDecodeValues enables PercentDecodeValues.
+func WithPercentDecodeValues() ConfigOption {
+	return func(conf *Configuration) { conf.PercentDecodeValues = true }
+}
+
+// WithFoldKeys enables FoldKeys.
+func WithFoldKeys() ConfigOption {
+	return func(conf *Configuration) { conf.FoldKeys = true }
+}
+
+// WithKeyNormalizer sets KeyNormalizer.
+func WithKeyNormalizer(normalize func(key string) string) ConfigOption {
+	return func(conf *Configuration) { conf.KeyNormalizer = normalize }
+}
+
+// WithLimits sets MaxLength, MaxOptions and MaxNestingDepth.
+func WithLimits(maxLength, maxOptions, maxNestingDepth int) ConfigOption {
+	return func(conf *Configuration) {
+		conf.MaxLength, conf.MaxOptions, conf.MaxNestingDepth = maxLength, maxOptions, maxNestingDepth
+	}
+}
+
+// WithCollectAllErrors enables CollectAllErrors.
+func WithCollectAllErrors() ConfigOption {
+	return func(conf *Configuration) { conf.CollectAllErrors = true }
+}
+
+// Validate reports settings that cannot work together, like equal
+// separators or a separator that is also a quote. The error wraps
+// ErrInvalidConfiguration. The parse funcs do not call Validate, and parse
+// tags even with an invalid configuration in some way.
+func (conf Configuration) Validate() error {
+	kvSep, itemSep := conf.separators()
+	if conf.NamePosition < NameNone || conf.NamePosition > NameLast {
+		return fmt.Errorf("%w: unknown NamePosition %d", ErrInvalidConfiguration, conf.NamePosition)
+	}
+	if conf.QuotedNames < QuotedNamesAllow || conf.QuotedNames > QuotedNamesError {
+		return fmt.Errorf("%w: unknown QuotedNames %d", ErrInvalidConfiguration, conf.QuotedNames)
+	}
+	if conf.DuplicateKeys < DuplicateKeysError || conf.DuplicateKeys > DuplicateKeysCollect {
+		return fmt.Errorf("%w: unknown DuplicateKeys %d", ErrInvalidConfiguration, conf.DuplicateKeys)
+	}
+	special := []struct {
+		name string
+		c    byte
+	}{
+		{"KeyValueSeparator", kvSep},
+		{"ItemSeparator", itemSep},
+		{"NameModifierSeparator", conf.NameModifierSeparator},
+		{"NameVariantSeparator", conf.NameVariantSeparator},
+		{"DirectivePrefix", conf.DirectivePrefix},
+		{"NegationPrefix", conf.NegationPrefix},
+	}
+	for i, s := range special {
+		if s.c != 0 && conf.quotes().has(s.c) || s.c == '\\' || asciiSpace[s.c] != 0 || conf.ListValues && (s.c == '[' || s.c == ']') {
+			return fmt.Errorf("%w: %s is %q", ErrInvalidConfiguration, s.name, s.c)
+		}
+		for _, t := range special[:i] {
+			if s.c != 0 && s.c == t.c {
+				return fmt.Errorf("%w: %s and %s are both %q", ErrInvalidConfiguration, t.name, s.name, s.c)
+			}
+		}
+	}
+	if len(conf.QuoteChars) > 3 {
+		return fmt.Errorf("%w: more than three QuoteChars", ErrInvalidConfiguration)
+	}
+	for i := 0; i < len(conf.QuoteChars); i++ {
+		if c := conf.QuoteChars[i]; c == '\\' || asciiSpace[c] != 0 || isAlnum(c) {
+			return fmt.Errorf("%w: QuoteChars contains %q", ErrInvalidConfiguration, c)
+		}
+	}
+	for i := 0; i < len(conf.EscapableChars); i++ {
+		if isAlnum(conf.EscapableChars[i]) {
+			return fmt.Errorf("%w: EscapableChars contains %q", ErrInvalidConfiguration, conf.EscapableChars[i])
+		}
+	}
+	if conf.ContinuationKey != "" && conf.ContinuationKey == conf.WildcardKey {
+		return fmt.Errorf("%w: ContinuationKey and WildcardKey are both %q", ErrInvalidConfiguration, conf.ContinuationKey)
+	}
+	if conf.NamePosition == NameNone && (conf.NameModifierSeparator != 0 || conf.NameVariantSeparator != 0 || conf.QuotedNames != QuotedNamesAllow) {
+		return fmt.Errorf("%w: name settings without NamePosition", ErrInvalidConfiguration)
+	}
+	if conf.KeyNormalizer != nil {
+		for _, k := range [...]string{conf.ContinuationKey, conf.WildcardKey} {
+			if k != "" && conf.foldKey(k) != k {
+				return fmt.Errorf("%w: KeyNormalizer changes %q, which would never match", ErrInvalidConfiguration, k)
+			}
+		}
+	}
+	if conf.MaxLength < 0 || conf.MaxOptions < 0 || conf.MaxNestingDepth < 0 {
+		return fmt.Errorf("%w: negative limit", ErrInvalidConfiguration)
+	}
+	return nil
+}
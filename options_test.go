@@ -0,0 +1,138 @@
+package tagparser
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestNew(t *testing.T) {
+	conf, err := New(
+		WithNamePosition(NameFirst),
+		WithSeparators('=', ';'),
+		WithDuplicatePolicy(DuplicateKeysLastWins),
+		WithAllowedKeys("a", "b"),
+		WithKeyAliases(M{"c": "a"}),
+		WithDeprecatedKeys(M{"c": ""}),
+		WithWarnQuotedEmptyValues(),
+		WithStrictInvisibleChars(),
+		WithQuotedNames(QuotedNamesError),
+		WithQuoteChars(`'"`),
+		WithStandardEscapes(),
+		WithEscapableChars(`;=`),
+		WithContinuation("+", " "),
+		WithDirectivePrefix('#'),
+		WithListValues(),
+		WithNegationPrefix('!'),
+		WithWildcardKey("*"),
+		WithSkipMarker("-"),
+		WithNameSeparators('/', '|'),
+		WithStripKeyPrefix("x-"),
+		WithGreedyLastValue(),
+		WithMultiline(),
+		WithPercentDecodeValues(),
+		WithFoldKeys(),
+		WithUnicodeWhitespace(),
+		WithLimits(100, 10, 3),
+		WithCollectAllErrors(),
+	)
+	expected := Configuration{
+		NamePosition:          NameFirst,
+		KeyValueSeparator:     '=',
+		ItemSeparator:         ';',
+		DuplicateKeys:         DuplicateKeysLastWins,
+		KnownKeys:             []string{"a", "b"},
+		KeyAliases:            M{"c": "a"},
+		DeprecatedKeys:        M{"c": ""},
+		WarnQuotedEmptyValues: true,
+		StrictInvisibleChars:  true,
+		QuotedNames:           QuotedNamesError,
+		QuoteChars:            `'"`,
+		AllowStandardEscapes:  true,
+		EscapableChars:        `;=`,
+		ContinuationKey:       "+",
+		ContinuationJoiner:    " ",
+		DirectivePrefix:       '#',
+		ListValues:            true,
+		NegationPrefix:        '!',
+		WildcardKey:           "*",
+		SkipMarker:            "-",
+		NameModifierSeparator: '/',
+		NameVariantSeparator:  '|',
+		StripKeyPrefix:        "x-",
+		GreedyLastValue:       true,
+		Multiline:             true,
+		PercentDecodeValues:   true,
+		FoldKeys:              true,
+		UnicodeWhitespace:     true,
+		MaxLength:             100,
+		MaxOptions:            10,
+		MaxNestingDepth:       3,
+		CollectAllErrors:      true,
+	}
+	if err != nil || !reflect.DeepEqual(conf, expected) {
+		t.Errorf("** New = %+v, %v, wanted %+v", conf, err, expected)
+	}
+	// Every field must have an option; funcs are checked below.
+	v := reflect.ValueOf(expected)
+	for i := 0; i < v.NumField(); i++ {
+		if f := v.Type().Field(i); f.IsExported() && f.Type.Kind() != reflect.Func && v.Field(i).IsZero() {
+			t.Errorf("** New has no option for %s", f.Name)
+		}
+	}
+	if conf, err := New(); err != nil || !reflect.DeepEqual(conf, Configuration{}) {
+		t.Errorf("** New() = %+v, %v, wanted zero", conf, err)
+	}
+	// Funcs are not comparable, so KeyValidator is checked by calling it.
+	if conf, err := New(WithKeyValidator(ValidateIdentifier)); err != nil || conf.KeyValidator("a-b") != ErrNotIdentifier {
+		t.Errorf("** New(WithKeyValidator) = %+v, %v", conf, err)
+	}
+	expand := func(key, value string) (string, error) { return key + value, nil }
+	if conf, err := New(WithExpandValue(expand)); err != nil {
+		t.Errorf("** New(WithExpandValue) error %v", err)
+	} else if v, _ := conf.ExpandValue("a", "b"); v != "ab" {
+		t.Errorf("** New(WithExpandValue) ExpandValue = %q", v)
+	}
+	if conf, err := New(WithKeyNormalizer(strings.ToLower)); err != nil || conf.KeyNormalizer("A") != "a" {
+		t.Errorf("** New(WithKeyNormalizer) = %+v, %v", conf, err)
+	}
+}
+
+func TestConfiguration_Validate(t *testing.T) {
+	var tests = []struct {
+		conf  Configuration
+		error string
+	}{
+		{Configuration{NamePosition: 3}, `invalid configuration: unknown NamePosition 3`},
+		{Configuration{NamePosition: NameFirst, QuotedNames: -1}, `invalid configuration: unknown QuotedNames -1`},
+		{Configuration{DuplicateKeys: 4}, `invalid configuration: unknown DuplicateKeys 4`},
+		{Configuration{ItemSeparator: ':'}, `invalid configuration: KeyValueSeparator and ItemSeparator are both ':'`},
+		{Configuration{KeyValueSeparator: '\''}, `invalid configuration: KeyValueSeparator is '\''`},
+		{Configuration{ItemSeparator: '\\'}, `invalid configuration: ItemSeparator is '\\'`},
+		{Configuration{DirectivePrefix: ' '}, `invalid configuration: DirectivePrefix is ' '`},
+		{Configuration{NamePosition: NameFirst, NameModifierSeparator: ','}, `invalid configuration: ItemSeparator and NameModifierSeparator are both ','`},
+		{Configuration{NamePosition: NameFirst, NameModifierSeparator: '/', NameVariantSeparator: '/'}, `invalid configuration: NameModifierSeparator and NameVariantSeparator are both '/'`},
+		{Configuration{QuoteChars: `"`, ItemSeparator: '"'}, `invalid configuration: ItemSeparator is '"'`},
+		{Configuration{QuoteChars: `'"|-`}, `invalid configuration: more than three QuoteChars`},
+		{Configuration{QuoteChars: `"a`}, `invalid configuration: QuoteChars contains 'a'`},
+		{Configuration{DirectivePrefix: '-', NegationPrefix: '-'}, `invalid configuration: DirectivePrefix and NegationPrefix are both '-'`},
+		{Configuration{ListValues: true, ItemSeparator: ']'}, `invalid configuration: ItemSeparator is ']'`},
+		{Configuration{EscapableChars: `,a`}, `invalid configuration: EscapableChars contains 'a'`},
+		{Configuration{ContinuationKey: "*", WildcardKey: "*"}, `invalid configuration: ContinuationKey and WildcardKey are both "*"`},
+		{Configuration{NameModifierSeparator: '/'}, `invalid configuration: name settings without NamePosition`},
+		{Configuration{QuotedNames: QuotedNamesError}, `invalid configuration: name settings without NamePosition`},
+		{Configuration{MaxOptions: -1}, `invalid configuration: negative limit`},
+	}
+	for _, test := range tests {
+		err := test.conf.Validate()
+		if err == nil || err.Error() != test.error || !errors.Is(err, ErrInvalidConfiguration) {
+			t.Errorf("** Validate(%+v) = %v, wanted %s", test.conf, err, test.error)
+		}
+	}
+	for _, conf := range []Configuration{VMihailenco(), JSON(), XML(), Gorm(), Validator(), Protobuf()} {
+		if err := conf.Validate(); err != nil {
+			t.Errorf("** Validate(%+v) = %v, wanted nil", conf, err)
+		}
+	}
+}
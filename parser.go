@@ -0,0 +1,103 @@
+package tagparser
+
+// scanClasses are the character classes the scanner stops at outside quotes.
+const scanClasses = CharQuote | CharEscape | CharItemSeparator | CharKeyValueSeparator | CharListBracket
+
+// charTable holds the classes of all bytes in a dialect, see Compile.
+type charTable [256]CharClass
+
+// Parser is a Configuration compiled by Configuration.Compile, with lookup
+// tables for the separators, quotes and brackets of the dialect precomputed,
+// so that the scanner skips ordinary bytes with a single lookup.
+//
+// A Parser is immutable and safe for concurrent use by multiple goroutines;
+// keep one per dialect in a package-level variable.
+type Parser struct {
+	conf Configuration
+}
+
+// Compile validates the configuration (see Validate) and returns a Parser for
+// it. The Parser keeps its own copy of the configuration, so changing conf,
+// including the elements of KnownKeys and the key maps, afterwards does not
+// affect it.
+func (conf Configuration) Compile() (*Parser, error) {
+	if err := conf.Validate(); err != nil {
+		return nil, err
+	}
+	conf.copyKeys()
+	t := new(charTable)
+	for c := range t {
+		t[c] = conf.Classify(byte(c))
+	}
+	if conf.ListValues {
+		// The scanner tracks the nesting of list values.
+		t[']'] |= CharListBracket
+	}
+	conf.table = t
+	return &Parser{conf}, nil
+}
+
+// MustCompile is like Compile, but panics if the configuration is invalid,
+// for initializing package-level variables.
+func (conf Configuration) MustCompile() *Parser {
+	p, err := conf.Compile()
+	if err != nil {
+		panic(err)
+	}
+	return p
+}
+
+// Configuration returns a copy of the configuration the parser was compiled
+// from.
+func (p *Parser) Configuration() Configuration {
+	conf := p.conf
+	conf.table = nil
+	conf.copyKeys()
+	return conf
+}
+
+// copyKeys replaces the slices and maps of keys with copies.
+func (conf *Configuration) copyKeys() {
+	conf.KnownKeys = append([]string(nil), conf.KnownKeys...)
+	conf.KeyAliases = copyMap(conf.KeyAliases)
+	conf.DeprecatedKeys = copyMap(conf.DeprecatedKeys)
+}
+
+func copyMap(m map[string]string) map[string]string {
+	if m == nil {
+		return nil
+	}
+	c := make(map[string]string, len(m))
+	for k, v := range m {
+		c[k] = v
+	}
+	return c
+}
+
+// ParseFunc is like Configuration.ParseFunc.
+func (p *Parser) ParseFunc(tag string, callback func(key, value string) error) error {
+	return parseFunc(tag, &p.conf, ErrorInfo{}, callback, nil, nil)
+}
+
+// ParseFuncWithInfo is like Configuration.ParseFuncWithInfo.
+func (p *Parser) ParseFuncWithInfo(tag string, info ErrorInfo, callback func(key, value string) error) error {
+	return parseFunc(tag, &p.conf, info, callback, nil, nil)
+}
+
+// Parse is like Configuration.Parse.
+func (p *Parser) Parse(tag string) (name string, opts map[string]string, err error) {
+	return p.conf.Parse(tag)
+}
+
+// ParseResult is like Configuration.ParseResult. Result.Conf is the
+// configuration the parser was compiled from, without the tables.
+func (p *Parser) ParseResult(tag string) (Result, error) {
+	r, err := p.conf.ParseResult(tag)
+	r.Conf.table = nil
+	return r, err
+}
+
+// ParseTrusted is like Configuration.ParseTrusted.
+func (p *Parser) ParseTrusted(tag string) (name string, opts map[string]string) {
+	return p.conf.ParseTrusted(tag)
+}
@@ -0,0 +1,122 @@
+package tagparser
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"sync"
+	"testing"
+)
+
+func TestParser_matchesConfiguration(t *testing.T) {
+	var tests = []struct {
+		conf Configuration
+		tag  string
+	}{
+		{Configuration{}, ``},
+		{Configuration{NamePosition: NameFirst}, ` alfa , bravo : charlie ,delta:'x, y',echo\,x:\:`},
+		{Configuration{NamePosition: NameLast}, `alfa,bravo:charlie,'name'`},
+		{Configuration{}, `alfa:'x,bravo`},
+		{Configuration{}, `a\`},
+		{Configuration{}, `:x,alfa:1,alfa:2`},
+		{Configuration{QuoteChars: "\"`"}, "alfa:\"x,'y\",bravo:`\\`,charlie:'z'"},
+		{Configuration{KeyValueSeparator: '=', ItemSeparator: ';'}, `alfa=a:b;bravo=x,y;charlie`},
+		{Configuration{ListValues: true}, `alfa:[x,[y]],bravo:[1,2,charlie:]`},
+		{Configuration{ListValues: true}, `alfa:[x`},
+		{Configuration{GreedyLastValue: true}, `alfa:1,desc:a, b: c`},
+		{Configuration{DirectivePrefix: '#', NegationPrefix: '!'}, `#v:2,!alfa,bravo`},
+		{Configuration{AllowStandardEscapes: true, PercentDecodeValues: true}, `alfa:'\x41\n',bravo:%2C`},
+		{Configuration{CollectAllErrors: true}, `:a,b:'c,\z`},
+	}
+	for _, test := range tests {
+		p, err := test.conf.Compile()
+		if err != nil {
+			t.Fatalf("** Compile(%+v) error: %v", test.conf, err)
+		}
+		expected, expErr := test.conf.ParseResult(test.tag)
+		actual, err := p.ParseResult(test.tag)
+		if !reflect.DeepEqual(actual, expected) || fmt.Sprint(err) != fmt.Sprint(expErr) {
+			t.Errorf("** ParseResult(%q) = %+v, %v, wanted %+v, %v", test.tag, actual, err, expected, expErr)
+		}
+	}
+}
+
+func TestParser(t *testing.T) {
+	conf := Configuration{NamePosition: NameFirst, KnownKeys: []string{"bravo"}, KeyAliases: M{"b": "bravo"}}
+	p := conf.MustCompile()
+	conf.KnownKeys[0] = "charlie"
+	conf.KeyAliases["b"] = "charlie"
+
+	name, opts, err := p.Parse(`alfa,b:1`)
+	if name != "alfa" || !reflect.DeepEqual(opts, M{"bravo": "1"}) || err != nil {
+		t.Errorf("** Parse = %q, %q, %v", name, opts, err)
+	}
+	if name, opts := p.ParseTrusted(`alfa,charlie`); name != "alfa" || !reflect.DeepEqual(opts, M{"charlie": ""}) {
+		t.Errorf("** ParseTrusted = %q, %q", name, opts)
+	}
+	var items []string
+	err = p.ParseFunc(`alfa,bravo:1`, func(key, value string) error {
+		items = append(items, key+"="+value)
+		return nil
+	})
+	if expected := []string{"=alfa", "bravo=1"}; err != nil || !reflect.DeepEqual(items, expected) {
+		t.Errorf("** ParseFunc = %q, %v, wanted %q", items, err, expected)
+	}
+	err = p.ParseFuncWithInfo(`alfa,charlie`, ErrorInfo{Struct: "User"}, func(key, value string) error {
+		return nil
+	})
+	if expErr := `User: charlie: unknown option key (at 6)`; err == nil || err.Error() != expErr {
+		t.Errorf("** ParseFuncWithInfo error %v, wanted %s", err, expErr)
+	}
+
+	c := p.Configuration()
+	c.KnownKeys[0] = "delta"
+	c.KeyAliases["b"] = "delta"
+	if c.table != nil || c.NamePosition != NameFirst || p.Configuration().KnownKeys[0] != "bravo" || p.Configuration().KeyAliases["b"] != "bravo" || c.DeprecatedKeys != nil {
+		t.Errorf("** Configuration() = %+v", c)
+	}
+}
+
+func TestParser_invalid(t *testing.T) {
+	conf := Configuration{KeyValueSeparator: ','}
+	if p, err := conf.Compile(); p != nil || !errors.Is(err, ErrInvalidConfiguration) {
+		t.Errorf("** Compile = %v, %v, wanted ErrInvalidConfiguration", p, err)
+	}
+	defer func() {
+		if e := recover(); e == nil {
+			t.Errorf("** MustCompile did not panic")
+		}
+	}()
+	conf.MustCompile()
+}
+
+func TestParser_concurrent(t *testing.T) {
+	p := Configuration{NamePosition: NameFirst}.MustCompile()
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				tag := fmt.Sprintf("n%d,k%d:'%d'", i, j, j%3)
+				name, opts, err := p.Parse(tag)
+				if err != nil || name != fmt.Sprintf("n%d", i) || opts[fmt.Sprintf("k%d", j)] != fmt.Sprint(j%3) {
+					t.Errorf("** Parse(%q) = %q, %q, %v", tag, name, opts, err)
+				}
+			}
+		}(i)
+	}
+	wg.Wait()
+}
+
+func BenchmarkParser_ParseFunc(t *testing.B) {
+	p := Configuration{NamePosition: NameFirst, DuplicateKeys: DuplicateKeysCollect}.MustCompile()
+	for i := 0; i < t.N; i++ {
+		err := p.ParseFunc(benchTag, func(key, value string) error {
+			return nil
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+}
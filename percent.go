@@ -0,0 +1,68 @@
+package tagparser
+
+import "strings"
+
+// percentDecode decodes %XX sequences in value, which is the unquoted value
+// of the span start:end of the tag, reporting malformed sequences.
+func (s *scanner) percentDecode(value string, start, end int) string {
+	if strings.IndexByte(value, '%') < 0 {
+		return value
+	}
+	b := make([]byte, 0, len(value))
+	var pos []int
+	for i := 0; i < len(value); i++ {
+		c := value[i]
+		if c != '%' {
+			b = append(b, c)
+			continue
+		}
+		if i+2 < len(value) && isHex(value[i+1]) && isHex(value[i+2]) {
+			b = append(b, unhex(value[i+1])<<4|unhex(value[i+2]))
+			i += 2
+		} else {
+			if pos == nil {
+				// Escapes like \x25 can produce percent signs, so map the
+				// position within the value back to the tag.
+				appendUnquoteTrimPos(nil, s.tag[start:end], &s.conf, &pos)
+			}
+			s.fail(start+pos[i], CodeInvalidPercent)
+			b = append(b, c)
+		}
+	}
+	return string(b)
+}
+
+func isHex(c byte) bool {
+	return c >= '0' && c <= '9' || c >= 'a' && c <= 'f' || c >= 'A' && c <= 'F'
+}
+
+func unhex(c byte) byte {
+	switch {
+	case c <= '9':
+		return c - '0'
+	case c <= 'F':
+		return c - 'A' + 10
+	default:
+		return c - 'a' + 10
+	}
+}
+
+// PercentEncodeValue percent-encodes every byte of value except ASCII
+// letters, digits and `-._~` (the unreserved characters of RFC 3986), so that
+// the result can be used as a value without quoting in dialects with
+// Configuration.PercentDecodeValues.
+func PercentEncodeValue(value string) string {
+	const hex = "0123456789ABCDEF"
+	var b strings.Builder
+	for i := 0; i < len(value); i++ {
+		c := value[i]
+		if c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c == '-' || c == '.' || c == '_' || c == '~' {
+			b.WriteByte(c)
+		} else {
+			b.WriteByte('%')
+			b.WriteByte(hex[c>>4])
+			b.WriteByte(hex[c&0xF])
+		}
+	}
+	return b.String()
+}
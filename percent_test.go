@@ -0,0 +1,26 @@
+package tagparser
+
+import "testing"
+
+func TestPercentEncodeValue(t *testing.T) {
+	var tests = []struct {
+		value    string
+		expected string
+	}{
+		{``, ``},
+		{`abc-XYZ_09.~`, `abc-XYZ_09.~`},
+		{`a,b:c 'd'`, `a%2Cb%3Ac%20%27d%27`},
+		{"100%\\é", `100%25%5C%C3%A9`},
+	}
+	conf := Configuration{PercentDecodeValues: true}
+	for _, test := range tests {
+		actual := PercentEncodeValue(test.value)
+		if actual != test.expected {
+			t.Errorf("** PercentEncodeValue(%q) = %q, wanted %q", test.value, actual, test.expected)
+		}
+		_, opts, err := conf.Parse("k:" + actual)
+		if err != nil || opts["k"] != test.value {
+			t.Errorf("** Parse(%q) = %q, %v, wanted %q", "k:"+actual, opts["k"], err, test.value)
+		}
+	}
+}
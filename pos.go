@@ -0,0 +1,48 @@
+package tagparser
+
+// ParseFuncPos is like ParseFunc, but also reports byte offsets of every item
+// within the tag, for tools that highlight or fix individual options: keyPos
+// is the start of the key, valuePos is the start of the value, and end is the
+// end of the item, all excluding surrounding whitespace. For an item without
+// a value, valuePos equals end. With continuations, the value extends to the
+// end of the last continuation.
+func ParseFuncPos(tag string, callback func(key, value string, keyPos, valuePos, end int) error) error {
+	return nameNone.ParseFuncPos(tag, callback)
+}
+
+// ParseFuncPos is like the package-level ParseFuncPos, but parses the tag
+// according to the configuration. The name is reported with an empty key and
+// keyPos equal to valuePos. With Multiline, offsets refer to the dedented
+// tag.
+func (conf Configuration) ParseFuncPos(tag string, callback func(key, value string, keyPos, valuePos, end int) error) error {
+	if conf.Multiline {
+		tag, conf.Multiline = Dedent(tag), false
+	}
+	return parseItems(tag, &conf, ErrorInfo{}, func(key, value string, it item) error {
+		keyPos, end := trimmedSpan(&conf, tag, it.keyStart, it.keyEnd)
+		valuePos := end
+		if it.isName {
+			valuePos = keyPos
+		} else if it.hasValue {
+			valuePos, end = trimmedSpan(&conf, tag, it.valueStart, it.valueEnd)
+		}
+		return callback(key, value, keyPos, valuePos, end)
+	}, nil, nil)
+}
+
+// trimmedSpan returns the span of tag[start:end] without leading and trailing
+// whitespace, keeping escaped trailing whitespace.
+func trimmedSpan(conf *Configuration, tag string, start, end int) (int, int) {
+	ts, te := conf.trimSpace(tag[start:end])
+	ts, te = start+ts, start+te
+	if te < end {
+		var n int
+		for te-n > ts && tag[te-n-1] == '\\' {
+			n++
+		}
+		if n%2 == 1 {
+			te++
+		}
+	}
+	return ts, te
+}
@@ -0,0 +1,58 @@
+package tagparser
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseFuncPos(t *testing.T) {
+	nameFirst := Configuration{NamePosition: NameFirst}
+	var tests = []struct {
+		conf     Configuration
+		tag      string
+		expected string
+	}{
+		{Configuration{}, ``, ``},
+		{Configuration{}, `foo`, `foo= 0,3,3`},
+		{Configuration{}, ` foo , bar : boz `, `foo= 1,4,4 bar=boz 7,13,16`},
+		{Configuration{}, `a:'x, y',b:`, `a=x, y 0,2,8 b= 9,11,11`},
+		{Configuration{}, `a\ :b\ `, `a =b  0,4,7`},
+		{Configuration{}, `a:b\\ `, `a=b\ 0,2,5`},
+		{nameFirst, ` name ,a`, `=name 1,1,5 a= 7,8,8`},
+		{Configuration{ContinuationKey: "+"}, `a:x, +:y, b`, `a=xy 0,2,8 b= 10,11,11`},
+		{Configuration{ContinuationKey: "+"}, `a,+:y`, `a=y 0,4,5`},
+		{Configuration{Multiline: true}, "\n\ta:x,\n\tb:y\n", `a=x 0,2,3 b=y 5,7,8`},
+		{Configuration{UnicodeWhitespace: true}, "\u3000a\u00A0:\u00A0x\u3000,b", `a=x 3,9,10 b= 14,15,15`},
+	}
+	for _, test := range tests {
+		var items []string
+		err := test.conf.ParseFuncPos(test.tag, func(key, value string, keyPos, valuePos, end int) error {
+			items = append(items, fmt.Sprintf("%s=%s %d,%d,%d", key, value, keyPos, valuePos, end))
+			return nil
+		})
+		if err != nil {
+			t.Errorf("** ParseFuncPos(%q) error: %v", test.tag, err)
+		}
+		if actual := strings.Join(items, " "); actual != test.expected {
+			t.Errorf("** ParseFuncPos(%q) = %q, wanted %q", test.tag, actual, test.expected)
+		}
+	}
+}
+
+func TestParseFuncPos_error(t *testing.T) {
+	errSimulated := errors.New("simulated")
+	var positions []int
+	err := ParseFuncPos(`a, b:c`, func(key, value string, keyPos, valuePos, end int) error {
+		positions = append(positions, keyPos, valuePos, end)
+		return errSimulated
+	})
+	if expErr := "a: simulated (at 1)"; err == nil || err.Error() != expErr {
+		t.Errorf("** ParseFuncPos error %v, wanted %s", err, expErr)
+	}
+	if expected := []int{0, 1, 1, 3, 5, 6}; !reflect.DeepEqual(positions, expected) {
+		t.Errorf("** ParseFuncPos positions %v, wanted %v", positions, expected)
+	}
+}
@@ -0,0 +1,74 @@
+package tagparser
+
+// Presets for popular struct tag dialects. Each call returns a new
+// Configuration, so adjust it as needed:
+//
+//	conf := tagparser.JSON()
+//	conf.DuplicateKeys = tagparser.DuplicateKeysError
+//
+// Quotes and backslashes keep their special meaning in all presets, even
+// though the original parsers treat them literally; tags relying on that are
+// rare and better rewritten anyway.
+
+// noSeparator is a byte that never occurs in UTF-8 text, for dialects without
+// key-value options.
+const noSeparator = 0xFF
+
+// VMihailenco matches vmihailenco/tagparser, as used by msgpack and go-pg:
+// `name,key:value`.
+func VMihailenco() Configuration {
+	return Configuration{NamePosition: NameFirst}
+}
+
+// JSON matches encoding/json: `name,omitempty,string`. A tag of exactly `-`
+// sets Result.Skip, while `-,` names a field `-`. Repeated options are
+// ignored by encoding/json, so the last one wins here. Since encoding/json
+// allows colons in names, like `a:b`, there are no key-value options, and
+// options like `format:RFC3339` of encoding/json/v2 are returned as keys.
+func JSON() Configuration {
+	return Configuration{NamePosition: NameFirst, KeyValueSeparator: noSeparator, SkipMarker: "-", DuplicateKeys: DuplicateKeysLastWins}
+}
+
+// XML matches encoding/xml: `name,attr` or `a>b>c,omitempty`. Use SplitName
+// to obtain the path elements after the first one as modifiers. A namespace
+// is separated from the name by a space, and is returned as part of the name.
+// Since encoding/xml has no key-value options and namespaces are usually
+// URLs, colons are not special.
+func XML() Configuration {
+	return Configuration{NamePosition: NameFirst, NameModifierSeparator: '>', KeyValueSeparator: noSeparator, SkipMarker: "-", DuplicateKeys: DuplicateKeysLastWins}
+}
+
+// Gorm matches gorm.io/gorm: `column:name;type:varchar(100);not null`. Gorm
+// compares keys case-insensitively and lets later settings override earlier
+// ones. A tag of exactly `-` sets Result.Skip.
+func Gorm() Configuration {
+	return Configuration{ItemSeparator: ';', FoldKeys: true, SkipMarker: "-", DuplicateKeys: DuplicateKeysLastWins}
+}
+
+// Validator matches go-playground/validator:
+// `required,min=1,max=10,oneof=a b`. Validations run in order and may repeat,
+// so use ParseOrdered. A tag of exactly `-` sets Result.Skip. Alternatives
+// like `rgb|rgba` are returned as a single key, see SplitValue.
+func Validator() Configuration {
+	return Configuration{KeyValueSeparator: '=', SkipMarker: "-", DuplicateKeys: DuplicateKeysCollect}
+}
+
+// Protobuf matches the tags generated by protoc-gen-go:
+// `bytes,1,opt,name=foo,json=foo,proto3`. The wire type and field number are
+// positional items, returned as keys without values, so use ParseOrdered to
+// get them in order.
+func Protobuf() Configuration {
+	return Configuration{KeyValueSeparator: '=', DuplicateKeys: DuplicateKeysLastWins}
+}
+
+// ParseJSONName parses an encoding/json tag with the JSON preset, returning
+// the name along with its kind: `-` is NameSkipped, `-,` is NameExplicit with
+// name `-`, and an empty tag or `,omitempty` is NameDefault, meaning the
+// field name is used.
+func ParseJSONName(tag string) (name string, kind NameKind, opts map[string]string, err error) {
+	r, err := jsonPreset.ParseResult(tag)
+	return r.Name, r.NameKind(), r.Options, err
+}
+
+// jsonPreset is the configuration of ParseJSONName. Callers cannot modify it.
+var jsonPreset = JSON()
@@ -0,0 +1,41 @@
+package tagparser
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestPresets(t *testing.T) {
+	var tests = []struct {
+		conf     Configuration
+		tag      string
+		expected Result
+	}{
+		{VMihailenco(), `name,key:value`, Result{Name: "name", Options: M{"key": "value"}}},
+		{JSON(), `email,omitempty,string`, Result{Name: "email", Options: M{"omitempty": "", "string": ""}}},
+		{JSON(), `-`, Result{Name: "-", Skip: true}},
+		{JSON(), `-,`, Result{Name: "-"}},
+		{JSON(), `,omitempty,omitempty`, Result{Options: M{"omitempty": ""}}},
+		{JSON(), `at,format:RFC3339`, Result{Name: "at", Options: M{"format:RFC3339": ""}}},
+		{JSON(), `a:b,omitempty`, Result{Name: "a:b", Options: M{"omitempty": ""}}},
+		{XML(), `a>b>c,omitempty`, Result{Name: "a>b>c", Options: M{"omitempty": ""}}},
+		{XML(), `http://example.com/ns name,attr`, Result{Name: "http://example.com/ns name", Options: M{"attr": ""}}},
+		{Gorm(), `column:user_name;type:varchar(100);NOT NULL;Type:text`, Result{Options: M{"column": "user_name", "type": "text", "not null": ""}}},
+		{Gorm(), `-`, Result{Options: M{"-": ""}, Skip: true}},
+		{Validator(), `required,min=1,oneof=a b`, Result{Options: M{"required": "", "min": "1", "oneof": "a b"}}},
+		{Protobuf(), `bytes,1,opt,name=foo,json=foo,proto3`, Result{Options: M{"bytes": "", "1": "", "opt": "", "name": "foo", "json": "foo", "proto3": ""}}},
+	}
+	for _, test := range tests {
+		actual, err := test.conf.ParseResult(test.tag)
+		test.expected.Tag, test.expected.Conf = test.tag, test.conf
+		if err != nil {
+			t.Errorf("** ParseResult(%q) error: %v", test.tag, err)
+		}
+		if !reflect.DeepEqual(actual, test.expected) {
+			t.Errorf("** ParseResult(%q) = %+v, wanted %+v", test.tag, actual, test.expected)
+		}
+	}
+	if r := XML().SplitName("a>b>c"); r.Name != "a" || !reflect.DeepEqual(r.Modifiers, []string{"b", "c"}) {
+		t.Errorf("** XML().SplitName = %+v", r)
+	}
+}
@@ -0,0 +1,80 @@
+package tagparser
+
+// RawItem is an item of a tag as reported by ParseFuncRaw: the cooked key and
+// value, like ParseFunc returns them, alongside the exact text they were
+// parsed from, for tools that rewrite tags and want to keep untouched items
+// byte for byte.
+type RawItem struct {
+	// Key and Value are unquoted and unescaped, like ParseFunc reports them.
+	// The name comes with an empty Key.
+	Key   string `json:"key"`
+	Value string `json:"value"`
+
+	// RawKey and RawValue are the text of the key and the value within the
+	// tag, with quotes and escapes intact, excluding surrounding whitespace
+	// and the key-value separator. RawKey of the name is empty. With
+	// continuations, RawValue extends to the end of the last continuation.
+	RawKey   string `json:"rawKey"`
+	RawValue string `json:"rawValue"`
+
+	// KeyPos, ValuePos and End are byte offsets within the tag, like those
+	// reported by ParseFuncPos: RawKey is tag[KeyPos:KeyEnd], RawValue is
+	// tag[ValuePos:End].
+	KeyPos   int `json:"keyPos"`
+	KeyEnd   int `json:"keyEnd"`
+	ValuePos int `json:"valuePos"`
+	End      int `json:"end"`
+
+	// HasValue tells a key with an empty value, like `a:`, from a key
+	// without one.
+	HasValue bool `json:"hasValue"`
+
+	// WasQuoted is true if the value, or the name, is quoted, like in
+	// `a:''`, telling an empty quoted value from a missing one.
+	WasQuoted bool `json:"wasQuoted"`
+}
+
+// ParseFuncRaw is like ParseFunc, but reports every item along with its raw
+// text and offsets within the tag, so that a formatter can replace just the
+// items it changes.
+func ParseFuncRaw(tag string, callback func(it RawItem) error) error {
+	return nameNone.ParseFuncRaw(tag, callback)
+}
+
+// ParseFuncRaw is like the package-level ParseFuncRaw, but parses the tag
+// according to the configuration. With Multiline, the raw text and offsets
+// refer to the dedented tag.
+func (conf Configuration) ParseFuncRaw(tag string, callback func(it RawItem) error) error {
+	if conf.Multiline {
+		tag, conf.Multiline = Dedent(tag), false
+	}
+	return parseItems(tag, &conf, ErrorInfo{}, func(key, value string, it item) error {
+		r := RawItem{Key: key, Value: value, HasValue: it.hasValue}
+		r.KeyPos, r.KeyEnd = trimmedSpan(&conf, tag, it.keyStart, it.keyEnd)
+		r.ValuePos, r.End = r.KeyEnd, r.KeyEnd
+		if it.isName {
+			r.ValuePos, r.End = r.KeyPos, r.KeyEnd
+			r.KeyEnd = r.KeyPos
+		} else if it.hasValue {
+			r.ValuePos, r.End = trimmedSpan(&conf, tag, it.valueStart, it.valueEnd)
+		}
+		r.RawKey, r.RawValue = tag[r.KeyPos:r.KeyEnd], tag[r.ValuePos:r.End]
+		r.WasQuoted = r.ValuePos < r.End && conf.quotes().has(tag[r.ValuePos])
+		return callback(r)
+	}, nil, nil)
+}
+
+// RawSpan returns the byte offsets of the whole item within the tag, from
+// the start of the key to the end of the value, excluding surrounding
+// whitespace. A formatter can replace tag[start:end] to rewrite the item.
+func (it RawItem) RawSpan() (start, end int) {
+	return it.KeyPos, it.End
+}
+
+// NeedsUnescape reports whether the raw key or value differ from the cooked
+// ones, usually because of quotes or escapes, but also because of settings
+// like FoldKeys, KeyAliases or PercentDecodeValues. If it returns false,
+// RawKey and RawValue can be used as is.
+func (it RawItem) NeedsUnescape() bool {
+	return it.RawValue != it.Value || it.RawKey != it.Key
+}
@@ -0,0 +1,86 @@
+package tagparser
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestParseFuncRaw(t *testing.T) {
+	nameFirst := Configuration{NamePosition: NameFirst}
+	var tests = []struct {
+		conf     Configuration
+		tag      string
+		expected string
+	}{
+		{Configuration{}, ``, ``},
+		{Configuration{}, `foo`, `foo= [foo|] 0,3,3,3`},
+		{Configuration{}, ` foo , bar : boz `, `foo= [foo|] 1,4,4,4 bar=boz [bar|boz] 7,10,13,16`},
+		{Configuration{}, `a:'x, y',b:`, `a=x, y [a|'x, y'] 0,1,2,8 b=+ [b|] 9,10,11,11`},
+		{Configuration{}, `a\ :b\ `, `a =b  [a\ |b\ ] 0,3,4,7`},
+		{Configuration{AllowStandardEscapes: true}, `'a\tb':'\x41'`, "a\tb=A ['a\\tb'|'\\x41'] 0,6,7,13"},
+		{nameFirst, ` 'na,me' ,a`, `=na,me [|'na,me'] 1,1,1,8 a= [a|] 10,11,11,11`},
+		{Configuration{NegationPrefix: '!'}, `!a`, `a=false [!a|] 0,2,2,2`},
+		{Configuration{ContinuationKey: "+"}, `a:x, +:y, b`, `a=xy [a|x, +:y] 0,1,2,8 b= [b|] 10,11,11,11`},
+		{Configuration{Multiline: true}, "\n\ta:x,\n\tb:y\n", `a=x [a|x] 0,1,2,3 b=y [b|y] 5,6,7,8`},
+	}
+	for _, test := range tests {
+		var items []string
+		err := test.conf.ParseFuncRaw(test.tag, func(it RawItem) error {
+			value := it.Value
+			if it.HasValue && value == "" {
+				value = "+"
+			}
+			items = append(items, fmt.Sprintf("%s=%s [%s|%s] %d,%d,%d,%d", it.Key, value, it.RawKey, it.RawValue, it.KeyPos, it.KeyEnd, it.ValuePos, it.End))
+			return nil
+		})
+		if err != nil {
+			t.Errorf("** ParseFuncRaw(%q) error: %v", test.tag, err)
+		}
+		if actual := strings.Join(items, " "); actual != test.expected {
+			t.Errorf("** ParseFuncRaw(%q) = %q, wanted %q", test.tag, actual, test.expected)
+		}
+	}
+}
+
+func TestParseFuncRaw_WasQuoted(t *testing.T) {
+	conf := Configuration{NamePosition: NameFirst, QuoteChars: `'"`}
+	var quoted []string
+	conf.ParseFuncRaw(`'n',a:'',b:,c," x ",d:x'y',e`, func(it RawItem) error {
+		quoted = append(quoted, fmt.Sprintf("%s=%v", it.Key, it.WasQuoted))
+		return nil
+	})
+	if actual, expected := strings.Join(quoted, " "), `=true a=true b=false c=false  x =false d=false e=false`; actual != expected {
+		t.Errorf("** ParseFuncRaw WasQuoted = %s, wanted %s", actual, expected)
+	}
+}
+
+func TestRawItem_RawSpan(t *testing.T) {
+	conf := Configuration{NamePosition: NameFirst, FoldKeys: true, NegationPrefix: '!'}
+	const tag = ` n , a:'x' , B:y , c\:d , e:f , !g `
+	var spans []string
+	conf.ParseFuncRaw(tag, func(it RawItem) error {
+		start, end := it.RawSpan()
+		spans = append(spans, fmt.Sprintf("%s:%v", tag[start:end], it.NeedsUnescape()))
+		return nil
+	})
+	if actual, expected := strings.Join(spans, " "), `n:false a:'x':true B:y:true c\:d:true e:f:false !g:true`; actual != expected {
+		t.Errorf("** RawSpan = %s, wanted %s", actual, expected)
+	}
+}
+
+func TestParseFuncRaw_error(t *testing.T) {
+	errSimulated := errors.New("simulated")
+	var raw []string
+	err := ParseFuncRaw(`a, b:'c'`, func(it RawItem) error {
+		raw = append(raw, it.RawKey+"|"+it.RawValue)
+		return errSimulated
+	})
+	if expErr := "a: simulated (at 1)"; err == nil || err.Error() != expErr {
+		t.Errorf("** ParseFuncRaw error %v, wanted %s", err, expErr)
+	}
+	if actual, expected := strings.Join(raw, " "), `a| b|'c'`; actual != expected {
+		t.Errorf("** ParseFuncRaw raw = %q, wanted %q", actual, expected)
+	}
+}
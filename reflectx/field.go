@@ -0,0 +1,62 @@
+// Package reflectx contains reflection-based helpers built on top of
+// tagparser, keeping the core parser free of reflect imports.
+package reflectx
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"sync"
+
+	"github.com/andreyvit/tagparser"
+)
+
+// ErrFieldNotFound is returned by Field when the struct has no such field.
+var ErrFieldNotFound = errors.New("field not found")
+
+type fieldCacheKey struct {
+	typ  reflect.Type
+	name string
+}
+
+type fieldCacheEntry struct {
+	tag   reflect.StructTag
+	found bool
+}
+
+var fieldCache sync.Map // fieldCacheKey -> fieldCacheEntry
+
+// Field parses the tagKey tag of the given field of struct type T (or a
+// pointer to one) according to conf, e.g. to find out the JSON name of
+// User.Email:
+//
+//	name, _, err := reflectx.Field[User]("Email", "json", tagparser.Configuration{NamePosition: tagparser.NameFirst})
+//
+// Promoted fields of embedded structs are found too. The struct field lookup
+// is cached, so Field is cheap to call repeatedly. A missing tag yields an
+// empty name and nil options; a missing field yields an error wrapping
+// ErrFieldNotFound.
+func Field[T any](fieldName, tagKey string, conf tagparser.Configuration) (name string, opts map[string]string, err error) {
+	typ := reflect.TypeOf((*T)(nil)).Elem()
+	key := fieldCacheKey{typ, fieldName}
+	v, ok := fieldCache.Load(key)
+	if !ok {
+		v, _ = fieldCache.LoadOrStore(key, lookupField(typ, fieldName))
+	}
+	entry := v.(fieldCacheEntry)
+	if !entry.found {
+		return "", nil, fmt.Errorf("%v.%s: %w", typ, fieldName, ErrFieldNotFound)
+	}
+	return conf.Parse(entry.tag.Get(tagKey))
+}
+
+func lookupField(typ reflect.Type, fieldName string) fieldCacheEntry {
+	if typ.Kind() == reflect.Pointer {
+		typ = typ.Elem()
+	}
+	if typ.Kind() != reflect.Struct {
+		return fieldCacheEntry{}
+	}
+	f, ok := typ.FieldByName(fieldName)
+	return fieldCacheEntry{f.Tag, ok}
+}
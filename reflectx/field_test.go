@@ -0,0 +1,73 @@
+package reflectx
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/andreyvit/tagparser"
+)
+
+type M = map[string]string
+
+type fieldTestBase struct {
+	ID int `json:"id,omitempty"`
+}
+
+type fieldTestUser struct {
+	fieldTestBase
+	Email string `json:"email_address,omitempty" db:"email"`
+	Plain string
+}
+
+func TestField(t *testing.T) {
+	conf := tagparser.Configuration{NamePosition: tagparser.NameFirst}
+	var tests = []struct {
+		testName string
+		field    string
+		tagKey   string
+		name     string
+		opts     map[string]string
+		error    string
+	}{
+		{`simple`, "Email", "json", "email_address", M{"omitempty": ""}, ``},
+		{`other key`, "Email", "db", "email", nil, ``},
+		{`promoted`, "ID", "json", "id", M{"omitempty": ""}, ``},
+		{`no tag`, "Plain", "json", "", nil, ``},
+		{`no field`, "Missing", "json", "", nil, `reflectx.fieldTestUser.Missing: field not found`},
+	}
+	for _, test := range tests {
+		t.Run(test.testName, func(t *testing.T) {
+			for i := 0; i < 2; i++ { // second iteration hits the cache
+				name, opts, err := Field[fieldTestUser](test.field, test.tagKey, conf)
+				if err != nil {
+					if ae := err.Error(); ae != test.error {
+						t.Errorf("** error %q, wanted %q", ae, test.error)
+					}
+				} else if test.error != "" {
+					t.Errorf("** no error, wanted %q", test.error)
+				}
+				if name != test.name {
+					t.Errorf("** name = %q, wanted %q", name, test.name)
+				}
+				if !reflect.DeepEqual(opts, test.opts) {
+					t.Errorf("** opts = %q, wanted %q", opts, test.opts)
+				}
+			}
+		})
+	}
+}
+
+func TestField_pointer(t *testing.T) {
+	name, _, err := Field[*fieldTestUser]("Email", "db", tagparser.Configuration{NamePosition: tagparser.NameFirst})
+	if err != nil || name != "email" {
+		t.Errorf("** Field = %q, %v, wanted %q", name, err, "email")
+	}
+}
+
+func TestField_not_struct(t *testing.T) {
+	_, _, err := Field[int]("Email", "db", tagparser.Configuration{})
+	if !errors.Is(err, ErrFieldNotFound) {
+		t.Errorf("** err = %v, wanted %v", err, ErrFieldNotFound)
+	}
+}
@@ -0,0 +1,23 @@
+package reflectx
+
+import "reflect"
+
+// FieldMask returns the tag-derived names of the fields of struct type t (as
+// resolved by ResolveFields) for which selector returns true, in field order.
+// A nil selector selects all fields. This is handy for building protobuf
+// FieldMasks, SQL column lists and GraphQL selection sets:
+//
+//	cols, err := reflectx.FieldMask(reflect.TypeOf(User{}), "db", func(name string, opts map[string]string) bool {
+//		_, readonly := opts["readonly"]
+//		return !readonly
+//	})
+func FieldMask(t reflect.Type, tagKey string, selector func(name string, opts map[string]string) bool) ([]string, error) {
+	fields, err := ResolveFields(t, tagKey)
+	var names []string
+	for _, f := range fields {
+		if selector == nil || selector(f.Name, f.Opts) {
+			names = append(names, f.Name)
+		}
+	}
+	return names, err
+}
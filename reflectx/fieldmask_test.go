@@ -0,0 +1,30 @@
+package reflectx
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestFieldMask(t *testing.T) {
+	typ := reflect.TypeOf(resolveUser{})
+	all, err := FieldMask(typ, "db", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []string{"id", "created_at", "Note", "Pick", "name", "email", "Plain", "inner", "Embedded"}
+	if !reflect.DeepEqual(all, expected) {
+		t.Errorf("** FieldMask = %q, wanted %q", all, expected)
+	}
+
+	writable, err := FieldMask(typ, "db", func(name string, opts map[string]string) bool {
+		_, readonly := opts["readonly"]
+		return !readonly && name != "Embedded"
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected = []string{"id", "created_at", "Note", "Pick", "email", "Plain", "inner"}
+	if !reflect.DeepEqual(writable, expected) {
+		t.Errorf("** FieldMask = %q, wanted %q", writable, expected)
+	}
+}
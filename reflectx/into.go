@@ -0,0 +1,144 @@
+package reflectx
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"time"
+
+	"github.com/andreyvit/tagparser"
+)
+
+// ErrUnknownOption is returned by ParseInto for options that do not map to
+// any field of the destination struct.
+var ErrUnknownOption = errors.New("unknown option")
+
+// ErrUnsupportedType is returned by ParseInto for destination fields of types
+// it cannot convert values to.
+var ErrUnsupportedType = errors.New("unsupported field type")
+
+var durationType = reflect.TypeOf(time.Duration(0))
+
+// ParseInto parses the tag according to conf and stores the options into the
+// fields of the struct pointed to by dest, which are mapped to option keys by
+// `tagopt` sub-tags:
+//
+//	type Column struct {
+//		Name      string        `tagopt:",name"`
+//		OmitEmpty bool          `tagopt:"omitempty"`
+//		Size      int           `tagopt:"size"`
+//		TTL       time.Duration `tagopt:"ttl"`
+//		Roles     []string      `tagopt:"roles,sep:;"`
+//	}
+//
+// A field with the `name` sub-tag option receives the name of the tag. Fields
+// may be strings, bools (an option without a value means true), integers,
+// floats, time.Duration or slices of those, which are split with
+// tagparser.SplitValue at `sep` (default '|').
+//
+// Conversion errors, unknown options (ErrUnknownOption) and duplicate keys
+// are reported as *tagparser.Error with the position of the option. Like
+// other parse funcs, ParseInto does not stop at such errors and returns the
+// first one. An invalid dest or sub-tag is reported right away.
+func ParseInto(conf tagparser.Configuration, tag string, dest any) error {
+	v := reflect.ValueOf(dest)
+	if v.Kind() != reflect.Pointer || v.IsNil() || v.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("%T: %w", dest, ErrNotStruct)
+	}
+	v = v.Elem()
+
+	type target struct {
+		index int
+		sep   byte
+	}
+	targets := make(map[string]target)
+	nameIndex := -1
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		sub, ok := sf.Tag.Lookup("tagopt")
+		if !ok || !sf.IsExported() {
+			continue
+		}
+		key, opts, err := tagparser.ParseName(sub)
+		if err != nil {
+			return fmt.Errorf("%v.%s: %w", t, sf.Name, err)
+		}
+		if _, ok := opts["name"]; ok {
+			nameIndex = i
+		} else if key != "" {
+			sep := byte('|')
+			if s := opts["sep"]; len(s) == 1 {
+				sep = s[0]
+			}
+			targets[key] = target{i, sep}
+		}
+	}
+
+	seen := make(map[string]bool)
+	return conf.ParseFunc(tag, func(key, value string) error {
+		if key == "" {
+			if nameIndex >= 0 {
+				v.Field(nameIndex).SetString(value)
+			}
+			return nil
+		}
+		if seen[key] {
+			return tagparser.ErrDuplicateKey
+		}
+		seen[key] = true
+		tg, ok := targets[key]
+		if !ok {
+			return ErrUnknownOption
+		}
+		f := v.Field(tg.index)
+		if f.Kind() != reflect.Slice {
+			return setValue(f, value, true)
+		}
+		items, err := tagparser.SplitValue(value, tg.sep)
+		s := reflect.MakeSlice(f.Type(), len(items), len(items))
+		for i, item := range items {
+			if e := setValue(s.Index(i), item, false); e != nil && err == nil {
+				err = e
+			}
+		}
+		f.Set(s)
+		return err
+	})
+}
+
+func setValue(f reflect.Value, value string, flag bool) error {
+	if f.Type() == durationType {
+		d, err := time.ParseDuration(value)
+		f.SetInt(int64(d))
+		return err
+	}
+	switch f.Kind() {
+	case reflect.String:
+		f.SetString(value)
+	case reflect.Bool:
+		if flag && value == "" {
+			f.SetBool(true)
+			return nil
+		}
+		b, err := strconv.ParseBool(value)
+		f.SetBool(b)
+		return err
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(value, 0, f.Type().Bits())
+		f.SetInt(n)
+		return err
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		n, err := strconv.ParseUint(value, 0, f.Type().Bits())
+		f.SetUint(n)
+		return err
+	case reflect.Float32, reflect.Float64:
+		n, err := strconv.ParseFloat(value, f.Type().Bits())
+		f.SetFloat(n)
+		return err
+	default:
+		return fmt.Errorf("%v: %w", f.Type(), ErrUnsupportedType)
+	}
+	return nil
+}
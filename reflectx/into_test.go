@@ -0,0 +1,87 @@
+package reflectx
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/andreyvit/tagparser"
+)
+
+type intoColumn struct {
+	Name      string        `tagopt:",name"`
+	OmitEmpty bool          `tagopt:"omitempty"`
+	Unique    bool          `tagopt:"unique"`
+	Size      int           `tagopt:"size"`
+	Small     int8          `tagopt:"small"`
+	Count     uint          `tagopt:"count"`
+	Ratio     float64       `tagopt:"ratio"`
+	TTL       time.Duration `tagopt:"ttl"`
+	Comment   string        `tagopt:"comment"`
+	Roles     []string      `tagopt:"roles"`
+	Ports     []int         `tagopt:"ports,sep:;"`
+	Bad       []chan int    `tagopt:"bad"`
+	Weird     struct{}      `tagopt:"weird"`
+	Untagged  string
+	private   string `tagopt:"private"`
+}
+
+func TestParseInto(t *testing.T) {
+	conf := tagparser.Configuration{NamePosition: tagparser.NameFirst}
+	var tests = []struct {
+		tag      string
+		expected intoColumn
+		error    string
+	}{
+		{``, intoColumn{}, ``},
+		{`email,omitempty,unique:false,size:0x10,count:3,ratio:0.5,ttl:5m,comment:'a, b',roles:admin|editor,ports:80;443`, intoColumn{
+			Name: "email", OmitEmpty: true, Size: 16, Count: 3, Ratio: 0.5, TTL: 5 * time.Minute, Comment: "a, b",
+			Roles: []string{"admin", "editor"}, Ports: []int{80, 443},
+		}, ``},
+		{`,size:big,ttl:soon`, intoColumn{}, `size: strconv.ParseInt: parsing "big": invalid syntax (at 2)`},
+		{`,small:300`, intoColumn{Small: 127}, `small: strconv.ParseInt: parsing "300": value out of range (at 2)`},
+		{`,unique:maybe,count:-1,ratio:x`, intoColumn{}, `unique: strconv.ParseBool: parsing "maybe": invalid syntax (at 2)`},
+		{`,ttl:soon`, intoColumn{}, `ttl: time: invalid duration "soon" (at 2)`},
+		{`,other:1`, intoColumn{}, `other: unknown option (at 2)`},
+		{`,private`, intoColumn{}, `private: unknown option (at 2)`},
+		{`,size:1,size:2`, intoColumn{Size: 1}, `size: duplicate option key (at 9)`},
+		{`,ports:1;x`, intoColumn{Ports: []int{1, 0}}, `ports: strconv.ParseInt: parsing "x": invalid syntax (at 2)`},
+		{`,roles:'a`, intoColumn{Roles: []string{"a"}}, `unterminated quote (at 8)`},
+		{`,roles:\'a|b`, intoColumn{Roles: []string{"a|b"}}, `roles: unterminated quote (at 1) (at 2)`},
+		{`,weird:1`, intoColumn{}, `weird: struct {}: unsupported field type (at 2)`},
+		{`,bad:1`, intoColumn{Bad: []chan int{nil}}, `bad: chan int: unsupported field type (at 2)`},
+	}
+	for _, test := range tests {
+		var actual intoColumn
+		err := ParseInto(conf, test.tag, &actual)
+		if err != nil {
+			if ae := err.Error(); ae != test.error {
+				t.Errorf("** ParseInto(%q) error %q, wanted %q", test.tag, ae, test.error)
+			}
+		} else if test.error != "" {
+			t.Errorf("** ParseInto(%q) no error, wanted %q", test.tag, test.error)
+		}
+		if !reflect.DeepEqual(actual, test.expected) {
+			t.Errorf("** ParseInto(%q) = %+v, wanted %+v", test.tag, actual, test.expected)
+		}
+	}
+}
+
+func TestParseInto_errors(t *testing.T) {
+	var s string
+	if err := ParseInto(tagparser.Configuration{}, `a`, &s); !errors.Is(err, ErrNotStruct) {
+		t.Errorf("** err = %v, wanted %v", err, ErrNotStruct)
+	}
+	if err := ParseInto(tagparser.Configuration{}, `a`, intoColumn{}); !errors.Is(err, ErrNotStruct) {
+		t.Errorf("** err = %v, wanted %v", err, ErrNotStruct)
+	}
+
+	var bad struct {
+		A string `tagopt:"a,'b"`
+	}
+	const expErr = `struct { A string "tagopt:\"a,'b\"" }.A: unterminated quote (at 3)`
+	if err := ParseInto(tagparser.Configuration{}, `a`, &bad); err == nil || err.Error() != expErr {
+		t.Errorf("** err = %v, wanted %v", err, expErr)
+	}
+}
@@ -0,0 +1,236 @@
+package reflectx
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/andreyvit/tagparser"
+)
+
+// ErrNotStruct is returned when a struct type is expected, but a different
+// type is given.
+var ErrNotStruct = errors.New("not a struct type")
+
+// ErrNameCollision is the cause of NameCollisionError.
+var ErrNameCollision = errors.New("field name collision")
+
+// NameCollisionError reports several fields resolving to the same name under
+// CollisionError policy.
+type NameCollisionError struct {
+	// Name is the colliding tag-derived name.
+	Name string
+	// Paths are the Go paths of the colliding fields, least nested first.
+	Paths []string
+}
+
+func (e *NameCollisionError) Error() string {
+	return fmt.Sprintf("%s %q: %s", ErrNameCollision, e.Name, strings.Join(e.Paths, ", "))
+}
+
+func (e *NameCollisionError) Unwrap() error {
+	return ErrNameCollision
+}
+
+// CollisionPolicy determines how ResolveFieldsWithPolicy handles several
+// fields with the same name, e.g. an outer field and a promoted field of an
+// embedded struct.
+type CollisionPolicy int
+
+const (
+	// CollisionGoRules resolves collisions silently like encoding/json does,
+	// see ResolveFields.
+	CollisionGoRules CollisionPolicy = iota
+
+	// CollisionError resolves collisions like CollisionGoRules, but also
+	// reports the first one as a *NameCollisionError.
+	CollisionError
+
+	// CollisionFirstWins keeps the field that comes first in the struct,
+	// regardless of nesting depth and tags.
+	CollisionFirstWins
+)
+
+// ResolvedField is a field of a struct as seen by a serializer, with its name
+// derived from a tag.
+type ResolvedField struct {
+	// Name is the name from the tag, or the Go field name if the tag does not
+	// provide one.
+	Name string
+	// Opts are the options of the tag.
+	Opts map[string]string
+	// Tagged is true if Name comes from the tag.
+	Tagged bool
+	// Index is the index sequence for reflect.Value.FieldByIndex.
+	Index []int
+	// Path is the Go path to the field, e.g. "Base.ID" for a promoted field.
+	Path string
+}
+
+// ResolveFields lists the fields of struct type t (or a pointer to one) named
+// according to the tagKey tag, parsed with the name first, following the
+// rules of encoding/json:
+//
+//   - unexported fields and fields tagged "-" are skipped;
+//   - fields of embedded structs without a tag name are promoted;
+//   - among fields with the same name, the least nested one wins; if there
+//     are several at the same depth, a tagged one wins if it is the only
+//     tagged one, otherwise all of them are dropped.
+//
+// The fields are returned in the order of their indices. Tag syntax errors
+// do not stop resolution; the first one is returned, describing the struct
+// and the field via tagparser.ErrorInfo.
+func ResolveFields(t reflect.Type, tagKey string) ([]ResolvedField, error) {
+	return ResolveFieldsWithPolicy(t, tagKey, CollisionGoRules)
+}
+
+// ResolveFieldsWithPolicy is like ResolveFields, but handles fields with the
+// same name according to the given policy. A tag syntax error takes precedence
+// over a collision error.
+func ResolveFieldsWithPolicy(t reflect.Type, tagKey string, policy CollisionPolicy) ([]ResolvedField, error) {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("%v: %w", t, ErrNotStruct)
+	}
+
+	type embedded struct {
+		typ   reflect.Type
+		index []int
+		path  string
+	}
+	var fields []ResolvedField
+	var firstErr error
+	visited := make(map[reflect.Type]bool)
+	next := []embedded{{typ: t}}
+	for len(next) > 0 {
+		current := next
+		next = nil
+		for _, e := range current {
+			if visited[e.typ] {
+				continue
+			}
+			for i := 0; i < e.typ.NumField(); i++ {
+				sf := e.typ.Field(i)
+				ft := sf.Type
+				if ft.Kind() == reflect.Pointer {
+					ft = ft.Elem()
+				}
+				if !sf.IsExported() && !(sf.Anonymous && ft.Kind() == reflect.Struct) {
+					continue
+				}
+				tag := sf.Tag.Get(tagKey)
+				if tag == "-" {
+					continue
+				}
+				info := tagparser.ErrorInfo{Struct: e.typ.Name(), Field: sf.Name, Namespace: tagKey}
+				name, opts, err := parseName(tag, info)
+				if err != nil && firstErr == nil {
+					firstErr = err
+				}
+
+				index := make([]int, len(e.index)+1)
+				copy(index, e.index)
+				index[len(e.index)] = i
+				path := sf.Name
+				if e.path != "" {
+					path = e.path + "." + sf.Name
+				}
+
+				if name == "" && sf.Anonymous && ft.Kind() == reflect.Struct {
+					next = append(next, embedded{ft, index, path})
+					continue
+				}
+				if !sf.IsExported() {
+					continue
+				}
+				f := ResolvedField{Name: name, Opts: opts, Tagged: name != "", Index: index, Path: path}
+				if !f.Tagged {
+					f.Name = sf.Name
+				}
+				fields = append(fields, f)
+			}
+		}
+		for _, e := range current {
+			visited[e.typ] = true
+		}
+	}
+
+	sort.SliceStable(fields, func(i, j int) bool {
+		a, b := &fields[i], &fields[j]
+		if a.Name != b.Name {
+			return a.Name < b.Name
+		}
+		if len(a.Index) != len(b.Index) {
+			return len(a.Index) < len(b.Index)
+		}
+		return a.Tagged && !b.Tagged
+	})
+	result := fields[:0]
+	for i := 0; i < len(fields); {
+		j := i + 1
+		for j < len(fields) && fields[j].Name == fields[i].Name {
+			j++
+		}
+		if j > i+1 && policy == CollisionError && firstErr == nil {
+			e := &NameCollisionError{Name: fields[i].Name}
+			for _, f := range fields[i:j] {
+				e.Paths = append(e.Paths, f.Path)
+			}
+			firstErr = e
+		}
+		switch {
+		case j == i+1:
+			result = append(result, fields[i])
+		case policy == CollisionFirstWins:
+			first := i
+			for k := i + 1; k < j; k++ {
+				if indexLess(fields[k].Index, fields[first].Index) {
+					first = k
+				}
+			}
+			result = append(result, fields[first])
+		case len(fields[i].Index) < len(fields[i+1].Index) || fields[i].Tagged && !fields[i+1].Tagged:
+			result = append(result, fields[i])
+		}
+		i = j
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return indexLess(result[i].Index, result[j].Index)
+	})
+	return result, firstErr
+}
+
+var nameFirst = tagparser.Configuration{NamePosition: tagparser.NameFirst}
+
+func parseName(tag string, info tagparser.ErrorInfo) (name string, opts map[string]string, err error) {
+	return parseWithInfo(nameFirst, tag, info)
+}
+
+// parseWithInfo is like conf.Parse, but returns errors carrying info.
+func parseWithInfo(conf tagparser.Configuration, tag string, info tagparser.ErrorInfo) (name string, opts map[string]string, err error) {
+	name, opts, err = conf.Parse(tag)
+	var list *tagparser.ErrorList
+	var e *tagparser.Error
+	if errors.As(err, &list) {
+		for _, e := range list.Errors {
+			e.Info = info
+		}
+	} else if errors.As(err, &e) {
+		e.Info = info
+	}
+	return
+}
+
+// indexLess compares field index sequences, none of which can be a prefix of
+// another.
+func indexLess(a, b []int) bool {
+	i := 0
+	for i < len(a)-1 && i < len(b)-1 && a[i] == b[i] {
+		i++
+	}
+	return a[i] < b[i]
+}
@@ -0,0 +1,140 @@
+package reflectx
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+type resolveBase struct {
+	ID        int    `db:"id"`
+	CreatedAt string `db:"created_at"`
+	Shadowed  string `db:"name"`
+}
+
+type ResolveExported struct {
+	Note string
+}
+
+type resolveConflictA struct {
+	Dup string
+}
+
+type resolveConflictB struct {
+	Dup string
+}
+
+type resolveTaggedA struct {
+	Pick string `db:"Pick"`
+}
+
+type resolveTaggedB struct {
+	Pick string
+}
+
+type resolveHidden struct {
+	Hidden string
+}
+
+type resolveCycle struct {
+	*resolveCycle
+	Loop int `db:"loop"`
+}
+
+type resolveUser struct {
+	resolveBase
+	*ResolveExported
+	resolveConflictA
+	resolveConflictB
+	resolveTaggedA
+	resolveTaggedB
+	resolveHidden `db:"hidden"` // unexported, but not promoted because of the name
+	Name          string        `db:"name,readonly"`
+	Email         string        `db:"email"`
+	Skipped       string        `db:"-"`
+	Plain         string
+	private       string
+	Inner         resolveBase `db:"inner"`
+	Embedded      struct {
+		X int
+	}
+}
+
+func TestResolveFields(t *testing.T) {
+	fields, err := ResolveFields(reflect.TypeOf(&resolveUser{}), "db")
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []ResolvedField{
+		{Name: "id", Tagged: true, Index: []int{0, 0}, Path: "resolveBase.ID"},
+		{Name: "created_at", Tagged: true, Index: []int{0, 1}, Path: "resolveBase.CreatedAt"},
+		{Name: "Note", Index: []int{1, 0}, Path: "ResolveExported.Note"},
+		{Name: "Pick", Tagged: true, Index: []int{4, 0}, Path: "resolveTaggedA.Pick"},
+		{Name: "name", Opts: map[string]string{"readonly": ""}, Tagged: true, Index: []int{7}, Path: "Name"},
+		{Name: "email", Tagged: true, Index: []int{8}, Path: "Email"},
+		{Name: "Plain", Index: []int{10}, Path: "Plain"},
+		{Name: "inner", Tagged: true, Index: []int{12}, Path: "Inner"},
+		{Name: "Embedded", Index: []int{13}, Path: "Embedded"},
+	}
+	if !reflect.DeepEqual(fields, expected) {
+		t.Errorf("** ResolveFields =\n%+v\nwanted:\n%+v", fields, expected)
+	}
+}
+
+func TestResolveFields_cycle(t *testing.T) {
+	fields, err := ResolveFields(reflect.TypeOf(resolveCycle{}), "db")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(fields) != 1 || fields[0].Name != "loop" {
+		t.Errorf("** ResolveFields = %+v", fields)
+	}
+}
+
+func TestResolveFields_errors(t *testing.T) {
+	_, err := ResolveFields(reflect.TypeOf(42), "db")
+	if !errors.Is(err, ErrNotStruct) {
+		t.Errorf("** err = %v, wanted %v", err, ErrNotStruct)
+	}
+
+	type bad struct {
+		A string `db:"a,x:'unterminated"`
+		B string `db:"b,x,x"`
+		C string `db:"c"`
+	}
+	fields, err := ResolveFields(reflect.TypeOf(bad{}), "db")
+	const expErr = `bad.A db: unterminated quote (at 5)`
+	if err == nil || err.Error() != expErr {
+		t.Errorf("** err = %v, wanted %v", err, expErr)
+	}
+	if len(fields) != 3 {
+		t.Errorf("** ResolveFields = %+v, wanted 3 fields", fields)
+	}
+}
+
+func TestResolveFieldsWithPolicy(t *testing.T) {
+	fields, err := ResolveFieldsWithPolicy(reflect.TypeOf(resolveUser{}), "db", CollisionError)
+	const expErr = `field name collision "Dup": resolveConflictA.Dup, resolveConflictB.Dup`
+	if err == nil || err.Error() != expErr {
+		t.Errorf("** err = %v, wanted %v", err, expErr)
+	}
+	if !errors.Is(err, ErrNameCollision) {
+		t.Errorf("** err = %v, wanted %v", err, ErrNameCollision)
+	}
+	if len(fields) != 9 {
+		t.Errorf("** ResolveFieldsWithPolicy(CollisionError) = %+v, wanted 9 fields", fields)
+	}
+
+	fields, err = ResolveFieldsWithPolicy(reflect.TypeOf(resolveUser{}), "db", CollisionFirstWins)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var paths []string
+	for _, f := range fields {
+		paths = append(paths, f.Path)
+	}
+	expected := []string{"resolveBase.ID", "resolveBase.CreatedAt", "resolveBase.Shadowed", "ResolveExported.Note", "resolveConflictA.Dup", "resolveTaggedA.Pick", "Email", "Plain", "Inner", "Embedded"}
+	if !reflect.DeepEqual(paths, expected) {
+		t.Errorf("** ResolveFieldsWithPolicy(CollisionFirstWins) paths = %q, wanted %q", paths, expected)
+	}
+}
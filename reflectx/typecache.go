@@ -0,0 +1,63 @@
+package reflectx
+
+import (
+	"reflect"
+	"sync"
+
+	"github.com/andreyvit/tagparser"
+)
+
+// TypeCache parses the tags of struct fields once per type and tag key, for
+// libraries that look up tags of the same types over and over. It is safe for
+// concurrent use.
+type TypeCache struct {
+	conf    tagparser.Configuration
+	entries sync.Map // typeCacheKey -> []FieldTag
+}
+
+type typeCacheKey struct {
+	typ    reflect.Type
+	tagKey string
+}
+
+// FieldTag is the parsed tag of a struct field, as cached by TypeCache.
+type FieldTag struct {
+	// Name is the name from the tag, if the configuration has one.
+	Name string
+	// Opts are the options of the tag.
+	Opts map[string]string
+	// Err is the parse error, if any, describing the struct and the field via
+	// tagparser.ErrorInfo.
+	Err error
+}
+
+// NewTypeCache returns a cache that parses tags according to conf.
+func NewTypeCache(conf tagparser.Configuration) *TypeCache {
+	return &TypeCache{conf: conf}
+}
+
+// Fields returns the parsed tagKey tags of the fields of struct type t (or a
+// pointer to one), indexed like reflect.Type.Field. Fields without the tag
+// have zero entries. Returns nil if t is not a struct type. The result is
+// shared, so it must not be modified.
+func (c *TypeCache) Fields(t reflect.Type, tagKey string) []FieldTag {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct {
+		return nil
+	}
+	key := typeCacheKey{t, tagKey}
+	if v, ok := c.entries.Load(key); ok {
+		return v.([]FieldTag)
+	}
+	fields := make([]FieldTag, t.NumField())
+	for i := range fields {
+		sf := t.Field(i)
+		info := tagparser.ErrorInfo{Struct: t.Name(), Field: sf.Name, Namespace: tagKey}
+		f := &fields[i]
+		f.Name, f.Opts, f.Err = parseWithInfo(c.conf, sf.Tag.Get(tagKey), info)
+	}
+	v, _ := c.entries.LoadOrStore(key, fields)
+	return v.([]FieldTag)
+}
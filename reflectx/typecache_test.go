@@ -0,0 +1,70 @@
+package reflectx
+
+import (
+	"reflect"
+	"sync"
+	"testing"
+
+	"github.com/andreyvit/tagparser"
+)
+
+type typeCacheUser struct {
+	ID    int    `db:"id,pk"`
+	Email string `db:"email,x:'bad"`
+	Plain string
+	Dup   string `db:"dup,a,a"`
+}
+
+func TestTypeCache(t *testing.T) {
+	c := NewTypeCache(tagparser.Configuration{NamePosition: tagparser.NameFirst})
+	fields := c.Fields(reflect.TypeOf(&typeCacheUser{}), "db")
+	if len(fields) != 4 {
+		t.Fatalf("** Fields = %+v, wanted 4 entries", fields)
+	}
+	if f := fields[0]; f.Name != "id" || !reflect.DeepEqual(f.Opts, M{"pk": ""}) || f.Err != nil {
+		t.Errorf("** Fields[0] = %+v", f)
+	}
+	if f, expErr := fields[1], `typeCacheUser.Email db: unterminated quote (at 9)`; f.Name != "email" || f.Err == nil || f.Err.Error() != expErr {
+		t.Errorf("** Fields[1] = %+v, wanted error %v", f, expErr)
+	}
+	if f := fields[2]; !reflect.DeepEqual(f, FieldTag{}) {
+		t.Errorf("** Fields[2] = %+v, wanted zero", f)
+	}
+	if f, expErr := fields[3], `typeCacheUser.Dup db: a: duplicate option key (at 7)`; f.Err == nil || f.Err.Error() != expErr {
+		t.Errorf("** Fields[3] = %+v, wanted error %v", f, expErr)
+	}
+
+	again := c.Fields(reflect.TypeOf(typeCacheUser{}), "db")
+	if &again[0] != &fields[0] {
+		t.Errorf("** Fields not cached")
+	}
+	if other := c.Fields(reflect.TypeOf(typeCacheUser{}), "json"); &other[0] == &fields[0] {
+		t.Errorf("** Fields cached across tag keys")
+	}
+	if f := c.Fields(reflect.TypeOf(42), "db"); f != nil {
+		t.Errorf("** Fields(int) = %+v, wanted nil", f)
+	}
+}
+
+func TestTypeCache_collect(t *testing.T) {
+	c := NewTypeCache(tagparser.Configuration{NamePosition: tagparser.NameFirst, CollectAllErrors: true})
+	fields := c.Fields(reflect.TypeOf(typeCacheUser{}), "db")
+	if f, expErr := fields[3], `typeCacheUser.Dup db: a: duplicate option key (at 7)`; f.Err == nil || f.Err.Error() != expErr {
+		t.Errorf("** Fields[3] = %+v, wanted error %v", f, expErr)
+	}
+}
+
+func TestTypeCache_concurrent(t *testing.T) {
+	c := NewTypeCache(tagparser.Configuration{NamePosition: tagparser.NameFirst})
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if f := c.Fields(reflect.TypeOf(typeCacheUser{}), "db"); f[0].Name != "id" {
+				t.Errorf("** Fields[0] = %+v", f[0])
+			}
+		}()
+	}
+	wg.Wait()
+}
@@ -0,0 +1,418 @@
+package tagparser
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Schema describes the options a tag may have, so that typos like
+// `omitempy` are reported instead of being silently ignored.
+type Schema struct {
+	// Keys lists the known option keys.
+	Keys []KeySpec
+	// AllowUnknown disables reporting of keys not listed in Keys.
+	AllowUnknown bool
+	// RequireName reports tags without a name.
+	RequireName bool
+	// Exclusive lists groups of mutually exclusive keys, like required and
+	// omitempty, of which a tag may have at most one. An empty key in a group
+	// stands for a non-empty name.
+	Exclusive [][]string
+	// Ordered requires the keys to appear in the order of Keys. It is
+	// checked by ParseWithSchema, but not by Validate, which only sees a map.
+	Ordered bool
+}
+
+// KeySpec describes an option key of a Schema.
+type KeySpec struct {
+	Key string
+	// Required reports tags without the key.
+	Required bool
+	// Value is the kind of value the key takes.
+	Value ValueKind
+	// Enum lists allowed values for ValueEnum.
+	Enum []string
+	// Separator separates the items of ValueList values, ',' if zero.
+	Separator byte
+	// Count is the number of tags the key has been found in by
+	// InferSchema. It is not used for validation.
+	Count int
+}
+
+// ValueKind describes the value an option of a Schema takes.
+type ValueKind int
+
+const (
+	// ValueAny accepts any value, or none.
+	ValueAny ValueKind = iota
+	// ValueNone is a flag that does not take a value.
+	ValueNone
+	// ValueString requires a non-empty value.
+	ValueString
+	// ValueBool accepts no value (meaning true) or a value accepted by
+	// strconv.ParseBool.
+	ValueBool
+	// ValueInt requires a decimal integer.
+	ValueInt
+	// ValueEnum requires one of KeySpec.Enum.
+	ValueEnum
+	// ValueList is a list split at KeySpec.Separator by SplitValue, which
+	// ParseWithSchema returns as []string. An empty value is an empty list.
+	ValueList
+)
+
+// SchemaError is the Cause of CodeUnknownKey, CodeMissingKey,
+// CodeInvalidValue, CodeConflictingKeys and CodeKeyOrder errors, and is
+// returned as is by Schema.Validate. Use errors.As to tell these contract
+// violations from syntax errors, whose Cause is not a *SchemaError.
+type SchemaError struct {
+	Code ErrorCode
+	// Key is the offending option key, or empty for a missing or
+	// conflicting name.
+	Key string
+	// Value is the offending value for CodeInvalidValue.
+	Value string
+	// Other is the key that Key conflicts with for CodeConflictingKeys,
+	// or empty for the name, and the key that Key must precede for
+	// CodeKeyOrder.
+	Other string
+	// Msg describes the problem without the key.
+	Msg string
+}
+
+// Unwrap returns ErrUnknownKey for CodeUnknownKey errors, and nil otherwise.
+func (e *SchemaError) Unwrap() error {
+	if e.Code == CodeUnknownKey {
+		return ErrUnknownKey
+	}
+	return nil
+}
+
+func (e *SchemaError) Error() string {
+	if e.Key == "" {
+		return e.Msg
+	}
+	return e.Key + ": " + e.Msg
+}
+
+// Validate checks an already parsed tag against the schema, returning the
+// first problem found as *SchemaError. Keys are checked in sorted order,
+// followed by conflicts of the keys in sorted order, including the ones with
+// the name, and missing required keys in schema order.
+func (s *Schema) Validate(name string, opts map[string]string) error {
+	keys := make([]string, 0, len(opts))
+	for k := range opts {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		if err := s.ValidateOption(k, opts[k]); err != nil {
+			return err
+		}
+	}
+	has := Result{Name: name, Options: opts}.has
+	for _, k := range keys {
+		if err := s.validateConflict(k, has); err != nil {
+			return err
+		}
+	}
+	return s.validateMissing(name, has)
+}
+
+// ValidateOption checks a single option against the schema, returning
+// *SchemaError for unknown keys and invalid values. It is suitable for use in
+// a ParseFunc callback; conflicts between keys are not checked.
+func (s *Schema) ValidateOption(key, value string) error {
+	spec := s.lookup(key)
+	if spec == nil {
+		if s.AllowUnknown {
+			return nil
+		}
+		return &SchemaError{Code: CodeUnknownKey, Key: key, Msg: "unknown option key"}
+	}
+	var msg string
+	switch spec.Value {
+	case ValueNone:
+		if value != "" {
+			msg = "option does not take a value"
+		}
+	case ValueString:
+		if value == "" {
+			msg = "option requires a value"
+		}
+	case ValueBool:
+		if value != "" {
+			if _, err := strconv.ParseBool(value); err != nil {
+				msg = "invalid boolean value " + strconv.Quote(value)
+			}
+		}
+	case ValueInt:
+		if _, err := strconv.Atoi(value); err != nil {
+			msg = "invalid integer value " + strconv.Quote(value)
+		}
+	case ValueEnum:
+		msg = "invalid value " + strconv.Quote(value) + ", wanted one of " + strings.Join(spec.Enum, ", ")
+		for _, v := range spec.Enum {
+			if v == value {
+				msg = ""
+			}
+		}
+	case ValueList:
+		if _, err := SplitValue(value, spec.separator()); err != nil {
+			msg = "invalid list value " + strconv.Quote(value) + ": " + err.Error()
+		}
+	}
+	if msg != "" {
+		return &SchemaError{Code: CodeInvalidValue, Key: key, Value: value, Msg: msg}
+	}
+	return nil
+}
+
+// validateOrder returns a CodeKeyOrder error if the tag already has a key
+// that the schema lists after key.
+func (s *Schema) validateOrder(key string, has func(key string) bool) error {
+	if !s.Ordered {
+		return nil
+	}
+	found := false
+	for i := range s.Keys {
+		other := s.Keys[i].Key
+		if other == key {
+			found = true
+		} else if found && has(other) {
+			return &SchemaError{Code: CodeKeyOrder, Key: key, Other: other, Msg: "option must come before " + strconv.Quote(other)}
+		}
+	}
+	return nil
+}
+
+// validateConflict returns a CodeConflictingKeys error if key, or the name if
+// key is empty, shares an Exclusive group with another key the tag has.
+func (s *Schema) validateConflict(key string, has func(key string) bool) error {
+	for _, group := range s.Exclusive {
+		if !containsKey(group, key) {
+			continue
+		}
+		for _, other := range group {
+			if other == key || !has(other) {
+				continue
+			}
+			msg := "conflicts with option " + strconv.Quote(other)
+			if key == "" {
+				msg = "name " + msg
+			} else if other == "" {
+				msg = "conflicts with the name"
+			}
+			return &SchemaError{Code: CodeConflictingKeys, Key: key, Other: other, Msg: msg}
+		}
+	}
+	return nil
+}
+
+func containsKey(keys []string, key string) bool {
+	for _, k := range keys {
+		if k == key {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *Schema) validateMissing(name string, has func(key string) bool) error {
+	if s.RequireName && name == "" {
+		return &SchemaError{Code: CodeMissingKey, Msg: "missing name"}
+	}
+	for i := range s.Keys {
+		if spec := &s.Keys[i]; spec.Required && !has(spec.Key) {
+			return &SchemaError{Code: CodeMissingKey, Key: spec.Key, Msg: "missing required option"}
+		}
+	}
+	return nil
+}
+
+func (spec *KeySpec) separator() byte {
+	if spec.Separator == 0 {
+		return ','
+	}
+	return spec.Separator
+}
+
+func (s *Schema) lookup(key string) *KeySpec {
+	for i := range s.Keys {
+		if s.Keys[i].Key == key {
+			return &s.Keys[i]
+		}
+	}
+	return nil
+}
+
+// ParseWithSchema is like Parse, but also validates the options against the
+// schema. Unknown keys and invalid values are reported at the position of
+// their key, conflicts and keys out of order (see Schema.Ordered) at the
+// position of the latter of the two items, and missing keys and names at the
+// end of the tag. The wildcard item (see WildcardKey) is not validated. Under
+// FoldKeys, the keys of the schema match regardless of case, and they are
+// passed through KeyNormalizer, if any.
+//
+// Values are strings, except for ValueList keys, which are split into
+// []string. With a '|' Separator for groups,
+//
+//	a,groups:'admin|\'a|b\''
+//
+// yields {"groups": []string{"admin", "a|b"}}. Lists that fail to split are
+// left as strings.
+func (conf Configuration) ParseWithSchema(tag string, schema *Schema) (name string, opts map[string]any, err error) {
+	schema = conf.foldSchema(schema)
+	r, err := conf.parseResultWithSchema(tag, schema)
+	return r.Name, schema.values(r.Options), err
+}
+
+// foldSchema returns the schema with its keys folded like the keys of tags
+// (see foldKey), so that they can be compared exactly.
+func (conf *Configuration) foldSchema(s *Schema) *Schema {
+	if !conf.normalizesKeys() {
+		return s
+	}
+	folded := *s
+	folded.Keys = make([]KeySpec, len(s.Keys))
+	for i, spec := range s.Keys {
+		spec.Key = conf.foldKey(spec.Key)
+		folded.Keys[i] = spec
+	}
+	folded.Exclusive = make([][]string, len(s.Exclusive))
+	for i, group := range s.Exclusive {
+		folded.Exclusive[i] = make([]string, len(group))
+		for j, k := range group {
+			folded.Exclusive[i][j] = conf.foldKey(k)
+		}
+	}
+	return &folded
+}
+
+// values converts parsed options into ParseWithSchema results.
+func (s *Schema) values(opts map[string]string) map[string]any {
+	if opts == nil {
+		return nil
+	}
+	values := make(map[string]any, len(opts))
+	for k, v := range opts {
+		values[k] = v
+		if spec := s.lookup(k); spec != nil && spec.Value == ValueList {
+			if items, err := SplitValue(v, spec.separator()); err == nil {
+				values[k] = items
+			}
+		}
+	}
+	return values
+}
+
+// parseResultWithSchema parses the tag and validates it against a schema
+// folded with foldSchema.
+func (conf Configuration) parseResultWithSchema(tag string, schema *Schema) (Result, error) {
+	r, err := conf.parseResult(tag, nil, schema)
+	if err == nil || conf.CollectAllErrors {
+		if se := schema.validateMissing(r.Name, r.has); se != nil {
+			err = addError(err, &Error{Tag: tag, Pos: len(tag), Cause: se, Code: CodeMissingKey}, conf.CollectAllErrors)
+		}
+	}
+	return r, err
+}
+
+// has reports whether the result has the key, or a non-empty name if key is
+// empty.
+func (r Result) has(key string) bool {
+	if key == "" {
+		return r.Name != ""
+	}
+	_, ok := r.Options[key]
+	return ok
+}
+
+// maxInferredEnum is the largest number of distinct values InferSchema turns
+// into an enumeration.
+const maxInferredEnum = 8
+
+// InferSchema proposes a schema for a corpus of parsed tags, as a starting
+// point for formalizing an existing informal vocabulary. Keys are listed by
+// Count, most frequent first, then by key. A key is Required if every tag
+// has it, and the name is required if every tag has one; skipped tags (see
+// SkipMarker) are not considered.
+//
+// The value kind is the most specific one that accepts every value seen:
+// ValueNone for flags, ValueInt, ValueBool, then ValueEnum with the values
+// in sorted order if there are at most 8 distinct values and some repeat,
+// ValueString if no value is empty, and ValueAny otherwise.
+func InferSchema(parsedTags []Result) Schema {
+	type stats struct {
+		spec   KeySpec
+		values map[string]int
+	}
+	var schema Schema
+	keys := make(map[string]*stats)
+	tags := 0
+	named := 0
+	for _, r := range parsedTags {
+		if r.Skip {
+			continue
+		}
+		tags++
+		if r.Name != "" {
+			named++
+		}
+		for k, v := range r.Options {
+			st := keys[k]
+			if st == nil {
+				st = &stats{spec: KeySpec{Key: k}, values: make(map[string]int)}
+				keys[k] = st
+			}
+			st.spec.Count++
+			st.values[v]++
+		}
+	}
+	schema.RequireName = tags > 0 && named == tags
+	for _, st := range keys {
+		spec := st.spec
+		spec.Required = spec.Count == tags
+		spec.Value, spec.Enum = inferValueKind(st.values, spec.Count)
+		schema.Keys = append(schema.Keys, spec)
+	}
+	sort.Slice(schema.Keys, func(i, j int) bool {
+		a, b := &schema.Keys[i], &schema.Keys[j]
+		return a.Count > b.Count || a.Count == b.Count && a.Key < b.Key
+	})
+	return schema
+}
+
+// inferValueKind returns the value kind for InferSchema given the number of
+// times each value has been seen, out of count.
+func inferValueKind(values map[string]int, count int) (ValueKind, []string) {
+	none, ints, bools := true, true, true
+	for v := range values {
+		none = none && v == ""
+		if _, err := strconv.Atoi(v); err != nil {
+			ints = false
+		}
+		if _, err := strconv.ParseBool(v); err != nil && v != "" {
+			bools = false
+		}
+	}
+	switch {
+	case none:
+		return ValueNone, nil
+	case ints:
+		return ValueInt, nil
+	case bools:
+		return ValueBool, nil
+	case len(values) <= maxInferredEnum && len(values) < count:
+		enum := make([]string, 0, len(values))
+		for v := range values {
+			enum = append(enum, v)
+		}
+		sort.Strings(enum)
+		return ValueEnum, enum
+	case values[""] == 0:
+		return ValueString, nil
+	}
+	return ValueAny, nil
+}
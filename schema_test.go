@@ -0,0 +1,250 @@
+package tagparser
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+var testSchema = &Schema{
+	RequireName: true,
+	Keys: []KeySpec{
+		{Key: "omitempty", Value: ValueNone},
+		{Key: "inline", Value: ValueBool},
+		{Key: "size", Value: ValueInt},
+		{Key: "format", Value: ValueEnum, Enum: []string{"json", "text"}},
+		{Key: "table", Value: ValueString, Required: true},
+		{Key: "comment"},
+		{Key: "groups", Value: ValueList, Separator: '|'},
+		{Key: "tags", Value: ValueList},
+		{Key: "required", Value: ValueNone},
+	},
+	Exclusive: [][]string{{"required", "omitempty"}},
+}
+
+func TestSchema_Validate(t *testing.T) {
+	var tests = []struct {
+		name string
+		opts map[string]string
+		code ErrorCode
+		err  string
+	}{
+		{"a", M{"table": "t", "omitempty": "", "inline": "", "size": "10", "format": "json", "comment": "any"}, "", ""},
+		{"a", M{"table": "t", "inline": "false"}, "", ""},
+		{"a", M{"table": "t", "groups": `a|'b|c'`, "tags": ""}, "", ""},
+		{"a", M{"table": "t", "groups": `a|'b`}, CodeInvalidValue, `groups: invalid list value "a|'b": unterminated quote (at 3)`},
+		{"a", M{"table": "t", "omitempy": ""}, CodeUnknownKey, "omitempy: unknown option key"},
+		{"a", M{"table": "t", "omitempty": "x"}, CodeInvalidValue, "omitempty: option does not take a value"},
+		{"a", M{"table": "t", "inline": "x"}, CodeInvalidValue, `inline: invalid boolean value "x"`},
+		{"a", M{"table": "t", "size": ""}, CodeInvalidValue, `size: invalid integer value ""`},
+		{"a", M{"table": "t", "format": "xml"}, CodeInvalidValue, `format: invalid value "xml", wanted one of json, text`},
+		{"a", M{"table": ""}, CodeInvalidValue, "table: option requires a value"},
+		{"a", M{"size": "x", "inline": "x"}, CodeInvalidValue, `inline: invalid boolean value "x"`},
+		{"a", M{"table": "t", "required": "", "omitempty": ""}, CodeConflictingKeys, `omitempty: conflicts with option "required"`},
+		{"a", M{"required": "", "omitempty": ""}, CodeConflictingKeys, `omitempty: conflicts with option "required"`},
+		{"a", nil, CodeMissingKey, "table: missing required option"},
+		{"", M{"table": "t"}, CodeMissingKey, "missing name"},
+	}
+	for _, test := range tests {
+		err := testSchema.Validate(test.name, test.opts)
+		if test.code == "" {
+			if err != nil {
+				t.Errorf("** Validate(%q, %v) = %v, wanted nil", test.name, test.opts, err)
+			}
+			continue
+		}
+		var se *SchemaError
+		if !errors.As(err, &se) || se.Code != test.code || se.Error() != test.err {
+			t.Errorf("** Validate(%q, %v) = %v, wanted %s %q", test.name, test.opts, err, test.code, test.err)
+		}
+	}
+}
+
+func TestSchema_AllowUnknown(t *testing.T) {
+	s := &Schema{AllowUnknown: true}
+	if err := s.Validate("", M{"foo": "bar"}); err != nil {
+		t.Errorf("** Validate = %v, wanted nil", err)
+	}
+}
+
+func TestConfiguration_ParseWithSchema(t *testing.T) {
+	conf := Configuration{NamePosition: NameFirst, WildcardKey: "*"}
+	var tests = []struct {
+		tag  string
+		opts map[string]any
+		code ErrorCode
+		err  string
+	}{
+		{`a,table:t,size:1,*:x`, map[string]any{"table": "t", "size": "1"}, "", ""},
+		{`a,table:t,omitempy`, map[string]any{"table": "t", "omitempy": ""}, CodeUnknownKey, "omitempy: unknown option key (at 11)"},
+		{`a,size:x,table:t`, map[string]any{"table": "t", "size": "x"}, CodeInvalidValue, `size: invalid integer value "x" (at 3)`},
+		{`a,size:1`, map[string]any{"size": "1"}, CodeMissingKey, "table: missing required option (at 9)"},
+		{`a`, nil, CodeMissingKey, "table: missing required option (at 2)"},
+		{`a,table:t,table:u`, map[string]any{"table": "t"}, CodeDuplicateKey, "table: duplicate option key (at 11)"},
+		{`a,table:t,groups:'admin|\'a|b\'',tags:'x, y'`, map[string]any{"table": "t", "groups": []string{"admin", "a|b"}, "tags": []string{"x", "y"}}, "", ""},
+		{`a,table:t,tags`, map[string]any{"table": "t", "tags": []string(nil)}, "", ""},
+		{`a,table:t,groups:'\'a'`, map[string]any{"table": "t", "groups": "'a"}, CodeInvalidValue, `groups: invalid list value "'a": unterminated quote (at 1) (at 11)`},
+		{`a,table:t,required,omitempty`, map[string]any{"table": "t", "required": "", "omitempty": ""}, CodeConflictingKeys, `omitempty: conflicts with option "required" (at 20)`},
+		{`a,omitempty,table:t,required`, map[string]any{"table": "t", "required": "", "omitempty": ""}, CodeConflictingKeys, `required: conflicts with option "omitempty" (at 21)`},
+	}
+	for _, test := range tests {
+		name, opts, err := conf.ParseWithSchema(test.tag, testSchema)
+		if name != "a" || !reflect.DeepEqual(opts, test.opts) {
+			t.Errorf("** ParseWithSchema(%q) = %q, %v, wanted %q, %v", test.tag, name, opts, "a", test.opts)
+		}
+		if test.code == "" {
+			if err != nil {
+				t.Errorf("** ParseWithSchema(%q) error %v, wanted nil", test.tag, err)
+			}
+			continue
+		}
+		var e *Error
+		if !errors.As(err, &e) || e.Code != test.code || e.Error() != test.err {
+			t.Errorf("** ParseWithSchema(%q) error %v, wanted %s %q", test.tag, err, test.code, test.err)
+		}
+	}
+}
+
+func TestConfiguration_ParseWithSchema_collect(t *testing.T) {
+	conf := Configuration{NamePosition: NameFirst, CollectAllErrors: true}
+	_, _, err := conf.ParseWithSchema(`a,omitempy,size:x`, testSchema)
+	if expErr := `omitempy: unknown option key (at 3); size: invalid integer value "x" (at 12); table: missing required option (at 18)`; err == nil || err.Error() != expErr {
+		t.Errorf("** ParseWithSchema error %v, wanted %s", err, expErr)
+	}
+}
+
+func TestSchema_Exclusive_name(t *testing.T) {
+	schema := &Schema{AllowUnknown: true, Exclusive: [][]string{{"", "inline"}, {"x", "y"}}}
+	var tests = []struct {
+		conf Configuration
+		tag  string
+		err  string
+	}{
+		{Configuration{NamePosition: NameFirst}, `a,x,inline`, `inline: conflicts with the name (at 5)`},
+		{Configuration{NamePosition: NameFirst}, `,x,inline`, ``},
+		{Configuration{NamePosition: NameLast}, `inline,x,a`, `name conflicts with option "inline" (at 10)`},
+		{Configuration{NamePosition: NameLast}, `x,y,a`, `y: conflicts with option "x" (at 3)`},
+	}
+	for _, test := range tests {
+		_, _, err := test.conf.ParseWithSchema(test.tag, schema)
+		if test.err == "" {
+			if err != nil {
+				t.Errorf("** ParseWithSchema(%q) error %v, wanted nil", test.tag, err)
+			}
+			continue
+		}
+		var e *Error
+		var se *SchemaError
+		if !errors.As(err, &e) || e.Code != CodeConflictingKeys || e.Error() != test.err || !errors.As(err, &se) {
+			t.Errorf("** ParseWithSchema(%q) error %v, wanted %q", test.tag, err, test.err)
+		}
+	}
+	if err := schema.Validate("a", M{"inline": ""}); err == nil || err.Error() != `inline: conflicts with the name` {
+		t.Errorf("** Validate error %v", err)
+	}
+	if err := schema.Validate("a", M{"x": ""}); err != nil {
+		t.Errorf("** Validate error %v, wanted nil", err)
+	}
+}
+
+func TestSchema_Ordered(t *testing.T) {
+	schema := &Schema{Ordered: true, AllowUnknown: true, Keys: []KeySpec{{Key: "table"}, {Key: "size"}, {Key: "omitempty"}}}
+	conf := Configuration{NamePosition: NameFirst, CollectAllErrors: true}
+	var tests = []struct {
+		tag string
+		err string
+	}{
+		{`a,table:t,x,omitempty`, ``},
+		{`a,size:1,omitempty`, ``},
+		{`a,omitempty,size:1,table:t`, `size: option must come before "omitempty" (at 13); table: option must come before "size" (at 20)`},
+	}
+	for _, test := range tests {
+		_, _, err := conf.ParseWithSchema(test.tag, schema)
+		if test.err == "" {
+			if err != nil {
+				t.Errorf("** ParseWithSchema(%q) error %v, wanted nil", test.tag, err)
+			}
+			continue
+		}
+		var se *SchemaError
+		if err == nil || err.Error() != test.err || !errors.As(err, &se) || se.Code != CodeKeyOrder {
+			t.Errorf("** ParseWithSchema(%q) error %v, wanted %q", test.tag, err, test.err)
+		}
+	}
+	if err := schema.Validate("a", M{"omitempty": "", "table": ""}); err != nil {
+		t.Errorf("** Validate error %v, wanted nil", err)
+	}
+}
+
+func TestConfiguration_ParseWithSchema_FoldKeys(t *testing.T) {
+	schema := &Schema{
+		Ordered: true,
+		Keys: []KeySpec{
+			{Key: "primaryKey", Required: true},
+			{Key: "omitEmpty", Value: ValueNone},
+			{Key: "Required", Value: ValueNone},
+			{Key: "Tags", Value: ValueList},
+		},
+		Exclusive: [][]string{{"Required", "omitEmpty"}},
+	}
+	conf := Configuration{NamePosition: NameFirst, FoldKeys: true, CollectAllErrors: true}
+	var tests = []struct {
+		tag  string
+		opts map[string]any
+		err  string
+	}{
+		{`x,PrimaryKey,omitEmpty,tags:'a,b'`, map[string]any{"primarykey": "", "omitempty": "", "tags": []string{"a", "b"}}, ``},
+		{`x,omitempty,primarykey,OMITEMPTY`, map[string]any{"primarykey": "", "omitempty": ""}, `primarykey: option must come before "omitempty" (at 13); omitempty: duplicate option key differing only in case: "omitempty" (at 3) and "OMITEMPTY" (at 24) (at 24)`},
+		{`x,omitEmpty,required,foo`, map[string]any{"omitempty": "", "required": "", "foo": ""}, `required: conflicts with option "omitempty" (at 13); foo: unknown option key (at 22); primarykey: missing required option (at 25)`},
+	}
+	for _, test := range tests {
+		_, opts, err := conf.ParseWithSchema(test.tag, schema)
+		if !reflect.DeepEqual(opts, test.opts) || test.err == "" && err != nil || test.err != "" && (err == nil || err.Error() != test.err) {
+			t.Errorf("** ParseWithSchema(%q) = %v, %v, wanted %v, %s", test.tag, opts, err, test.opts, test.err)
+		}
+	}
+	if schema.Keys[0].Key != "primaryKey" || schema.Exclusive[0][0] != "Required" {
+		t.Errorf("** ParseWithSchema modified the schema: %+v", schema)
+	}
+}
+
+func TestInferSchema(t *testing.T) {
+	conf := Configuration{NamePosition: NameFirst, SkipMarker: "-"}
+	var results []Result
+	for _, tag := range []string{
+		`id,size:10,format:json,omitempty,inline:true,comment:x`,
+		`name,size:20,format:text,inline,comment:y`,
+		`email,size:255,format:json,comment:z,note:`,
+		`-`,
+		`phone,size:1,format:text,omitempty,comment:w,note:a`,
+	} {
+		r, err := conf.ParseResult(tag)
+		if err != nil {
+			t.Fatalf("** ParseResult(%q) error: %v", tag, err)
+		}
+		results = append(results, r)
+	}
+	actual := InferSchema(results)
+	expected := Schema{
+		RequireName: true,
+		Keys: []KeySpec{
+			{Key: "comment", Value: ValueString, Required: true, Count: 4},
+			{Key: "format", Value: ValueEnum, Enum: []string{"json", "text"}, Required: true, Count: 4},
+			{Key: "size", Value: ValueInt, Required: true, Count: 4},
+			{Key: "inline", Value: ValueBool, Count: 2},
+			{Key: "note", Value: ValueAny, Count: 2},
+			{Key: "omitempty", Value: ValueNone, Count: 2},
+		},
+	}
+	if !reflect.DeepEqual(actual, expected) {
+		t.Errorf("** InferSchema = %+v, wanted %+v", actual, expected)
+	}
+	for _, r := range results {
+		if err := actual.Validate(r.Name, r.Options); err != nil && !r.Skip {
+			t.Errorf("** Validate(%q) error: %v", r.Tag, err)
+		}
+	}
+	if actual := InferSchema(nil); !reflect.DeepEqual(actual, Schema{}) {
+		t.Errorf("** InferSchema(nil) = %+v", actual)
+	}
+}
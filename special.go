@@ -0,0 +1,117 @@
+package tagparser
+
+import (
+	"unicode"
+	"unicode/utf8"
+)
+
+// CharClass is a set of roles a byte plays in the tag syntax of a dialect,
+// as reported by Configuration.Classify.
+type CharClass uint8
+
+const (
+	// CharSpace is ASCII whitespace, trimmed at the ends of keys and values
+	// unless escaped or quoted, but ordinary elsewhere.
+	CharSpace CharClass = 1 << iota
+	// CharQuote is a quote delimiting quoted text, see
+	// Configuration.QuoteChars.
+	CharQuote
+	// CharEscape is the backslash starting an escape sequence.
+	CharEscape
+	// CharItemSeparator separates items, see Configuration.ItemSeparator.
+	CharItemSeparator
+	// CharKeyValueSeparator separates keys from values, see
+	// Configuration.KeyValueSeparator.
+	CharKeyValueSeparator
+	// CharDirectivePrefix starts a directive when it is the first character
+	// of a key, see Configuration.DirectivePrefix.
+	CharDirectivePrefix
+	// CharNegationPrefix negates a flag when it is the first character of a
+	// key, see Configuration.NegationPrefix.
+	CharNegationPrefix
+	// CharListBracket is the '[' starting a list value when it is the first
+	// character of a value, see Configuration.ListValues. The closing ']' is
+	// ordinary outside of list values, so it is not classified.
+	CharListBracket
+)
+
+// SpaceChars lists the bytes of CharSpace class.
+const SpaceChars = " \t\n\v\f\r"
+
+// Classify returns the roles of the byte in the dialect, or zero for bytes
+// that are ordinary everywhere. Multibyte invisible characters, which are
+// reported in keys, are not classified; see RemoveInvisible.
+func (conf Configuration) Classify(c byte) CharClass {
+	var class CharClass
+	if asciiSpace[c] != 0 {
+		class |= CharSpace
+	}
+	if conf.quotes().has(c) {
+		class |= CharQuote
+	}
+	kvSep, itemSep := conf.separators()
+	switch c {
+	case '\\':
+		class |= CharEscape
+	case itemSep:
+		class |= CharItemSeparator
+	case kvSep:
+		class |= CharKeyValueSeparator
+	}
+	if conf.DirectivePrefix != 0 && c == conf.DirectivePrefix {
+		class |= CharDirectivePrefix
+	}
+	if conf.NegationPrefix != 0 && c == conf.NegationPrefix {
+		class |= CharNegationPrefix
+	}
+	if conf.ListValues && c == '[' {
+		class |= CharListBracket
+	}
+	return class
+}
+
+// IsSpecial reports whether the byte has to be escaped or quoted to appear
+// literally in a key or value in the dialect of conf. Whitespace, the
+// directive and negation prefixes and the list bracket are special, even
+// though they only need escaping at the start (and, for whitespace, the end)
+// of a key or value.
+func IsSpecial(c byte, conf Configuration) bool {
+	return conf.Classify(c) != 0
+}
+
+// trimSpace returns the span of s without leading and trailing whitespace,
+// ASCII or Unicode depending on UnicodeWhitespace.
+func (conf *Configuration) trimSpace(s string) (start, end int) {
+	if conf.UnicodeWhitespace {
+		return trimUnicodeSpace(s)
+	}
+	return trimSpace(s)
+}
+
+// trimUnicodeSpace is like trimSpace, but trims Unicode whitespace, stopping
+// at escaped trailing whitespace.
+func trimUnicodeSpace(s string) (start, end int) {
+	end = len(s)
+	for start < end {
+		r, n := utf8.DecodeRuneInString(s[start:end])
+		if !unicode.IsSpace(r) {
+			break
+		}
+		start += n
+	}
+	for end > start {
+		r, n := utf8.DecodeLastRuneInString(s[start:end])
+		if !unicode.IsSpace(r) {
+			break
+		}
+		var backslashes int
+		for end-n-backslashes > start && s[end-n-backslashes-1] == '\\' {
+			backslashes++
+		}
+		if backslashes%2 == 1 {
+			break
+		}
+		end -= n
+	}
+	return
+}
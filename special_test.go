@@ -0,0 +1,92 @@
+package tagparser
+
+import "testing"
+
+func TestConfiguration_Classify(t *testing.T) {
+	gorm := Configuration{KeyValueSeparator: '=', ItemSeparator: ';', DirectivePrefix: '#'}
+	var tests = []struct {
+		conf     Configuration
+		c        byte
+		expected CharClass
+	}{
+		{Configuration{}, 'a', 0},
+		{Configuration{}, ' ', CharSpace},
+		{Configuration{}, '\t', CharSpace},
+		{Configuration{}, '\'', CharQuote},
+		{Configuration{}, '\\', CharEscape},
+		{Configuration{}, ',', CharItemSeparator},
+		{Configuration{}, ':', CharKeyValueSeparator},
+		{Configuration{}, '#', 0},
+		{Configuration{}, '=', 0},
+		{Configuration{}, '"', 0},
+		{Configuration{QuoteChars: `"`}, '"', CharQuote},
+		{Configuration{QuoteChars: `"`}, '\'', 0},
+		{gorm, ';', CharItemSeparator},
+		{gorm, '=', CharKeyValueSeparator},
+		{gorm, ',', 0},
+		{gorm, ':', 0},
+		{gorm, '#', CharDirectivePrefix},
+		{Configuration{NegationPrefix: '!'}, '!', CharNegationPrefix},
+		{Configuration{NegationPrefix: '-'}, '!', 0},
+		{Configuration{ListValues: true}, '[', CharListBracket},
+		{Configuration{ListValues: true}, ']', 0},
+		{Configuration{}, '[', 0},
+	}
+	for _, test := range tests {
+		if actual := test.conf.Classify(test.c); actual != test.expected {
+			t.Errorf("** Classify(%q) = %v, wanted %v", test.c, actual, test.expected)
+		}
+		if actual := IsSpecial(test.c, test.conf); actual != (test.expected != 0) {
+			t.Errorf("** IsSpecial(%q) = %v, wanted %v", test.c, actual, !actual)
+		}
+	}
+}
+
+func TestSpaceChars(t *testing.T) {
+	var conf Configuration
+	var n int
+	for c := 0; c < 256; c++ {
+		if conf.Classify(byte(c))&CharSpace != 0 {
+			n++
+		}
+	}
+	for i := 0; i < len(SpaceChars); i++ {
+		if conf.Classify(SpaceChars[i]) != CharSpace {
+			t.Errorf("** Classify(%q) is not CharSpace", SpaceChars[i])
+		}
+	}
+	if n != len(SpaceChars) {
+		t.Errorf("** %d bytes are CharSpace, wanted %d", n, len(SpaceChars))
+	}
+}
+
+func TestIsSpecial_Build(t *testing.T) {
+	// A byte is special if and only if Build escapes or quotes it somewhere.
+	confs := []Configuration{
+		{},
+		{KeyValueSeparator: '=', ItemSeparator: ';', DirectivePrefix: '#'},
+		{NegationPrefix: '!'},
+		{ListValues: true},
+		{NegationPrefix: '-', ListValues: true, DirectivePrefix: '#', QuoteChars: `"`},
+	}
+	for _, conf := range confs {
+		kvSep, _ := conf.separators()
+		for c := 0; c < 0x80; c++ {
+			ch := string(rune(c))
+			changed := false
+			for _, key := range []string{ch + "x", "x" + ch + "x", "x" + ch} {
+				if built, err := conf.BuildOrdered("", []Option{{key, ""}}); err != nil || built != key {
+					changed = true
+				}
+			}
+			for _, value := range []string{ch + "x", "x" + ch + "x", "x" + ch} {
+				if built, err := conf.BuildOrdered("", []Option{{"k", value}}); err != nil || built != "k"+string(kvSep)+value {
+					changed = true
+				}
+			}
+			if actual := IsSpecial(byte(c), conf); actual != changed {
+				t.Errorf("** IsSpecial(%q, %+v) = %v, but Build changes it: %v", c, conf, actual, changed)
+			}
+		}
+	}
+}
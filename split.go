@@ -0,0 +1,51 @@
+package tagparser
+
+// SplitValue splits an option value into a list at the given separator,
+// honoring the same quoting, escaping and trimming rules as the tag itself:
+//
+//	SplitValue(`admin | 'a|b' | c\|d`, '|') // {"admin", "a|b", "c|d"}
+//
+// Since escapes and quotes of the tag are processed before the value is
+// returned, quotes and backslashes meant for SplitValue have to be escaped in
+// the tag:
+//
+//	groups:'admin|\'a|b\''
+//
+// An empty value yields no items. Empty items are kept. The error, if
+// present, is *Error positioned within the value.
+func SplitValue(value string, sep byte) ([]string, error) {
+	return splitValue(value, sep, &nameNone)
+}
+
+func splitValue(value string, sep byte, conf *Configuration) ([]string, error) {
+	if value == "" {
+		return nil, nil
+	}
+	s := scanner{tag: value, conf: *conf}
+	n := len(value)
+	var items []string
+	var start int
+	var quoteStart int = -1
+	q := conf.quotes()
+	for i := 0; i < n; i++ {
+		switch c := value[i]; {
+		case c == '\\':
+			i++
+			s.checkEscape(i, quoteStart >= 0)
+		case quoteStart >= 0:
+			if c == value[quoteStart] {
+				quoteStart = -1
+			}
+		case q.has(c):
+			quoteStart = i
+		case c == sep:
+			items = append(items, s.unquote(start, i))
+			start = i + 1
+		}
+	}
+	if quoteStart >= 0 {
+		s.fail(quoteStart, CodeUnterminatedQuote)
+	}
+	items = append(items, s.unquote(start, n))
+	return items, s.err
+}
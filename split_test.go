@@ -0,0 +1,70 @@
+package tagparser
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSplitValue(t *testing.T) {
+	var tests = []struct {
+		value    string
+		sep      byte
+		expected []string
+		error    string
+	}{
+		{``, '|', nil, ``},
+		{`admin`, '|', []string{"admin"}, ``},
+		{`admin|editor`, '|', []string{"admin", "editor"}, ``},
+		{` admin | editor `, '|', []string{"admin", "editor"}, ``},
+		{`a||b|`, '|', []string{"a", "", "b", ""}, ``},
+		{`admin | 'a|b' | c\|d`, '|', []string{"admin", "a|b", "c|d"}, ``},
+		{`a;b`, ';', []string{"a", "b"}, ``},
+		{`a|'b`, '|', []string{"a", "b"}, `unterminated quote (at 3)`},
+		{`a|\b`, '|', []string{"a", "b"}, `invalid escape character (at 4)`},
+		{`a|b\`, '|', []string{"a", "b"}, `unterminated escape sequence (at 4)`},
+		{`a|b'c'`, '|', []string{"a", "bc"}, `invalid quote (at 4)`},
+	}
+	for _, test := range tests {
+		actual, err := SplitValue(test.value, test.sep)
+		if err != nil {
+			if ae := err.Error(); ae != test.error {
+				t.Errorf("** SplitValue(%q) error %q, wanted %q", test.value, ae, test.error)
+			}
+		} else if test.error != "" {
+			t.Errorf("** SplitValue(%q) no error, wanted %q", test.value, test.error)
+		}
+		if !reflect.DeepEqual(actual, test.expected) {
+			t.Errorf("** SplitValue(%q) = %q, wanted %q", test.value, actual, test.expected)
+		}
+	}
+}
+
+func TestResult_SplitValue(t *testing.T) {
+	conf := Configuration{EscapableChars: `,:|`}
+	r, err := conf.ParseResult(`roles:'a|b\\\ c'`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v := r.Options["roles"]; v != `a|b\ c` {
+		t.Fatalf("** roles = %q", v)
+	}
+	items, err := r.SplitValue("roles", '|')
+	const expErr = `invalid escape character (at 5)`
+	if err == nil || err.Error() != expErr {
+		t.Errorf("** SplitValue error %v, wanted %v", err, expErr)
+	}
+	if expected := []string{"a", "b c"}; !reflect.DeepEqual(items, expected) {
+		t.Errorf("** SplitValue = %q, wanted %q", items, expected)
+	}
+	if _, err := SplitValue(r.Options["roles"], '|'); err != nil {
+		t.Errorf("** package-level SplitValue error %v, wanted none", err)
+	}
+}
+
+func TestResult_SplitValue_quotes(t *testing.T) {
+	r := Result{Options: M{"v": "\"a|b\" | `c\"d` | it's"}, Conf: Configuration{QuoteChars: "\"`"}}
+	items, err := r.SplitValue("v", '|')
+	if expected := []string{"a|b", `c"d`, "it's"}; err != nil || !reflect.DeepEqual(items, expected) {
+		t.Errorf("** SplitValue = %q, %v, wanted %q", items, err, expected)
+	}
+}
@@ -0,0 +1,33 @@
+package tagparser
+
+import "time"
+
+// Stats describes the work done to parse one or more tags, as reported by
+// ParseInstrumented, which is only available when building with the
+// tagparser_stats build tag. Use Add to aggregate stats over a corpus.
+type Stats struct {
+	// Tags is the number of tags parsed.
+	Tags int
+	// BytesScanned is the number of tag bytes examined by the scanner,
+	// including repeated passes (e.g. for GreedyLastValue).
+	BytesScanned int
+	// Items is the number of items found, including the name and directives.
+	Items int
+	// Escapes is the number of backslash escape sequences processed.
+	Escapes int
+	// Allocs is the number of heap allocations made while parsing. It is
+	// measured process-wide, so concurrent goroutines inflate it.
+	Allocs uint64
+	// Duration is the wall-clock time spent parsing.
+	Duration time.Duration
+}
+
+// Add adds other to s.
+func (s *Stats) Add(other Stats) {
+	s.Tags += other.Tags
+	s.BytesScanned += other.BytesScanned
+	s.Items += other.Items
+	s.Escapes += other.Escapes
+	s.Allocs += other.Allocs
+	s.Duration += other.Duration
+}
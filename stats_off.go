@@ -0,0 +1,11 @@
+//go:build !tagparser_stats
+
+package tagparser
+
+// Without the tagparser_stats build tag, counting compiles to nothing.
+
+func (s *scanner) countBytes(n int) {}
+
+func (s *scanner) countItem() {}
+
+func (s *scanner) countEscape() {}
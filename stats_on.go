@@ -0,0 +1,43 @@
+//go:build tagparser_stats
+
+package tagparser
+
+import (
+	"runtime"
+	"time"
+)
+
+// ParseInstrumented is like ParseResult, but also returns stats about the
+// parsing. Measuring allocations stops the world, so this is only suitable
+// for analyzing tag corpora and benchmarking the parser.
+//
+// Only available when building with the tagparser_stats build tag.
+func (conf Configuration) ParseInstrumented(tag string) (Result, Stats, error) {
+	stats := Stats{Tags: 1}
+	var before, after runtime.MemStats
+	runtime.ReadMemStats(&before)
+	start := time.Now()
+	r, err := conf.parseResult(tag, &stats, nil)
+	stats.Duration = time.Since(start)
+	runtime.ReadMemStats(&after)
+	stats.Allocs = after.Mallocs - before.Mallocs
+	return r, stats, err
+}
+
+func (s *scanner) countBytes(n int) {
+	if s.stats != nil {
+		s.stats.BytesScanned += n
+	}
+}
+
+func (s *scanner) countItem() {
+	if s.stats != nil {
+		s.stats.Items++
+	}
+}
+
+func (s *scanner) countEscape() {
+	if s.stats != nil {
+		s.stats.Escapes++
+	}
+}
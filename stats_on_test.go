@@ -0,0 +1,34 @@
+//go:build tagparser_stats
+
+package tagparser
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestConfiguration_ParseInstrumented(t *testing.T) {
+	var tests = []struct {
+		conf         Configuration
+		tag          string
+		bytesScanned int
+		items        int
+		escapes      int
+	}{
+		{Configuration{}, ``, 0, 0, 0},
+		{Configuration{NamePosition: NameFirst}, `alfa,bravo:c\,d,'e\'f'`, 22, 3, 2},
+		{Configuration{GreedyLastValue: true}, `alfa:b,c`, 16, 1, 0},
+	}
+	for _, test := range tests {
+		r, stats, err := test.conf.ParseInstrumented(test.tag)
+		if err != nil {
+			t.Errorf("** ParseInstrumented(%q) error %v", test.tag, err)
+		}
+		if expected, _ := test.conf.ParseResult(test.tag); !reflect.DeepEqual(r, expected) {
+			t.Errorf("** ParseInstrumented(%q) = %+v, wanted %+v", test.tag, r, expected)
+		}
+		if stats.Tags != 1 || stats.BytesScanned != test.bytesScanned || stats.Items != test.items || stats.Escapes != test.escapes {
+			t.Errorf("** ParseInstrumented(%q) stats = %+v, wanted %d bytes, %d items, %d escapes", test.tag, stats, test.bytesScanned, test.items, test.escapes)
+		}
+	}
+}
@@ -0,0 +1,15 @@
+package tagparser
+
+import (
+	"testing"
+	"time"
+)
+
+func TestStats_Add(t *testing.T) {
+	s := Stats{Tags: 1, BytesScanned: 10, Items: 2, Escapes: 1, Allocs: 3, Duration: time.Millisecond}
+	s.Add(Stats{Tags: 2, BytesScanned: 5, Items: 3, Escapes: 0, Allocs: 1, Duration: time.Second})
+	expected := Stats{Tags: 3, BytesScanned: 15, Items: 5, Escapes: 1, Allocs: 4, Duration: time.Second + time.Millisecond}
+	if s != expected {
+		t.Errorf("** Add = %+v, wanted %+v", s, expected)
+	}
+}
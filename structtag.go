@@ -0,0 +1,181 @@
+package tagparser
+
+import (
+	"errors"
+	"strconv"
+	"unicode/utf8"
+)
+
+// StructTagParser parses whole struct tags like
+// `json:"email,omitempty" db:"email"`, using a separate Configuration for
+// each registered namespace. The zero value is ready to use, but does not
+// know any namespaces.
+type StructTagParser struct {
+	confs   map[string]*Configuration
+	schemas map[string]*Schema
+}
+
+// Register sets the configuration used for the given namespace. Namespaces
+// that have not been registered are skipped, which is handy for tags with
+// a foreign syntax, like `validate:"min=2"`.
+func (p *StructTagParser) Register(namespace string, conf Configuration) {
+	if p.confs == nil {
+		p.confs = make(map[string]*Configuration)
+	}
+	p.confs[namespace] = &conf
+}
+
+// RegisterSchema is like Register, but also validates the namespace against
+// the schema like Configuration.ParseWithSchema does.
+func (p *StructTagParser) RegisterSchema(namespace string, conf Configuration, schema *Schema) {
+	p.Register(namespace, conf)
+	if p.schemas == nil {
+		p.schemas = make(map[string]*Schema)
+	}
+	p.schemas[namespace] = conf.foldSchema(schema)
+}
+
+// Parse parses the registered namespaces of a struct tag, following the
+// conventions of reflect.StructTag: space-separated `key:"value"` pairs with
+// Go-quoted values, where the first occurrence of a key wins.
+//
+// Errors are *Error with Info.Namespace set, and Tag and Pos referring to the
+// whole struct tag. Like other parse funcs, Parse does not stop at errors and
+// returns the first one along with the best guess results; the errors of
+// namespaces with CollectAllErrors are combined into one *ErrorList instead,
+// which starts with the first error of any earlier namespace.
+// Text that does not follow the conventions is reported as
+// CodeMalformedStructTag, and the rest of the struct tag is ignored, like
+// reflect.StructTag does.
+func (p *StructTagParser) Parse(structTag string) (map[string]Result, error) {
+	return p.parse(structTag, nil)
+}
+
+// parse is Parse that also records the spans of the quoted values of the
+// registered namespaces in spans, if not nil.
+func (p *StructTagParser) parse(structTag string, spans map[string][2]int) (map[string]Result, error) {
+	var results map[string]Result
+	var firstErr error
+	tag := structTag
+	i := 0
+	for {
+		for i < len(tag) && tag[i] == ' ' {
+			i++
+		}
+		if i >= len(tag) {
+			break
+		}
+
+		start := i
+		for i < len(tag) && tag[i] > ' ' && tag[i] != ':' && tag[i] != '"' && tag[i] != 0x7f {
+			i++
+		}
+		if i == start || i+1 >= len(tag) || tag[i] != ':' || tag[i+1] != '"' {
+			firstErr = malformedStructTag(firstErr, structTag, i)
+			break
+		}
+		namespace := tag[start:i]
+
+		i++
+		qstart := i
+		i++
+		for i < len(tag) && tag[i] != '"' {
+			if tag[i] == '\\' {
+				i++
+			}
+			i++
+		}
+		if i >= len(tag) {
+			firstErr = malformedStructTag(firstErr, structTag, qstart)
+			break
+		}
+		i++
+		quoted := tag[qstart:i]
+
+		conf := p.confs[namespace]
+		if conf == nil {
+			continue
+		}
+		if _, seen := results[namespace]; seen {
+			continue
+		}
+		value, err := strconv.Unquote(quoted)
+		if err != nil {
+			firstErr = malformedStructTag(firstErr, structTag, qstart)
+			break
+		}
+		if spans != nil {
+			spans[namespace] = [2]int{qstart, i}
+		}
+		var r Result
+		if schema := p.schemas[namespace]; schema != nil {
+			r, err = conf.parseResultWithSchema(value, schema)
+		} else {
+			r, err = conf.ParseResult(value)
+		}
+		if err != nil && (firstErr == nil || conf.CollectAllErrors) {
+			var list *ErrorList
+			var e *Error
+			if errors.As(err, &list) {
+				for _, e := range list.Errors {
+					relocate(e, structTag, namespace, quoted, qstart)
+				}
+			} else if errors.As(err, &e) {
+				relocate(e, structTag, namespace, quoted, qstart)
+			}
+			switch prev := firstErr.(type) {
+			case nil:
+				firstErr = err
+			case *ErrorList:
+				if list != nil {
+					prev.Errors = append(prev.Errors, list.Errors...)
+				}
+			case *Error:
+				if list != nil {
+					firstErr = &ErrorList{Errors: append([]*Error{prev}, list.Errors...)}
+				}
+			}
+		}
+		for _, w := range r.Warnings {
+			relocate(w, structTag, namespace, quoted, qstart)
+		}
+		if results == nil {
+			results = make(map[string]Result)
+		}
+		results[namespace] = r
+	}
+	return results, firstErr
+}
+
+// relocate makes an error of a namespace refer to the whole struct tag.
+func relocate(e *Error, structTag, namespace, quoted string, qstart int) {
+	e.Tag = structTag
+	e.Info.Namespace = namespace
+	e.Pos = qstart + literalOffset(quoted, e.Pos)
+}
+
+func malformedStructTag(firstErr error, structTag string, pos int) error {
+	if firstErr != nil {
+		return firstErr
+	}
+	return &Error{Tag: structTag, Pos: pos, Msg: CodeMalformedStructTag.Message(), Code: CodeMalformedStructTag}
+}
+
+// literalOffset maps a byte position within the unquoted value of a valid Go
+// string literal to the corresponding position within the literal.
+func literalOffset(quoted string, pos int) int {
+	rest := quoted[1 : len(quoted)-1]
+	for n := 0; len(rest) > 0; {
+		r, multibyte, tail, _ := strconv.UnquoteChar(rest, '"')
+		if multibyte {
+			n += utf8.RuneLen(r)
+		} else {
+			n++
+		}
+		if n > pos {
+			break
+		}
+		rest = tail
+	}
+	return len(quoted) - 1 - len(rest)
+}
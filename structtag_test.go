@@ -0,0 +1,97 @@
+package tagparser
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestStructTagParser(t *testing.T) {
+	var p StructTagParser
+	jsonConf := Configuration{NamePosition: NameFirst}
+	dbConf := Configuration{NamePosition: NameFirst, WildcardKey: "*"}
+	p.Register("json", jsonConf)
+	p.Register("db", dbConf)
+
+	var tests = []struct {
+		tag      string
+		expected map[string]Result
+		error    string
+	}{
+		{``, nil, ``},
+		{`validate:"min=2"`, nil, ``},
+		{`json:"email,omitempty" db:"email_addr,*" validate:"min=2"`, map[string]Result{
+			"json": {Name: "email", Options: M{"omitempty": ""}, Tag: "email,omitempty", Conf: jsonConf},
+			"db":   {Name: "email_addr", HasWildcard: true, Tag: "email_addr,*", Conf: dbConf},
+		}, ``},
+		{`json:"a" json:"b"`, map[string]Result{"json": {Name: "a", Tag: "a", Conf: jsonConf}}, ``},
+		{`json:"a,desc:'say \"hi\"'"`, map[string]Result{"json": {Name: "a", Options: M{"desc": `say "hi"`}, Tag: `a,desc:'say "hi"'`, Conf: jsonConf}}, ``},
+		{`db:"x" json:"a,:b"`, map[string]Result{"db": {Name: "x", Tag: "x", Conf: dbConf}, "json": {Name: "a", Tag: "a,:b", Conf: jsonConf}}, `json: empty key (at 16)`},
+		{`json:"a,\"q\",b:'c"`, map[string]Result{"json": {Name: "a", Options: M{`"q"`: "", "b": "c"}, Tag: `a,"q",b:'c`, Conf: jsonConf}}, `json: unterminated quote (at 17)`},
+		{`json:"é,:b"`, map[string]Result{"json": {Name: "é", Tag: "é,:b", Conf: jsonConf}}, `json: empty key (at 10)`},
+		{`json:"\u00e9,:b"`, map[string]Result{"json": {Name: "é", Tag: "é,:b", Conf: jsonConf}}, `json: empty key (at 14)`},
+		{`json:"a,b\\"`, map[string]Result{"json": {Name: "a", Options: M{"b": ""}, Tag: `a,b\`, Conf: jsonConf}}, `json: unterminated escape sequence (at 10)`},
+		{`json:"a" db`, map[string]Result{"json": {Name: "a", Tag: "a", Conf: jsonConf}}, `malformed struct tag (at 12)`},
+		{`json:"a`, nil, `malformed struct tag (at 6)`},
+		{`json:"\q"`, nil, `malformed struct tag (at 6)`},
+		{`json:"a,:b" db`, map[string]Result{"json": {Name: "a", Tag: "a,:b", Conf: jsonConf}}, `json: empty key (at 9)`},
+	}
+	for _, test := range tests {
+		actual, err := p.Parse(test.tag)
+		if err != nil {
+			if ae := err.Error(); ae != test.error {
+				t.Errorf("** Parse(%q) error %q, wanted %q", test.tag, ae, test.error)
+			}
+		} else if test.error != "" {
+			t.Errorf("** Parse(%q) no error, wanted %q", test.tag, test.error)
+		}
+		if !reflect.DeepEqual(actual, test.expected) {
+			t.Errorf("** Parse(%q) = %+v, wanted %+v", test.tag, actual, test.expected)
+		}
+	}
+}
+
+func TestStructTagParser_CollectAllErrors(t *testing.T) {
+	var p StructTagParser
+	p.Register("json", Configuration{NamePosition: NameFirst, CollectAllErrors: true})
+	_, err := p.Parse(`json:"a,:b,c,c"`)
+	if expErr := `json: empty key (at 9); json: c: duplicate option key (at 14)`; err == nil || err.Error() != expErr {
+		t.Errorf("** Parse error %v, wanted %s", err, expErr)
+	}
+
+	p.Register("db", Configuration{CollectAllErrors: true})
+	p.Register("xml", Configuration{})
+	_, err = p.Parse(`json:"a,:b" xml:":x" db:":y"`)
+	if expErr := `json: empty key (at 9); db: empty key (at 26)`; err == nil || err.Error() != expErr {
+		t.Errorf("** Parse error %v, wanted %s", err, expErr)
+	}
+
+	_, err = p.Parse(`xml:":x" json:"a,:b,'c"`)
+	if expErr := `xml: empty key (at 6); json: empty key (at 18); json: unterminated quote (at 21)`; err == nil || err.Error() != expErr {
+		t.Errorf("** Parse error %v, wanted %s", err, expErr)
+	}
+}
+
+func TestStructTagParser_RegisterSchema(t *testing.T) {
+	var p StructTagParser
+	p.RegisterSchema("json", Configuration{NamePosition: NameFirst}, &Schema{Keys: []KeySpec{{Key: "omitempty", Value: ValueNone}}})
+	r, err := p.Parse(`json:"a,omitempty,string"`)
+	if expErr := `json: string: unknown option key (at 19)`; err == nil || err.Error() != expErr {
+		t.Errorf("** Parse error %v, wanted %s", err, expErr)
+	}
+	if r["json"].Name != "a" {
+		t.Errorf("** Parse = %+v, wanted name a", r)
+	}
+}
+
+func TestStructTagParser_warnings(t *testing.T) {
+	var p StructTagParser
+	p.Register("db", Configuration{NamePosition: NameFirst, DeprecatedKeys: M{"pk": "use primary"}})
+	results, err := p.Parse(`json:"id" db:"id,pk"`)
+	warnings := results["db"].Warnings
+	if err != nil || len(warnings) != 1 {
+		t.Fatalf("** Parse = %+v, %v", results, err)
+	}
+	if actual, expected := warnings[0].Format(ErrorVerbose), "db: pk: deprecated option key (at 18)\n    json:\"id\" db:\"id,pk\"\n                     ^\n    hint: use primary"; actual != expected {
+		t.Errorf("** warning = %q, wanted %q", actual, expected)
+	}
+}
@@ -0,0 +1,53 @@
+package tagparser
+
+// suggest returns Error.Suggestion for a syntax error with the given code at
+// position i of the tag.
+func (s *scanner) suggest(i int, code ErrorCode) string {
+	tag := s.tag
+	switch code {
+	case CodeUnterminatedQuote:
+		end := s.closingQuotePos(i)
+		return "add a closing quote: " + tag[:end] + tag[i:i+1] + tag[end:]
+	case CodeInvalidQuote:
+		return "quote the entire key or value instead"
+	case CodeUnterminatedEscape:
+		return `use \\ for a literal backslash`
+	case CodeInvalidEscape:
+		c := tag[i : i+1]
+		if !isAlnum(c[0]) { // not in EscapableChars
+			return `quote the text to escape ` + c + `, or use \\` + c + ` for a literal backslash`
+		}
+		return `use \\` + c + ` for a literal backslash`
+	case CodeMalformedEscape:
+		return `use \xNN with two or \uXXXX with four hex digits`
+	case CodeEmptyKey:
+		return "add a key before the separator, or remove the item"
+	case CodeInvisibleChar:
+		return "remove the invisible characters: " + s.conf.RemoveInvisible(tag)
+	}
+	return ""
+}
+
+// closingQuotePos guesses where the quote opened at i was meant to be closed:
+// before the next item separator, or at the end of the tag, skipping
+// trailing whitespace.
+func (s *scanner) closingQuotePos(i int) int {
+	_, itemSep := s.conf.separators()
+	end := len(s.tag)
+	for j := i + 1; j < len(s.tag); j++ {
+		if c := s.tag[j]; c == '\\' {
+			j++
+		} else if c == itemSep {
+			end = j
+			break
+		}
+	}
+	for end > i+1 && asciiSpace[s.tag[end-1]] != 0 {
+		end--
+	}
+	return end
+}
+
+func isAlnum(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9'
+}
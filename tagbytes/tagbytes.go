@@ -0,0 +1,38 @@
+// Package tagbytes parses tags held in byte slices without copying them,
+// using unsafe conversions, which the core parser avoids.
+package tagbytes
+
+import (
+	"unsafe"
+
+	"github.com/andreyvit/tagparser"
+)
+
+// ParseFunc is like Configuration.ParseFunc for a tag held in a byte
+// slice, e.g. one read out of Go source, without converting it to a string.
+// Keys and values are passed as slices of the tag itself unless unescaping
+// or unquoting had to rewrite them, so nothing is copied for plain tags.
+//
+// The callback must not modify the slices or retain them beyond the call,
+// and the tag must not be modified while ParseFunc runs.
+func ParseFunc(conf tagparser.Configuration, tag []byte, callback func(key, value []byte) error) error {
+	return conf.ParseFunc(bytesToString(tag), func(key, value string) error {
+		return callback(stringToBytes(key), stringToBytes(value))
+	})
+}
+
+// ParseFuncPos is like Configuration.ParseFuncPos for a tag held in a byte
+// slice, with the same rules as ParseFunc.
+func ParseFuncPos(conf tagparser.Configuration, tag []byte, callback func(key, value []byte, keyPos, valuePos, end int) error) error {
+	return conf.ParseFuncPos(bytesToString(tag), func(key, value string, keyPos, valuePos, end int) error {
+		return callback(stringToBytes(key), stringToBytes(value), keyPos, valuePos, end)
+	})
+}
+
+func bytesToString(b []byte) string {
+	return unsafe.String(unsafe.SliceData(b), len(b))
+}
+
+func stringToBytes(s string) []byte {
+	return unsafe.Slice(unsafe.StringData(s), len(s))
+}
@@ -0,0 +1,76 @@
+package tagbytes
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/andreyvit/tagparser"
+)
+
+func TestParseFunc(t *testing.T) {
+	conf := tagparser.Configuration{NamePosition: tagparser.NameFirst}
+	var tests = []struct {
+		tag      string
+		expected string
+		error    string
+	}{
+		{``, ``, ``},
+		{`email,omitempty,size:255`, `=email;omitempty=;size=255;`, ``},
+		{`a,desc:'x, y',b\,c`, `=a;desc=x, y;b,c=;`, ``},
+		{`a,:b,c`, `=a;c=;`, `empty key (at 3)`},
+	}
+	for _, test := range tests {
+		var b strings.Builder
+		err := ParseFunc(conf, []byte(test.tag), func(key, value []byte) error {
+			b.Write(key)
+			b.WriteByte('=')
+			b.Write(value)
+			b.WriteByte(';')
+			return nil
+		})
+		if actual := b.String(); actual != test.expected {
+			t.Errorf("** ParseFunc(%q) = %q, wanted %q", test.tag, actual, test.expected)
+		}
+		if err != nil {
+			if ae := err.Error(); ae != test.error {
+				t.Errorf("** ParseFunc(%q) error %q, wanted %q", test.tag, ae, test.error)
+			}
+		} else if test.error != "" {
+			t.Errorf("** ParseFunc(%q) no error, wanted %q", test.tag, test.error)
+		}
+	}
+}
+
+func TestParseFunc_no_copy(t *testing.T) {
+	tag := []byte(`email,size:255`)
+	allocs := testing.AllocsPerRun(10, func() {
+		ParseFunc(tagparser.Configuration{}, tag, func(key, value []byte) error {
+			if string(key) == "size" && &value[0] != &tag[11] {
+				t.Fatal("** value copied")
+			}
+			return nil
+		})
+	})
+	if allocs != 0 {
+		t.Errorf("** ParseFunc allocates %v times, wanted 0", allocs)
+	}
+}
+
+func TestParseFuncPos(t *testing.T) {
+	errSimulated := errors.New("simulated")
+	var b strings.Builder
+	err := ParseFuncPos(tagparser.Configuration{}, []byte(`a, b:c ,d`), func(key, value []byte, keyPos, valuePos, end int) error {
+		b.WriteString(string(key) + "=" + string(value) + ";")
+		if string(key) == "d" {
+			return errSimulated
+		}
+		if string(key) == "b" && (keyPos != 3 || valuePos != 5 || end != 6) {
+			t.Errorf("** %s positions %d, %d, %d, wanted 3, 5, 6", key, keyPos, valuePos, end)
+		}
+		return nil
+	})
+	if !errors.Is(err, errSimulated) || b.String() != "a=;b=c;d=;" {
+		t.Errorf("** ParseFuncPos = %q, %v", b.String(), err)
+	}
+}
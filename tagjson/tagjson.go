@@ -0,0 +1,133 @@
+// Package tagjson passes tagparser results to tools that cannot parse the
+// dialect themselves, like linters, editors and code generators written in
+// other languages, as JSON. It is a separate package so that the core parser
+// does not depend on encoding/json, which imports reflect.
+package tagjson
+
+import (
+	"bytes"
+	"encoding/json"
+
+	"github.com/andreyvit/tagparser"
+)
+
+// ParsedTag is the result of Parse as a plain value. It marshals to JSON
+// like:
+//
+//	{"tag":"id,size:'10'","name":"id","hasName":true,"namePos":0,"nameEnd":2,
+//	 "options":[{"key":"size","value":"10","rawKey":"size","rawValue":"'10'",
+//	 "keyPos":3,"keyEnd":7,"valuePos":8,"end":12,"hasValue":true,
+//	 "wasQuoted":true}]}
+//
+// and back. Errors are marshaled like tagparser.ErrorJSON; decoded errors
+// have no Cause, and keep the message in Msg.
+type ParsedTag struct {
+	// Tag is the tag the result has been parsed from, dedented with
+	// Multiline. All positions are byte offsets within it.
+	Tag string `json:"tag"`
+
+	// Name is the name, and HasName is true if the tag has a name item.
+	// NamePos and NameEnd are the span of the name within the tag, with
+	// quotes and escapes intact, excluding surrounding whitespace.
+	Name    string `json:"name"`
+	HasName bool   `json:"hasName"`
+	NamePos int    `json:"namePos"`
+	NameEnd int    `json:"nameEnd"`
+
+	// Options are the other items in the order they appear, subject to
+	// DuplicateKeys, like in ParseOrdered. The wildcard item is included;
+	// directives are not.
+	Options []tagparser.RawItem `json:"options"`
+
+	// Errors are the errors found in the tag: all of them with
+	// CollectAllErrors, otherwise the first one.
+	Errors []*tagparser.Error `json:"errors,omitempty"`
+}
+
+// Parse parses a tag into a ParsedTag. The error, if any, is also listed in
+// ParsedTag.Errors.
+func Parse(conf tagparser.Configuration, tag string) (ParsedTag, error) {
+	if conf.Multiline {
+		tag, conf.Multiline = tagparser.Dedent(tag), false
+	}
+	p := ParsedTag{Tag: tag}
+	err := conf.ParseFuncRaw(tag, func(it tagparser.RawItem) error {
+		if it.Key == "" {
+			p.Name, p.HasName, p.NamePos, p.NameEnd = it.Value, true, it.ValuePos, it.End
+			return nil
+		}
+		if conf.DuplicateKeys != tagparser.DuplicateKeysCollect {
+			for i, o := range p.Options {
+				if o.Key == it.Key {
+					switch conf.DuplicateKeys {
+					case tagparser.DuplicateKeysError:
+						return tagparser.ErrDuplicateKey
+					case tagparser.DuplicateKeysLastWins:
+						p.Options[i] = it
+					}
+					return nil
+				}
+			}
+		}
+		p.Options = append(p.Options, it)
+		return nil
+	})
+	switch err := err.(type) {
+	case *tagparser.Error:
+		p.Errors = []*tagparser.Error{err}
+	case *tagparser.ErrorList:
+		p.Errors = err.Errors
+	}
+	return p, err
+}
+
+// MarshalJSON implements json.Marshaler. Options are never null, so that
+// consumers can iterate over them without checking, and characters like < and
+// > are not escaped, like in tagparser.ErrorJSON.
+func (p ParsedTag) MarshalJSON() ([]byte, error) {
+	type plain ParsedTag
+	if p.Options == nil {
+		p.Options = []tagparser.RawItem{}
+	}
+	var b bytes.Buffer
+	enc := json.NewEncoder(&b)
+	enc.SetEscapeHTML(false)
+	err := enc.Encode(plain(p))
+	return bytes.TrimSuffix(b.Bytes(), []byte("\n")), err
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (p *ParsedTag) UnmarshalJSON(data []byte) error {
+	type plain ParsedTag
+	var v struct {
+		plain
+		Errors []errorJSON `json:"errors"`
+	}
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	*p = ParsedTag(v.plain)
+	p.Errors = nil
+	for _, e := range v.Errors {
+		p.Errors = append(p.Errors, &tagparser.Error{Tag: e.Tag, Pos: e.Pos, Msg: e.Message, Info: tagparser.ErrorInfo{Struct: e.Struct, Field: e.Field, Namespace: e.Namespace}, Code: e.Code, Suggestion: e.Suggestion})
+	}
+	return nil
+}
+
+// MarshalText implements encoding.TextMarshaler by returning the tag, so that
+// a ParsedTag is written as its tag by text-based encoders and loggers.
+func (p ParsedTag) MarshalText() ([]byte, error) {
+	return []byte(p.Tag), nil
+}
+
+// errorJSON is a tagparser.Error in the ErrorJSON style.
+type errorJSON struct {
+	Code       tagparser.ErrorCode `json:"code"`
+	Pos        int                 `json:"pos"`
+	Message    string              `json:"message"`
+	Tag        string              `json:"tag"`
+	Struct     string              `json:"struct"`
+	Field      string              `json:"field"`
+	Namespace  string              `json:"namespace"`
+	Suggestion string              `json:"suggestion"`
+}
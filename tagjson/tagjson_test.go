@@ -0,0 +1,94 @@
+package tagjson
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"github.com/andreyvit/tagparser"
+)
+
+func TestParse(t *testing.T) {
+	conf := tagparser.Configuration{NamePosition: tagparser.NameFirst}
+	p, err := Parse(conf, `id,size:'10'`)
+	const expected = `{"tag":"id,size:'10'","name":"id","hasName":true,"namePos":0,"nameEnd":2,` +
+		`"options":[{"key":"size","value":"10","rawKey":"size","rawValue":"'10'",` +
+		`"keyPos":3,"keyEnd":7,"valuePos":8,"end":12,"hasValue":true,"wasQuoted":true}]}`
+	if data, jerr := json.Marshal(p); err != nil || jerr != nil || string(data) != expected {
+		t.Errorf("** Parse JSON = %s, %v, %v, wanted %s", data, err, jerr, expected)
+	}
+	if text, _ := p.MarshalText(); string(text) != `id,size:'10'` {
+		t.Errorf("** MarshalText = %s", text)
+	}
+
+	var tests = []struct {
+		conf    tagparser.Configuration
+		tag     string
+		name    string
+		hasName bool
+		keys    string
+		errors  int
+	}{
+		{conf, ``, "", false, ``, 0},
+		{conf, `,a`, "", true, `a`, 0},
+		{conf, `  n , a:1`, "n", true, `a:1@8`, 0},
+		{tagparser.Configuration{}, `a,*,a`, "", false, `a *`, 1},
+		{tagparser.Configuration{DuplicateKeys: tagparser.DuplicateKeysLastWins}, `a:1,b,a:2`, "", false, `a:2@8 b`, 0},
+		{tagparser.Configuration{DuplicateKeys: tagparser.DuplicateKeysFirstWins}, `a:1,b,a:2`, "", false, `a:1@2 b`, 0},
+		{tagparser.Configuration{DuplicateKeys: tagparser.DuplicateKeysCollect}, `a:1,a:2`, "", false, `a:1@2 a:2@6`, 0},
+		{tagparser.Configuration{DirectivePrefix: '#'}, `#v:2,a`, "", false, `a`, 0},
+		{tagparser.Configuration{Multiline: true, NamePosition: tagparser.NameLast}, "\n\ta,\n\tn\n", "n", true, `a`, 0},
+		{tagparser.Configuration{CollectAllErrors: true}, `:x,'a`, "", false, `a`, 2},
+	}
+	for _, test := range tests {
+		p, err := Parse(test.conf, test.tag)
+		var keys []byte
+		for i, o := range p.Options {
+			if i > 0 {
+				keys = append(keys, ' ')
+			}
+			keys = append(keys, o.Key...)
+			if o.HasValue {
+				keys = append(keys, ':')
+				keys = append(keys, o.Value...)
+				keys = append(keys, '@')
+				keys = append(keys, byte('0'+o.ValuePos))
+			}
+		}
+		if p.Name != test.name || p.HasName != test.hasName || string(keys) != test.keys || len(p.Errors) != test.errors || (err != nil) != (test.errors > 0) {
+			t.Errorf("** Parse(%q) = %+v, %v, wanted name %q (%v), keys %q, %d errors", test.tag, p, err, test.name, test.hasName, test.keys, test.errors)
+		}
+		if p.HasName && p.Tag[p.NamePos:p.NameEnd] != test.name {
+			t.Errorf("** Parse(%q) name span %d:%d", test.tag, p.NamePos, p.NameEnd)
+		}
+	}
+}
+
+func TestParsedTag_JSON(t *testing.T) {
+	conf := tagparser.Configuration{NamePosition: tagparser.NameFirst, KnownKeys: []string{"a"}}
+	p, _ := Parse(conf, `n,a:'x "y"',b`)
+	p.Errors[0].Info = tagparser.ErrorInfo{Struct: "User", Field: "Email", Namespace: "json"}
+	p.Errors[0].Suggestion = "did you mean a?"
+	data, err := json.Marshal(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var decoded ParsedTag
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatal(err)
+	}
+	if decoded.Errors[0].Error() != p.Errors[0].Error() || decoded.Errors[0].Cause != nil {
+		t.Errorf("** decoded error %v, wanted %v", decoded.Errors[0], p.Errors[0])
+	}
+	decoded.Errors, p.Errors = nil, nil
+	if !reflect.DeepEqual(decoded, p) {
+		t.Errorf("** decoded %+v, wanted %+v", decoded, p)
+	}
+
+	if data, _ := json.Marshal(ParsedTag{}); string(data) != `{"tag":"","name":"","hasName":false,"namePos":0,"nameEnd":0,"options":[]}` {
+		t.Errorf("** empty ParsedTag JSON = %s", data)
+	}
+	if err := json.Unmarshal([]byte(`{"errors":[{"pos":"x"}]}`), &decoded); err == nil {
+		t.Errorf("** Unmarshal succeeded, wanted an error")
+	}
+}
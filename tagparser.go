@@ -6,13 +6,18 @@ package tagparser
 import (
 	"errors"
 	"fmt"
+	"strconv"
 	"strings"
-	"unsafe"
 )
 
 // ErrDuplicateKey is returned as Error.Cause for duplicate tag keys.
 var ErrDuplicateKey = errors.New("duplicate option key")
 
+// ErrStop can be returned by parse func callbacks to stop parsing early. It is
+// not reported as an error: the parse func returns the errors found before
+// the callback returned ErrStop, if any, and nil otherwise.
+var ErrStop = errors.New("stop parsing")
+
 // Error is the type of error returned by parse funcs in this package.
 type Error struct {
 	// Tag is the original tag string that has a syntax error.
@@ -21,23 +26,60 @@ type Error struct {
 	// as errorneous.
 	Pos int
 	// Msg is an error message, or an optional prefix to the error message of
-	// the Cause.
+	// the Cause. Messages may change between versions; match on Code instead.
 	Msg string
 	// Cause is an optional underlying error returned by ParseFunc callback, or
 	// ErrDuplicateKey.
 	Cause error
+	// Info describes where the tag comes from, if provided by the caller.
+	Info ErrorInfo
+	// Code identifies the kind of the error. Codes and positions are stable
+	// across versions, unlike messages.
+	Code ErrorCode
+	// Suggestion is an optional hint on fixing the error, like a corrected
+	// tag. It is rendered by the ErrorVerbose style of Format.
+	Suggestion string
+
+	src *errorSource // see TestCase
 }
 
-func (e *Error) Error() string {
-	if e.Cause != nil {
-		if e.Msg != "" {
-			return fmt.Sprintf("%s: %v (at %d)", e.Msg, e.Cause, e.Pos+1)
+// ErrorInfo describes where a tag comes from, so that errors can be rendered
+// like `User.Email json: empty key (at 2)`. All fields are optional.
+type ErrorInfo struct {
+	// Struct is the name of the struct type, e.g. "User".
+	Struct string
+	// Field is the name of the struct field, e.g. "Email".
+	Field string
+	// Namespace is the key of the tag within the struct tag, e.g. "json".
+	Namespace string
+}
+
+// String returns a human-readable description like `User.Email json`.
+func (info ErrorInfo) String() string {
+	s := info.Field
+	if info.Struct != "" {
+		if s != "" {
+			s = info.Struct + "." + s
 		} else {
-			return fmt.Sprintf("%v (at %d)", e.Cause, e.Pos+1)
+			s = info.Struct
 		}
-	} else {
-		return fmt.Sprintf("%s (at %d)", e.Msg, e.Pos+1)
 	}
+	if info.Namespace != "" {
+		if s != "" {
+			s += " " + info.Namespace
+		} else {
+			s = info.Namespace
+		}
+	}
+	return s
+}
+
+func (e *Error) Error() string {
+	var prefix string
+	if info := e.Info.String(); info != "" {
+		prefix = info + ": "
+	}
+	return fmt.Sprintf("%s%s (at %d)", prefix, e.message(), e.Pos+1)
 }
 
 func (e *Error) Unwrap() error {
@@ -47,44 +89,20 @@ func (e *Error) Unwrap() error {
 // ParseName parses a tag treating the first item as a name. See ParseFunc for
 // the full syntax and details.
 func ParseName(tag string) (name string, opts map[string]string, err error) {
-	err = ParseNameFunc(tag, func(key, value string) error {
-		if key == "" {
-			name = value
-		} else {
-			if opts == nil {
-				opts = make(map[string]string)
-			}
-			if _, ok := opts[key]; ok {
-				return ErrDuplicateKey
-			}
-			opts[key] = value
-		}
-		return nil
-	})
-	return
+	return nameFirst.Parse(tag)
 }
 
 // Parse parses a tag without special treatment of the first item. See ParseFunc
 // for the full syntax and details.
 func Parse(tag string) (map[string]string, error) {
-	var opts map[string]string
-	err := ParseFunc(tag, func(key, value string) error {
-		if opts == nil {
-			opts = make(map[string]string)
-		}
-		if _, ok := opts[key]; ok {
-			return ErrDuplicateKey
-		}
-		opts[key] = value
-		return nil
-	})
+	_, opts, err := nameNone.Parse(tag)
 	return opts, err
 }
 
 // ParseNameFunc is like ParseFunc, but treats the first item as a name. See
 // ParseFunc for the full syntax and details.
 func ParseNameFunc(tag string, callback func(key, value string) error) error {
-	return parseFunc(tag, true, callback)
+	return parseFunc(tag, &nameFirst, ErrorInfo{}, callback, nil, nil)
 }
 
 // ParseFunc enumerates fields of a tag formatted as a list of keys and/or
@@ -110,12 +128,14 @@ func ParseNameFunc(tag string, callback func(key, value string) error) error {
 //  4. Both keys and values can use a backslash to escape special characters
 //     (`foo\ bar`, `foo\:bar`, `foo\,bar`, `'foo\'n\'bar'`); the escapes are
 //     processed and removed from the values (so `foo:\:\,\!` is returned as
-//     `map[string]string{"foo": ":,!"}`); you can escape any non-alphabetical
-//     characters;
+//     `map[string]string{"foo": ":,!"}`); you can escape any non-alphanumeric
+//     characters (Configuration.EscapableChars can restrict this outside of
+//     quotes);
 //
 //  5. Non-escaped unquoted leading and trailing ASCII whitespace is trimmed
-//     from keys and values. (There seems to be no reason to handle Unicode
-//     whitespace within struct tags.)
+//     from keys and values. (Hand-written struct tags have no reason to
+//     contain Unicode whitespace; Configuration.UnicodeWhitespace trims it in
+//     generated ones.)
 //
 //  6. ParseName and ParseNameFunc give special treatment to the first item of
 //     the tag if it does not have a colon. Such an item is returned as a name
@@ -126,170 +146,799 @@ func ParseNameFunc(tag string, callback func(key, value string) error) error {
 //
 //  7. For normal items, empty key names are not allowed.
 //
-// The error, if present, is *Error. If your callback returns an error, it will
+//  8. Keys should not contain invisible characters (byte order mark,
+//     zero-width spaces and joiners, non-breaking space), which are
+//     impossible to spot in code review. They are reported in
+//     Result.Warnings, or as errors with StrictInvisibleChars, with the tag
+//     cleaned up by RemoveInvisible as the suggestion. Names and values may
+//     contain them.
+//
+// The error, if present, is *Error, or *ErrorList with
+// Configuration.CollectAllErrors. If your callback returns an error, it will
 // be wrapped in an Error with your error stored in Error.Cause.
+//
+// Parsing does not stop at syntax errors, so display-oriented tools can
+// simply ignore errors and still get a best-effort result for any input:
+// unterminated quotes are closed at the end of the tag, backslashes of invalid
+// escapes and misplaced quotes are dropped, items with empty keys are skipped,
+// and the callback is invoked for all other items even after it has returned
+// an error. Only the first error is returned, unless CollectAllErrors is set.
+// Parsing does stop early at the limits of Configuration.MaxOptions and
+// MaxNestingDepth, and a tag longer than MaxLength is not parsed at all; the
+// items before the limit have been reported by then. Return ErrStop from the
+// callback to stop parsing once you have found what you need. ParseLenient
+// returns the best-effort result along with every problem found.
 func ParseFunc(tag string, callback func(key, value string) error) error {
-	return parseFunc(tag, false, callback)
+	return parseFunc(tag, &nameNone, ErrorInfo{}, callback, nil, nil)
+}
+
+// ParseFuncWithInfo is like ParseFunc, but returns errors carrying the given
+// description of the tag's origin, e.g. `User.Email json: empty key (at 2)`.
+func ParseFuncWithInfo(tag string, info ErrorInfo, callback func(key, value string) error) error {
+	return parseFunc(tag, &nameNone, info, callback, nil, nil)
 }
 
-func parseFunc(tag string, firstItemIsName bool, callback func(key, value string) error) error {
-	var parseErr error
-	fail := func(i int, msg string, cause error) {
-		if parseErr == nil {
-			parseErr = &Error{tag, i, msg, cause}
+func parseFunc(tag string, conf *Configuration, info ErrorInfo, callback, directive func(key, value string) error, stats *Stats) error {
+	return parseItems(tag, conf, info, func(key, value string, _ item) error {
+		return callback(key, value)
+	}, directive, stats)
+}
+
+// parseItems is like parseFunc, but also passes the item to the callback.
+// With continuations, the value span of the item covers all joined parts.
+func parseItems(tag string, conf *Configuration, info ErrorInfo, callback func(key, value string, it item) error, directive func(key, value string) error, stats *Stats) error {
+	err := scanItems(tag, conf, info, callback, directive, stats)
+	if err != nil {
+		attachSource(err, tag, conf)
+	}
+	return err
+}
+
+func scanItems(tag string, conf *Configuration, info ErrorInfo, callback func(key, value string, it item) error, directive func(key, value string) error, stats *Stats) error {
+	if conf.MaxLength > 0 && len(tag) > conf.MaxLength {
+		s := scanner{tag: tag, conf: *conf, info: info}
+		s.failLimit(conf.MaxLength, "tag longer than "+strconv.Itoa(conf.MaxLength)+" bytes")
+		return s.result()
+	}
+	if conf.Multiline {
+		tag = Dedent(tag)
+	}
+	s := scanner{tag: tag, conf: *conf, info: info, stats: stats}
+	if stats == nil && conf.simple() {
+		s.scanSimple(callback)
+		return s.result()
+	}
+	report := func(key, value string, it item) {
+		if len(conf.KnownKeys) > 0 && !it.isName {
+			s.checkKnown(it.keyStart, key)
+		}
+		if conf.KeyValidator != nil && !it.isName {
+			s.validateKey(it.keyStart, key)
+		}
+		if conf.ExpandValue != nil && it.hasValue && !it.isName && !it.isList {
+			value = s.expandValue(it, key, value)
+		}
+		err := callback(key, value, it)
+		if errors.Is(err, ErrStop) {
+			s.stopped = true
+		} else if err != nil {
+			s.failCallback(it.keyStart, key, err)
+		}
+	}
+	unquoteItem := func(it item) (key, value string, ok bool) {
+		key, value, ok = s.unquoteItem(it)
+		if ok && it.isDirective {
+			if directive != nil {
+				err := directive(key, value)
+				if errors.Is(err, ErrStop) {
+					s.stopped = true
+				} else if err != nil {
+					s.failCallback(it.keyStart, key, err)
+				}
+			}
+			return "", "", false
+		}
+		return
+	}
+	if conf.ContinuationKey == "" {
+		s.scan(func(it item) {
+			if key, value, ok := unquoteItem(it); ok {
+				report(key, value, it)
+			}
+		})
+		return s.result()
+	}
+
+	// With continuations, an item can only be reported once the next one is
+	// known not to be its continuation.
+	var prevKey, prevValue string
+	var prev item
+	var hasPrev bool
+	s.scan(func(it item) {
+		key, value, ok := unquoteItem(it)
+		if !ok {
+			return
 		}
+		if !it.isName && key == conf.ContinuationKey {
+			if !hasPrev {
+				s.fail(it.keyStart, CodeOrphanContinuation)
+			} else {
+				prevValue = prevValue + conf.ContinuationJoiner + value
+				if !prev.hasValue {
+					prev.valueStart, prev.hasValue = it.valueStart, true
+				}
+				prev.valueEnd = it.valueEnd
+			}
+			return
+		}
+		if hasPrev {
+			report(prevKey, prevValue, prev)
+		}
+		prevKey, prevValue, prev, hasPrev = key, value, it, true
+	})
+	if hasPrev && !s.stopped {
+		report(prevKey, prevValue, prev)
 	}
+	return s.result()
+}
 
-	var count int
-	var inValue bool
-	var start int
-	var key string
-	var keyStart int
+// simple reports whether the configuration only uses the settings supported
+// by scanSimple. Settings that only affect what the callers of parseItems do
+// with the items, like WildcardKey and DuplicateKeys, do not matter.
+func (conf *Configuration) simple() bool {
+	return (conf.NamePosition == NameNone || conf.NamePosition == NameFirst) &&
+		conf.ContinuationKey == "" && !conf.GreedyLastValue && conf.QuotedNames == QuotedNamesAllow &&
+		conf.QuoteChars == "" && conf.EscapableChars == "" && !conf.AllowStandardEscapes &&
+		!conf.ListValues && conf.NegationPrefix == 0 && conf.DirectivePrefix == 0 && !conf.Multiline &&
+		conf.StripKeyPrefix == "" && (conf.KeyValueSeparator == 0 || conf.KeyValueSeparator == ':') &&
+		(conf.ItemSeparator == 0 || conf.ItemSeparator == ',') && !conf.UnicodeWhitespace &&
+		conf.KeyValidator == nil && !conf.PercentDecodeValues && conf.ExpandValue == nil && !conf.FoldKeys && conf.KeyNormalizer == nil &&
+		len(conf.KnownKeys) == 0 && len(conf.KeyAliases) == 0 && len(conf.DeprecatedKeys) == 0 && !conf.WarnQuotedEmptyValues &&
+		conf.MaxLength == 0 && conf.MaxOptions == 0 && conf.MaxNestingDepth == 0
+}
+
+// scanSimple does the work of scan and parseItems for simple configurations,
+// which covers Parse, ParseName and the plain parse funcs. It is the original
+// scanner of this package, kept as a fast path: it behaves exactly like the
+// general one, but does not pay for the checks of optional features.
+func (s *scanner) scanSimple(callback func(key, value string, it item) error) {
+	tag := s.tag
+	n := len(tag)
+	nameFirst := s.conf.NamePosition == NameFirst
+	var it item
+	var inValue, stopped bool
+	var start, count int
 
 	flush := func(i int) {
 		count++
-		var value, errMsg string
-		var errPos int
-		if count == 1 && firstItemIsName && !inValue {
-			key = ""
-			keyStart = start
-			value, errMsg, errPos = unquoteTrim(tag[start:i])
-			if errMsg != "" {
-				fail(start+errPos, errMsg, nil)
-			}
+		if inValue {
+			it.valueStart, it.valueEnd, it.hasValue = start, i, true
 		} else {
-			if inValue {
-				key, errMsg, errPos = unquoteTrim(key)
-				if errMsg != "" {
-					fail(keyStart+errPos, errMsg, nil)
-				}
-				value, errMsg, errPos = unquoteTrim(tag[start:i])
-				if errMsg != "" {
-					fail(start+errPos, errMsg, nil)
-				}
-			} else if start < i {
-				keyStart = start
-				key, errMsg, errPos = unquoteTrim(tag[start:i])
-				if errMsg != "" {
-					fail(start+errPos, errMsg, nil)
-				}
-			} else {
+			it.keyStart, it.keyEnd = start, i
+			it.isName = nameFirst && count == 1
+			if start == i && !it.isName {
 				return
 			}
+		}
+		if !it.isName {
+			s.checkInvisible(it.keyStart, it.keyEnd)
+		}
+		var key, value string
+		if it.isName {
+			value = s.unquoteSimple(it.keyStart, it.keyEnd)
+		} else {
+			key = s.unquoteSimple(it.keyStart, it.keyEnd)
+			if it.hasValue {
+				value = s.unquoteSimple(it.valueStart, it.valueEnd)
+			}
 			if key == "" {
-				fail(keyStart, "empty key", nil)
+				s.fail(it.keyStart, CodeEmptyKey)
 				return
 			}
 		}
-		err := callback(key, value)
-		if err != nil {
-			fail(keyStart, key, err)
+		if err := callback(key, value, it); err != nil {
+			if errors.Is(err, ErrStop) {
+				stopped = true
+			} else {
+				s.failCallback(it.keyStart, key, err)
+			}
+		}
+	}
+
+	var quoteStart int = -1
+	for i := 0; i < n; i++ {
+		if quoteStart >= 0 {
+			switch tag[i] {
+			case '\'':
+				quoteStart = -1
+			case '\\':
+				i++
+				s.checkEscape(i, true)
+			}
+		} else {
+			switch tag[i] {
+			case '\'':
+				quoteStart = i
+			case '\\':
+				i++
+				s.checkEscape(i, false)
+			case ':':
+				if !inValue {
+					it.keyStart, it.keyEnd = start, i
+					start = i + 1
+					inValue = true
+				}
+			case ',':
+				flush(i)
+				if stopped {
+					return
+				}
+				start = i + 1
+				inValue = false
+				it = item{}
+			}
+		}
+	}
+	if quoteStart >= 0 {
+		s.fail(quoteStart, CodeUnterminatedQuote)
+	}
+	if start < n || inValue {
+		flush(n)
+	}
+}
+
+// scanner splits a tag into items, remembering the first error encountered.
+type scanner struct {
+	tag string
+	// conf is a copy rather than a pointer, so that the caller's
+	// configuration does not escape to the heap along with errors.
+	conf  Configuration
+	info  ErrorInfo
+	err   error
+	stats *Stats // only used with the tagparser_stats build tag
+	// stopped is set when a callback returns ErrStop, and limited when a
+	// limit is exceeded; both stop the scan.
+	stopped, limited bool
+	// options counts the items other than the name, see MaxOptions.
+	options int
+}
+
+// item is a raw item of a tag, represented by spans within the tag. A name is
+// represented by the key span.
+type item struct {
+	keyStart, keyEnd     int
+	valueStart, valueEnd int
+	hasValue             bool
+	isName               bool
+	isDirective          bool
+	isNegated            bool
+	isList               bool
+}
+
+// result returns the error to be reported by parse funcs.
+func (s *scanner) result() error {
+	if list, ok := s.err.(*ErrorList); ok {
+		list.sort()
+	}
+	return s.err
+}
+
+func (s *scanner) fail(i int, code ErrorCode) {
+	if s.err == nil || s.conf.CollectAllErrors {
+		s.err = addError(s.err, &Error{Tag: s.tag, Pos: i, Msg: code.Message(), Info: s.info, Code: code, Suggestion: s.suggest(i, code)}, s.conf.CollectAllErrors)
+	}
+}
+
+// failCallback records an error returned by a callback for the given key.
+func (s *scanner) failCallback(i int, key string, cause error) {
+	if s.err == nil || s.conf.CollectAllErrors {
+		code := CodeCallback
+		var se *SchemaError
+		if errors.As(cause, &se) {
+			// The cause already names the key.
+			code, key = se.Code, ""
+		} else if errors.Is(cause, ErrDuplicateKey) {
+			code = CodeDuplicateKey
+			if s.conf.FoldKeys {
+				if d := s.caseDuplicate(i); d != nil {
+					code, cause = CodeDuplicateKeyCase, d
+				}
+			}
+		}
+		s.err = addError(s.err, &Error{Tag: s.tag, Pos: i, Msg: key, Cause: cause, Info: s.info, Code: code}, s.conf.CollectAllErrors)
+	}
+}
+
+// unquote returns the trimmed and unescaped contents of the given span of the
+// tag, reporting errors at their positions within the tag.
+func (s *scanner) unquote(start, end int) string {
+	result, code, errPos := unquoteTrim(s.tag[start:end], &s.conf)
+	if code != "" {
+		s.fail(start+errPos, code)
+	}
+	return result
+}
+
+// unquoteItem returns the unquoted key and value of the item, or ok == false
+// if the item has to be skipped because of an empty key. Names are returned as
+// values with an empty key. Directives are returned without the prefix.
+func (s *scanner) unquoteItem(it item) (key, value string, ok bool) {
+	if it.isName {
+		if s.conf.QuotedNames == QuotedNamesError {
+			s.checkUnquotedName(it.keyStart, it.keyEnd)
+		}
+		return "", s.unquote(it.keyStart, it.keyEnd), true
+	}
+	key = s.unquote(it.keyStart, it.keyEnd)
+	if it.isDirective || it.isNegated {
+		key = key[1:]
+	}
+	if !it.isDirective {
+		key = strings.TrimPrefix(key, s.conf.StripKeyPrefix)
+	}
+	key = s.conf.foldKey(key)
+	if !it.isDirective {
+		if s.conf.DeprecatedKeys != nil {
+			s.checkDeprecated(it.keyStart, key)
+		}
+		if alias, ok := s.conf.lookupKey(s.conf.KeyAliases, key); ok {
+			key = s.conf.foldKey(alias)
 		}
 	}
+	if it.isNegated {
+		if it.hasValue {
+			s.fail(it.valueStart-1, CodeNegatedValue)
+			return "", "", false
+		}
+		value = "false"
+	} else if it.isList {
+		ts, te := s.conf.trimSpace(s.tag[it.valueStart:it.valueEnd])
+		value = s.tag[it.valueStart+ts : it.valueStart+te]
+	} else if it.hasValue {
+		value = s.unquote(it.valueStart, it.valueEnd)
+		if s.conf.PercentDecodeValues {
+			value = s.percentDecode(value, it.valueStart, it.valueEnd)
+		}
+		if value == "" && s.conf.WarnQuotedEmptyValues {
+			s.checkQuotedEmpty(it.valueStart, it.valueEnd, key)
+		}
+	}
+	if key == "" {
+		s.fail(it.keyStart, CodeEmptyKey)
+		return "", "", false
+	}
+	return key, value, true
+}
 
+// unquoteSimple is unquote for scanSimple.
+func (s *scanner) unquoteSimple(start, end int) string {
+	str := s.tag[start:end]
+	i := 0
+	for i < len(str) && str[i] != '\\' && str[i] != '\'' {
+		i++
+	}
+	if i == len(str) {
+		ts, te := trimSpace(str)
+		return str[ts:te]
+	}
+	var buf [64]byte
+	b, code, errPos := appendUnquoteTrim(buf[:0], str, &s.conf)
+	if code != "" {
+		s.fail(start+errPos, code)
+	}
+	return string(b)
+}
+
+// appendUnquote is like unquote, but appends the result to b.
+func (s *scanner) appendUnquote(b []byte, start, end int) []byte {
+	b, code, errPos := appendUnquoteTrim(b, s.tag[start:end], &s.conf)
+	if code != "" {
+		s.fail(start+errPos, code)
+	}
+	return b
+}
+
+// scan calls yield for every item of the tag, skipping empty items (but not
+// an empty name).
+func (s *scanner) scan(yield func(it item)) {
+	tag, conf := s.tag, &s.conf
 	n := len(tag)
+	s.countBytes(n)
+	var count int
+	var inValue bool
+	var start int
+	var it item
 
-	checkEscape := func(i int) {
-		if i >= n {
-			fail(i-1, "unterminated escape sequence", nil)
-			return
+	flush := func(i int) {
+		count++
+		if inValue {
+			it.valueStart, it.valueEnd, it.hasValue = start, i, true
+		} else {
+			it.keyStart, it.keyEnd = start, i
+		}
+		if conf.DirectivePrefix != 0 {
+			ts, te := conf.trimSpace(tag[it.keyStart:it.keyEnd])
+			it.isDirective = ts < te && tag[it.keyStart+ts] == conf.DirectivePrefix
+		}
+		if !inValue {
+			it.isName = !it.isDirective && (conf.NamePosition == NameFirst && count == 1 || conf.NamePosition == NameLast && i == n)
+			if start == i && !it.isName {
+				return
+			}
+		}
+		if !it.isName {
+			s.options++
+			if conf.MaxOptions > 0 && s.options > conf.MaxOptions {
+				s.failLimit(it.keyStart, "more than "+strconv.Itoa(conf.MaxOptions)+" options")
+				return
+			}
+		}
+		if conf.NegationPrefix != 0 && !it.isName && !it.isDirective {
+			ts, te := conf.trimSpace(tag[it.keyStart:it.keyEnd])
+			it.isNegated = ts < te && tag[it.keyStart+ts] == conf.NegationPrefix
 		}
-		c := tag[i]
-		if c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' {
-			fail(i, "invalid escape character", nil)
+		if !it.isName {
+			s.checkInvisible(it.keyStart, it.keyEnd)
 		}
+		s.countItem()
+		yield(it)
+	}
+
+	greedyStart := -1
+	if conf.GreedyLastValue {
+		greedyStart = s.lastValueStart()
 	}
 
+	kvSep, itemSep := conf.separators()
+	q, table := conf.quotes(), conf.table
 	var quoteStart int = -1
+	var bracketStart, depth int
 	for i := 0; i < n; i++ {
 		if quoteStart >= 0 {
 			switch tag[i] {
-			case '\'':
+			case tag[quoteStart]:
 				quoteStart = -1
 			case '\\':
 				i++
-				checkEscape(i)
+				s.checkEscape(i, true)
 			}
 		} else {
+			if table != nil && table[tag[i]]&scanClasses == 0 {
+				continue
+			}
 			switch tag[i] {
-			case '\'':
+			case q[0], q[1], q[2]:
 				quoteStart = i
 			case '\\':
 				i++
-				checkEscape(i)
-			case ':':
+				s.checkEscape(i, false)
+			case '[':
+				if ts, te := conf.trimSpace(tag[start:i]); conf.ListValues && inValue && (it.isList || ts == te) {
+					if depth == 0 {
+						bracketStart = i
+					}
+					it.isList = true
+					depth++
+					if conf.MaxNestingDepth > 0 && depth > conf.MaxNestingDepth {
+						s.failLimit(i, "brackets nested deeper than "+strconv.Itoa(conf.MaxNestingDepth))
+						return
+					}
+				}
+			case ']':
+				if depth > 0 {
+					depth--
+				}
+			case kvSep:
 				if !inValue {
-					key = tag[start:i]
-					keyStart = start
+					it.keyStart, it.keyEnd = start, i
 					start = i + 1
 					inValue = true
 				}
-			case ',':
+			case itemSep:
+				if start == greedyStart || depth > 0 {
+					continue
+				}
 				flush(i)
+				if s.stopped || s.limited {
+					return
+				}
 				start = i + 1
 				inValue = false
+				it = item{}
 			}
 		}
 	}
 	if quoteStart >= 0 {
-		fail(quoteStart, "unterminated quote", nil)
+		s.fail(quoteStart, CodeUnterminatedQuote)
 	}
-	if start < n || inValue {
+	if depth > 0 {
+		s.fail(bracketStart, CodeUnterminatedBracket)
+	}
+	if start < n || inValue || (conf.NamePosition == NameLast && count > 0) {
 		flush(n)
 	}
-	return parseErr
+}
+
+// lastValueStart returns the position where the value of the last item with
+// a colon starts, or -1 if no item has a colon.
+func (s *scanner) lastValueStart() int {
+	tag := s.tag
+	s.countBytes(len(tag))
+	kvSep, itemSep := s.conf.separators()
+	q := s.conf.quotes()
+	result := -1
+	var quote byte // the opening quote while in a quoted part
+	var inValue bool
+	for i := 0; i < len(tag); i++ {
+		switch c := tag[i]; {
+		case c == '\\':
+			i++
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case q.has(c):
+			quote = c
+		case c == kvSep && !inValue:
+			inValue = true
+			result = i + 1
+		case c == itemSep:
+			inValue = false
+		}
+	}
+	return result
+}
+
+func (s *scanner) checkUnquotedName(start, end int) {
+	ts, te := s.conf.trimSpace(s.tag[start:end])
+	if ts < te && s.conf.quotes().has(s.tag[start+ts]) {
+		s.fail(start+ts, CodeQuotedName)
+	}
+}
+
+// checkEscape validates the character at s.tag[i] escaped by a preceding
+// backslash.
+func (s *scanner) checkEscape(i int, inQuote bool) {
+	s.countEscape()
+	if s.conf.trusted {
+		return
+	}
+	if i >= len(s.tag) {
+		s.fail(i-1, CodeUnterminatedEscape)
+		return
+	}
+	c := s.tag[i]
+	if inQuote && s.conf.AllowStandardEscapes {
+		switch c {
+		case 'n', 't', 'r', '0':
+			return
+		case 'x', 'u':
+			if _, n := decodeEscape(s.tag[i:]); n <= 0 {
+				s.fail(i-n, CodeMalformedEscape)
+			}
+			return
+		}
+	}
+	if !inQuote && s.conf.EscapableChars != "" {
+		if strings.IndexByte(s.conf.EscapableChars, c) < 0 {
+			s.fail(i, CodeInvalidEscape)
+		}
+	} else if isAlnum(c) {
+		s.fail(i, CodeInvalidEscape)
+	}
+}
+
+func (s *scanner) checkInvisible(start, end int) {
+	if s.conf.trusted {
+		return
+	}
+	if s.conf.UnicodeWhitespace {
+		ts, te := trimUnicodeSpace(s.tag[start:end])
+		start, end = start+ts, start+te
+	}
+	for i := start; i < end; i++ {
+		if s.tag[i] >= 0x80 && invisibleCharLen(s.tag[i:end]) > 0 {
+			if s.conf.StrictInvisibleChars {
+				s.fail(i, CodeInvisibleChar)
+			} else if s.conf.warnings != nil {
+				*s.conf.warnings = append(*s.conf.warnings, &Error{Tag: s.tag, Pos: i, Msg: CodeInvisibleChar.Message(), Info: s.info, Code: CodeInvisibleChar, Suggestion: s.suggest(i, CodeInvisibleChar)})
+			}
+			return
+		}
+	}
 }
 
 var asciiSpace = [256]uint8{'\t': 1, '\n': 1, '\v': 1, '\f': 1, '\r': 1, ' ': 1}
 
-// unquoteTrim trims leading and trailing unescaped ASCII whitespace, processes
-// escape sequences within the string and removes single quotes.
-func unquoteTrim(s string) (result string, parseErr string, errPos int) {
-	n := len(s)
+// quoteSet holds up to three quote characters, padded by repeating the first
+// one, so that checks compile to plain comparisons.
+type quoteSet [3]byte
 
-	var start int
-	for start < n && asciiSpace[s[start]] != 0 {
-		start++
+func (q quoteSet) has(c byte) bool {
+	return c == q[0] || c == q[1] || c == q[2]
+}
+
+// in reports whether s contains any of the quote characters.
+func (q quoteSet) in(s string) bool {
+	return strings.IndexByte(s, q[0]) >= 0 || q[1] != q[0] && strings.IndexByte(s, q[1]) >= 0 || q[2] != q[0] && strings.IndexByte(s, q[2]) >= 0
+}
+
+// unquoteTrim trims leading and trailing unescaped whitespace, processes
+// escape sequences within the string and removes quotes, according to the
+// quoting, escaping and whitespace settings of conf.
+func unquoteTrim(s string, conf *Configuration) (result string, parseErr ErrorCode, errPos int) {
+	if !conf.hasQuoteOrEscape(s) {
+		start, end := conf.trimSpace(s)
+		return s[start:end], "", 0
 	}
 
-	var end int = n
+	var buf [64]byte
+	b, parseErr, errPos := appendUnquoteTrim(buf[:0], s, conf)
+	return string(b), parseErr, errPos
+}
+
+// hasQuoteOrEscape reports whether s contains quotes or backslashes.
+func (conf *Configuration) hasQuoteOrEscape(s string) bool {
+	if t := conf.table; t != nil {
+		for i := 0; i < len(s); i++ {
+			if t[s[i]]&(CharQuote|CharEscape) != 0 {
+				return true
+			}
+		}
+		return false
+	}
+	return strings.IndexByte(s, '\\') >= 0 || conf.quotes().in(s)
+}
+
+// trimSpace returns the span of s without leading and trailing ASCII
+// whitespace.
+func trimSpace(s string) (start, end int) {
+	end = len(s)
+	for start < end && asciiSpace[s[start]] != 0 {
+		start++
+	}
 	for end > start && asciiSpace[s[end-1]] != 0 {
 		end--
 	}
+	return
+}
+
+// appendUnquoteTrim is like unquoteTrim, but appends the result to b.
+func appendUnquoteTrim(b []byte, s string, conf *Configuration) (result []byte, parseErr ErrorCode, errPos int) {
+	return appendUnquoteTrimPos(b, s, conf, nil)
+}
+
+// appendUnquoteTrimPos is like appendUnquoteTrim, but if pos is not nil, also
+// appends to it the position within s that each appended byte comes from, so
+// that errors found in the result can be reported within s.
+func appendUnquoteTrimPos(b []byte, s string, conf *Configuration, pos *[]int) (result []byte, parseErr ErrorCode, errPos int) {
+	n := len(s)
+	q, std := conf.quotes(), conf.AllowStandardEscapes
+	start, end := conf.trimSpace(s)
 	// Note that end may have trimmed the final escaped space here. When we
 	// encounter a backslash at s[end-1] and end < n, we will output s[end].
 
-	if strings.IndexByte(s, '\\') < 0 && strings.IndexByte(s, '\'') < 0 {
-		return s[start:end], "", 0
-	}
-
-	b := make([]byte, 0, n)
-	var inQuote bool
+	initial := len(b)
+	var quote byte // the opening quote while in a quoted part
 	var quoteCount int
-mainLoop:
+	unit := start // where the bytes appended last come from
 	for i := start; i < end; i++ {
+		if pos != nil {
+			appendPos(pos, len(b)-initial, unit)
+			unit = i
+		}
 		c := s[i]
-		switch c {
-		case '\\':
+		if c == '\\' {
 			if i+1 < n {
+				if std && quote != 0 {
+					if r, l := decodeEscape(s[i+1:]); l > 0 {
+						b = appendRune(b, r)
+						i += l
+						continue
+					}
+				}
 				b = append(b, s[i+1])
 				i++
+				unit = i
 			}
-			continue mainLoop
-		case '\'':
+			continue
+		}
+		if quote == 0 && q.has(c) || c == quote {
 			quoteCount++
-			if quoteCount > 2 || (quoteCount == 1 && len(b) > 0) {
+			if quoteCount > 2 || (quoteCount == 1 && len(b) > initial) {
 				if parseErr == "" {
-					parseErr, errPos = "invalid quote", i
+					parseErr, errPos = CodeInvalidQuote, i
 				}
 			}
-			inQuote = !inQuote
-			continue mainLoop
+			if quote == 0 {
+				quote = c
+			} else {
+				quote = 0
+			}
+			continue
 		}
 		b = append(b, c)
 	}
-	if len(b) > 0 {
-		result = unsafe.String(&b[0], len(b))
+	if pos != nil {
+		appendPos(pos, len(b)-initial, unit)
 	}
-	return
+	return b, parseErr, errPos
+}
+
+// appendPos extends pos to n elements with p.
+func appendPos(pos *[]int, n, p int) {
+	for len(*pos) < n {
+		*pos = append(*pos, p)
+	}
+}
+
+// invisibleChars are characters that are easy to paste into a tag by accident
+// and impossible to see in code review.
+var invisibleChars = [...]string{
+	"\uFEFF", // byte order mark
+	"\u200B", // zero-width space
+	"\u200C", // zero-width non-joiner
+	"\u200D", // zero-width joiner
+	"\u2060", // word joiner
+	"\u00A0", // non-breaking space
+}
+
+// invisibleCharLen returns the length of the invisible character s starts
+// with, or 0 if it does not start with one.
+func invisibleCharLen(s string) int {
+	if s == "" || s[0] < 0x80 {
+		return 0
+	}
+	for _, ic := range invisibleChars {
+		if strings.HasPrefix(s, ic) {
+			return len(ic)
+		}
+	}
+	return 0
+}
+
+// RemoveInvisible returns the tag with all invisible characters (byte order
+// mark, zero-width spaces and joiners, non-breaking space) removed from its
+// keys, as found by ParseFunc. Names and values are left intact. It is the
+// suggested fix of CodeInvisibleChar errors and warnings.
+func RemoveInvisible(tag string) string {
+	return nameNone.RemoveInvisible(tag)
+}
+
+// RemoveInvisible is like the package-level RemoveInvisible, but finds the
+// keys and the name according to the configuration. Multiline tags are
+// scanned as is, without Dedent.
+func (conf Configuration) RemoveInvisible(tag string) string {
+	conf.Multiline, conf.MaxOptions, conf.MaxNestingDepth = false, 0, 0
+	conf.StrictInvisibleChars, conf.warnings = false, nil
+	s := scanner{tag: tag, conf: conf}
+	var b strings.Builder
+	last := 0
+	s.scan(func(it item) {
+		if it.isName {
+			return
+		}
+		for i := it.keyStart; i < it.keyEnd; i++ {
+			if l := invisibleCharLen(tag[i:it.keyEnd]); l > 0 {
+				b.WriteString(tag[last:i])
+				i += l - 1
+				last = i + 1
+			}
+		}
+	})
+	if last == 0 {
+		return tag
+	}
+	b.WriteString(tag[last:])
+	return b.String()
+}
+
+// hasInvisible reports whether s contains an invisible character.
+func hasInvisible(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if invisibleCharLen(s[i:]) > 0 {
+			return true
+		}
+	}
+	return false
 }
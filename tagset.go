@@ -0,0 +1,108 @@
+package tagparser
+
+import (
+	"fmt"
+	"strconv"
+)
+
+// TagSet is a struct tag parsed for rewriting by StructTagParser.ParseSet. It
+// keeps the results of the registered namespaces along with their positions,
+// so that rewrites like PropagateOption replace just the value of one
+// namespace and keep the rest of the struct tag byte for byte.
+type TagSet struct {
+	parser  *StructTagParser
+	tag     string
+	results map[string]Result
+	spans   map[string][2]int // of the quoted values, by namespace
+}
+
+// ParseSet is like Parse, but returns a TagSet. The TagSet is returned even
+// if there are errors, with the best guess results, like Parse does.
+func (p *StructTagParser) ParseSet(structTag string) (*TagSet, error) {
+	ts := &TagSet{parser: p}
+	return ts, ts.reset(structTag)
+}
+
+func (ts *TagSet) reset(structTag string) error {
+	ts.tag, ts.spans = structTag, make(map[string][2]int)
+	var err error
+	ts.results, err = ts.parser.parse(structTag, ts.spans)
+	return err
+}
+
+// String returns the struct tag, including the changes made so far.
+func (ts *TagSet) String() string {
+	return ts.tag
+}
+
+// Result returns the result of the given namespace, and false if the
+// namespace is not registered or not present in the struct tag.
+func (ts *TagSet) Result(namespace string) (Result, bool) {
+	r, ok := ts.results[namespace]
+	return r, ok
+}
+
+// PropagateOption copies an option from one namespace of the struct tag to
+// another, e.g. to keep the db names in sync with the json ones. An empty key
+// copies the name. The option replaces the one with the same key in the
+// target namespace, or is appended to it; a target namespace not present in
+// the struct tag is appended to it.
+//
+// The value is re-escaped for the configuration of the target namespace,
+// which is rewritten like Format does and quoted as a Go string literal, so
+// its formatting is normalized, but its directives, negated flags and list
+// values are kept. The error, if any, means that the struct tag
+// has not been changed: the source has no such option or name, the target
+// namespace is not registered or has errors, or the value cannot be
+// represented there (see BuildOrdered).
+func PropagateOption(ts *TagSet, key, from, to string) error {
+	conf := ts.parser.confs[to]
+	if conf == nil {
+		return fmt.Errorf("namespace %q is not registered", to)
+	}
+	src := ts.results[from]
+	value, ok := src.Options[key]
+	if key == "" {
+		value, ok = src.Name, src.Name != ""
+	}
+	if !ok {
+		return fmt.Errorf("namespace %q has no option %q", from, key)
+	}
+
+	var name string
+	var items []buildItem
+	span, present := ts.spans[to]
+	if present {
+		var err error
+		name, items, err = conf.formatItems(ts.results[to].Tag)
+		if err != nil {
+			return err
+		}
+	}
+	if key == "" {
+		name = value
+	} else {
+		i := 0
+		for i < len(items) && (items[i].isDirective || items[i].Key != key) {
+			i++
+		}
+		if i == len(items) {
+			items = append(items, buildItem{})
+		}
+		items[i] = buildItem{Option: Option{key, value}}
+	}
+	built, err := conf.build(name, items)
+	if err != nil {
+		return err
+	}
+
+	lit := strconv.Quote(built)
+	tag := ts.tag
+	if present {
+		tag = tag[:span[0]] + lit + tag[span[1]:]
+	} else {
+		tag += " " + to + ":" + lit
+	}
+	ts.reset(tag) // errors in other namespaces have been there before
+	return nil
+}
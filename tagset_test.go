@@ -0,0 +1,65 @@
+package tagparser
+
+import "testing"
+
+func TestPropagateOption(t *testing.T) {
+	var p StructTagParser
+	p.Register("json", Configuration{NamePosition: NameFirst})
+	p.Register("db", Configuration{NamePosition: NameFirst, ItemSeparator: ';'})
+	p.Register("kv", Configuration{})
+	p.Register("sql", Configuration{NamePosition: NameFirst, DirectivePrefix: '#', ListValues: true, NegationPrefix: '!'})
+	var tests = []struct {
+		tag      string
+		key      string
+		from, to string
+		expected string
+		err      string
+	}{
+		{`json:"email,omitempty" db:"mail;size:10" xml:"x"`, "", "json", "db", `json:"email,omitempty" db:"email;size:10" xml:"x"`, ``},
+		{`json:"a;b\"c"`, "", "json", "db", `json:"a;b\"c" db:"'a;b\"c'"`, ``},
+		{`json:"a,size:10" db:"n;x;size:5;y"`, "size", "json", "db", `json:"a,size:10" db:"n;x;size:10;y"`, ``},
+		{`json:"a,size:'1,2'"`, "size", "json", "kv", `json:"a,size:'1,2'" kv:"size:'1,2'"`, ``},
+		{`kv:"size:10"`, "size", "kv", "json", `kv:"size:10" json:",size:10"`, ``},
+		{`json:"email" sql:"mail,#version:2,cols:[a,b],!null"`, "", "json", "sql", `json:"email" sql:"email,#version:2,cols:[a,b],!null"`, ``},
+		{`json:"a,null,version:3" sql:"mail,#version:2,cols:[a,b],!null"`, "version", "json", "sql", `json:"a,null,version:3" sql:"mail,#version:2,cols:[a,b],!null,version:3"`, ``},
+		{`json:"a,null:x" sql:"mail,#version:2,cols:[a,b],!null"`, "null", "json", "sql", `json:"a,null:x" sql:"mail,#version:2,cols:[a,b],null:x"`, ``},
+		{`json:"a,cols:b" sql:"mail,cols:[a,b]"`, "cols", "json", "sql", `json:"a,cols:b" sql:"mail,cols:b"`, ``},
+		{`kv:"size:10"`, "size", "kv", "xml", `kv:"size:10"`, `namespace "xml" is not registered`},
+		{`json:"a"`, "size", "json", "db", `json:"a"`, `namespace "json" has no option "size"`},
+		{`json:",size:1"`, "", "json", "db", `json:",size:1"`, `namespace "json" has no option ""`},
+		{`json:"a" db:"'b"`, "", "json", "db", `json:"a" db:"'b"`, `unterminated quote (at 1)`},
+		{`json:"a" kv:"x"`, "", "json", "kv", `json:"a" kv:"x"`, `name "a": cannot be represented in the tag without NamePosition`},
+	}
+	for _, test := range tests {
+		ts, _ := p.ParseSet(test.tag)
+		err := PropagateOption(ts, test.key, test.from, test.to)
+		if actual := ts.String(); actual != test.expected {
+			t.Errorf("** PropagateOption(%s, %q, %s, %s) = %s, wanted %s", test.tag, test.key, test.from, test.to, actual, test.expected)
+		}
+		if (err == nil) != (test.err == "") || err != nil && err.Error() != test.err {
+			t.Errorf("** PropagateOption(%s, %q, %s, %s) error %v, wanted %s", test.tag, test.key, test.from, test.to, err, test.err)
+		}
+	}
+}
+
+func TestStructTagParser_ParseSet(t *testing.T) {
+	var p StructTagParser
+	p.Register("json", Configuration{NamePosition: NameFirst})
+	p.Register("db", Configuration{NamePosition: NameFirst})
+	ts, err := p.ParseSet(`json:"a,:b" db:"c"`)
+	if expErr := `json: empty key (at 9)`; err == nil || err.Error() != expErr {
+		t.Errorf("** ParseSet error %v, wanted %s", err, expErr)
+	}
+	if r, ok := ts.Result("db"); !ok || r.Name != "c" {
+		t.Errorf("** Result(db) = %+v, %v", r, ok)
+	}
+	if _, ok := ts.Result("xml"); ok {
+		t.Errorf("** Result(xml) found")
+	}
+	if err := PropagateOption(ts, "", "json", "db"); err != nil || ts.String() != `json:"a,:b" db:"a"` {
+		t.Errorf("** PropagateOption = %s, %v", ts.String(), err)
+	}
+	if err := PropagateOption(ts, "", "db", "json"); err == nil || err.Error() != `empty key (at 3)` {
+		t.Errorf("** PropagateOption error %v", err)
+	}
+}
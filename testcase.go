@@ -0,0 +1,208 @@
+package tagparser
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// errorSource is what an error has been produced from, so that TestCase can
+// reproduce it.
+type errorSource struct {
+	// tag is the tag that has been parsed. It differs from Error.Tag for
+	// the errors of StructTagParser, which refer to the whole struct tag.
+	tag  string
+	conf Configuration
+}
+
+// attachSource records the tag and the configuration the errors in err have
+// been produced from, unless they already know their source.
+func attachSource(err error, tag string, conf *Configuration) {
+	src := &errorSource{tag: tag, conf: *conf}
+	src.conf.warnings = nil
+	switch err := err.(type) {
+	case *Error:
+		if err.src == nil {
+			err.src = src
+		}
+	case *ErrorList:
+		for _, e := range err.Errors {
+			if e.src == nil {
+				e.src = src
+			}
+		}
+	}
+}
+
+// TestCase returns a table entry for this package's test suite that
+// reproduces the error, ready to be pasted into a bug report or pull request.
+// The entry captures the current behavior of the parser on the tag: name,
+// options and error message.
+//
+// Errors returned by the parse funcs remember their tag and configuration.
+// With the configuration of ParseName, the entry is for TestParseWithName;
+// otherwise, it is for TestConfiguration_Parse and spells out the
+// configuration. KeyValidator, ExpandValue and KeyNormalizer cannot be
+// spelled out and are left nil with a comment, to be filled in by hand.
+// Errors of StructTagParser reproduce the tag of the namespace. Other errors,
+// like the ones created by callers, are reproduced with ParseName on
+// Error.Tag.
+func (e *Error) TestCase() string {
+	tag, conf := e.Tag, nameFirst
+	if e.src != nil {
+		tag, conf = e.src.tag, e.src.conf
+	}
+	confLit := conf.goLiteral()
+	name, opts, err := conf.Parse(tag)
+	var b strings.Builder
+	b.WriteString("{`regression`, ")
+	if confLit != nameFirst.goLiteral() {
+		b.WriteString(confLit)
+		b.WriteString(", ")
+	}
+	b.WriteString(goLiteral(tag))
+	b.WriteString(", ")
+	b.WriteString(strconv.Quote(name))
+	b.WriteString(", ")
+	if opts == nil {
+		b.WriteString("nil")
+	} else {
+		b.WriteString(goMap(opts))
+	}
+	b.WriteString(", ")
+	if err != nil {
+		b.WriteString(goLiteral(err.Error()))
+	} else {
+		b.WriteString("``")
+	}
+	b.WriteString("},")
+	return b.String()
+}
+
+// goLiteral returns the configuration as a composite literal that lists its
+// non-zero fields, like the ones in the test tables.
+func (conf *Configuration) goLiteral() string {
+	var fields []string
+	add := func(name, value string) {
+		fields = append(fields, name+": "+value)
+	}
+	str := func(name, s string) {
+		if s != "" {
+			add(name, goLiteral(s))
+		}
+	}
+	char := func(name string, c byte) {
+		if c != 0 {
+			add(name, strconv.QuoteRuneToASCII(rune(c)))
+		}
+	}
+	flag := func(name string, v bool) {
+		if v {
+			add(name, "true")
+		}
+	}
+	num := func(name string, n int) {
+		if n != 0 {
+			add(name, strconv.Itoa(n))
+		}
+	}
+	keys := func(name string, m map[string]string) {
+		if m != nil {
+			add(name, goMap(m))
+		}
+	}
+	fn := func(name string, set bool) {
+		if set {
+			add(name, "nil /* set in the original */")
+		}
+	}
+
+	switch conf.NamePosition {
+	case NameFirst:
+		add("NamePosition", "NameFirst")
+	case NameLast:
+		add("NamePosition", "NameLast")
+	}
+	str("ContinuationKey", conf.ContinuationKey)
+	str("ContinuationJoiner", conf.ContinuationJoiner)
+	flag("GreedyLastValue", conf.GreedyLastValue)
+	char("NameModifierSeparator", conf.NameModifierSeparator)
+	char("NameVariantSeparator", conf.NameVariantSeparator)
+	if conf.QuotedNames == QuotedNamesError {
+		add("QuotedNames", "QuotedNamesError")
+	}
+	str("QuoteChars", conf.QuoteChars)
+	str("EscapableChars", conf.EscapableChars)
+	flag("AllowStandardEscapes", conf.AllowStandardEscapes)
+	flag("ListValues", conf.ListValues)
+	char("NegationPrefix", conf.NegationPrefix)
+	char("DirectivePrefix", conf.DirectivePrefix)
+	flag("Multiline", conf.Multiline)
+	str("WildcardKey", conf.WildcardKey)
+	str("StripKeyPrefix", conf.StripKeyPrefix)
+	char("KeyValueSeparator", conf.KeyValueSeparator)
+	char("ItemSeparator", conf.ItemSeparator)
+	flag("UnicodeWhitespace", conf.UnicodeWhitespace)
+	fn("KeyValidator", conf.KeyValidator != nil)
+	flag("PercentDecodeValues", conf.PercentDecodeValues)
+	fn("ExpandValue", conf.ExpandValue != nil)
+	flag("FoldKeys", conf.FoldKeys)
+	fn("KeyNormalizer", conf.KeyNormalizer != nil)
+	switch conf.DuplicateKeys {
+	case DuplicateKeysFirstWins:
+		add("DuplicateKeys", "DuplicateKeysFirstWins")
+	case DuplicateKeysLastWins:
+		add("DuplicateKeys", "DuplicateKeysLastWins")
+	case DuplicateKeysCollect:
+		add("DuplicateKeys", "DuplicateKeysCollect")
+	}
+	if conf.KnownKeys != nil {
+		quoted := make([]string, len(conf.KnownKeys))
+		for i, k := range conf.KnownKeys {
+			quoted[i] = strconv.Quote(k)
+		}
+		add("KnownKeys", "[]string{"+strings.Join(quoted, ", ")+"}")
+	}
+	keys("KeyAliases", conf.KeyAliases)
+	keys("DeprecatedKeys", conf.DeprecatedKeys)
+	flag("WarnQuotedEmptyValues", conf.WarnQuotedEmptyValues)
+	flag("StrictInvisibleChars", conf.StrictInvisibleChars)
+	str("SkipMarker", conf.SkipMarker)
+	flag("CollectAllErrors", conf.CollectAllErrors)
+	num("MaxLength", conf.MaxLength)
+	num("MaxOptions", conf.MaxOptions)
+	num("MaxNestingDepth", conf.MaxNestingDepth)
+	return "Configuration{" + strings.Join(fields, ", ") + "}"
+}
+
+// goMap returns m as an M literal with sorted keys.
+func goMap(m map[string]string) string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	var b strings.Builder
+	b.WriteString("M{")
+	for i, k := range keys {
+		if i > 0 {
+			b.WriteString(", ")
+		}
+		b.WriteString(strconv.Quote(k))
+		b.WriteString(": ")
+		b.WriteString(strconv.Quote(m[k]))
+	}
+	b.WriteString("}")
+	return b.String()
+}
+
+// goLiteral returns s as a raw string literal if possible, like the tags in
+// the test tables, or as an interpreted string literal otherwise.
+func goLiteral(s string) string {
+	for _, c := range []byte(s) {
+		if c == '`' || c < ' ' || c >= 0x7f {
+			return strconv.Quote(s)
+		}
+	}
+	return "`" + s + "`"
+}
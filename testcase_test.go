@@ -0,0 +1,121 @@
+package tagparser
+
+import (
+	"errors"
+	"go/parser"
+	"strings"
+	"testing"
+)
+
+func TestError_TestCase(t *testing.T) {
+	var tests = []struct {
+		tag      string
+		expected string
+	}{
+		{`alfa,bravo:'charlie`, "{`regression`, `alfa,bravo:'charlie`, \"alfa\", M{\"bravo\": \"charlie\"}, `unterminated quote (at 12)`},"},
+		{`alfa,:bravo`, "{`regression`, `alfa,:bravo`, \"alfa\", nil, `empty key (at 6)`},"},
+		{"a\\lfa,x:`y`,b", "{`regression`, \"a\\\\lfa,x:`y`,b\", \"alfa\", M{\"b\": \"\", \"x\": \"`y`\"}, `invalid escape character (at 3)`},"},
+	}
+	for _, test := range tests {
+		_, _, err := ParseName(test.tag)
+		actual := err.(*Error).TestCase()
+		if actual != test.expected {
+			t.Errorf("** TestCase(%q) = %s, wanted %s", test.tag, actual, test.expected)
+		}
+	}
+}
+
+func TestError_TestCase_no_error(t *testing.T) {
+	// The error may come from a different configuration or a callback, so the
+	// tag might parse fine with ParseName.
+	err := &Error{Tag: `alfa,bravo`, Msg: "custom"}
+	const expected = "{`regression`, `alfa,bravo`, \"alfa\", M{\"bravo\": \"\"}, ``},"
+	if actual := err.TestCase(); actual != expected {
+		t.Errorf("** TestCase = %s, wanted %s", actual, expected)
+	}
+}
+
+func TestError_TestCase_configuration(t *testing.T) {
+	var tests = []struct {
+		conf     Configuration
+		tag      string
+		expected string
+	}{
+		{Configuration{NamePosition: NameLast}, `a,:b,n`, "{`regression`, Configuration{NamePosition: NameLast}, `a,:b,n`, \"n\", M{\"a\": \"\"}, `empty key (at 3)`},"},
+		{Configuration{QuoteChars: `"`, MaxOptions: 1}, `a:"x,y",b`, "{`regression`, Configuration{QuoteChars: `\"`, MaxOptions: 1}, `a:\"x,y\",b`, \"\", M{\"a\": \"x,y\"}, `more than 1 options: limit exceeded (at 9)`},"},
+		{Configuration{Multiline: true, CollectAllErrors: true}, "a,\n\t:b,\n\t'c", "{`regression`, Configuration{Multiline: true, CollectAllErrors: true}, \"a,\\n\\t:b,\\n\\t'c\", \"\", M{\"a\": \"\", \"c\": \"\"}, `empty key (at 3); unterminated quote (at 8)`},"},
+	}
+	for _, test := range tests {
+		_, _, err := test.conf.Parse(test.tag)
+		var e *Error
+		if !errors.As(err, &e) {
+			t.Fatalf("** Parse(%q) error %v", test.tag, err)
+		}
+		if actual := e.TestCase(); actual != test.expected {
+			t.Errorf("** TestCase(%q) = %s, wanted %s", test.tag, actual, test.expected)
+		}
+	}
+}
+
+func TestError_TestCase_parses(t *testing.T) {
+	confs := []Configuration{
+		{KeyValidator: ValidateIdentifier},
+		{KeyValidator: ValidateIdentifier, FoldKeys: true},
+		{NamePosition: NameFirst, ExpandValue: func(key, value string) (string, error) { return value, nil }, Multiline: true},
+	}
+	for _, conf := range confs {
+		_, _, err := conf.Parse("a,'b")
+		tc := err.(*Error).TestCase()
+		if _, err := parser.ParseExpr("[]T{" + tc + "}"); err != nil {
+			t.Errorf("** TestCase = %s, not valid Go: %v", tc, err)
+		}
+		if !strings.Contains(tc, "nil /* set in the original */") {
+			t.Errorf("** TestCase = %s, wanted a placeholder", tc)
+		}
+	}
+}
+
+func TestError_TestCase_structTag(t *testing.T) {
+	var p StructTagParser
+	p.Register("db", Configuration{ItemSeparator: ';'})
+	_, err := p.Parse(`json:"x" db:"a;:b"`)
+	const expected = "{`regression`, Configuration{ItemSeparator: ';'}, `a;:b`, \"\", M{\"a\": \"\"}, `empty key (at 3)`},"
+	if actual := err.(*Error).TestCase(); actual != expected {
+		t.Errorf("** TestCase = %s, wanted %s", actual, expected)
+	}
+}
+
+func TestConfiguration_goLiteral(t *testing.T) {
+	conf := Configuration{
+		NamePosition: NameFirst, ContinuationKey: "+", ContinuationJoiner: " ", GreedyLastValue: true,
+		NameModifierSeparator: '!', NameVariantSeparator: '|', QuotedNames: QuotedNamesError,
+		QuoteChars: `'"`, EscapableChars: "n", AllowStandardEscapes: true, ListValues: true,
+		NegationPrefix: '-', DirectivePrefix: '#', Multiline: true, WildcardKey: "*", StripKeyPrefix: "x-",
+		KeyValueSeparator: '=', ItemSeparator: ';', UnicodeWhitespace: true, KeyValidator: ValidateIdentifier,
+		PercentDecodeValues: true, ExpandValue: func(key, value string) (string, error) { return value, nil },
+		FoldKeys: true, DuplicateKeys: DuplicateKeysFirstWins, KnownKeys: []string{"a", "b"},
+		KeyAliases: M{"b": "a"}, DeprecatedKeys: M{"b": "use a"}, SkipMarker: "-", CollectAllErrors: true,
+		MaxLength: 10, MaxOptions: 2, MaxNestingDepth: 3,
+	}
+	const expected = "Configuration{NamePosition: NameFirst, ContinuationKey: `+`, ContinuationJoiner: ` `, GreedyLastValue: true, " +
+		"NameModifierSeparator: '!', NameVariantSeparator: '|', QuotedNames: QuotedNamesError, " +
+		"QuoteChars: `'\"`, EscapableChars: `n`, AllowStandardEscapes: true, ListValues: true, " +
+		"NegationPrefix: '-', DirectivePrefix: '#', Multiline: true, WildcardKey: `*`, StripKeyPrefix: `x-`, " +
+		"KeyValueSeparator: '=', ItemSeparator: ';', UnicodeWhitespace: true, KeyValidator: nil /* set in the original */, " +
+		"PercentDecodeValues: true, ExpandValue: nil /* set in the original */, " +
+		"FoldKeys: true, DuplicateKeys: DuplicateKeysFirstWins, KnownKeys: []string{\"a\", \"b\"}, " +
+		"KeyAliases: M{\"b\": \"a\"}, DeprecatedKeys: M{\"b\": \"use a\"}, SkipMarker: `-`, CollectAllErrors: true, " +
+		"MaxLength: 10, MaxOptions: 2, MaxNestingDepth: 3}"
+	if actual := conf.goLiteral(); actual != expected {
+		t.Errorf("** goLiteral = %s, wanted %s", actual, expected)
+	}
+	if _, err := parser.ParseExpr(expected); err != nil {
+		t.Errorf("** goLiteral is not valid Go: %v", err)
+	}
+	for _, policy := range []DuplicateKeyPolicy{DuplicateKeysLastWins, DuplicateKeysCollect} {
+		conf := Configuration{DuplicateKeys: policy}
+		if actual := conf.goLiteral(); !strings.Contains(actual, "DuplicateKeys: DuplicateKeys") {
+			t.Errorf("** goLiteral = %s", actual)
+		}
+	}
+}
@@ -0,0 +1,177 @@
+package tagparser
+
+import "strconv"
+
+// Unnest parses a structured option value into nested maps and lists, for
+// gorm-like options such as `index:'{name:idx_a, priority:2, cols:[a, b]}'`.
+// Braces produce map[string]any, brackets produce []any, and anything else is
+// returned as a string, with the same quoting, escaping and trimming rules as
+// the tag itself. A value that does not start with a bracket is returned as a
+// string in its entirety.
+//
+// Like with SplitValue, quotes and backslashes meant for Unnest have to be
+// escaped in the tag, since the tag's own escapes are processed before the
+// value is returned.
+//
+// Duplicate keys are handled according to DuplicateKeys. The error, if
+// present, is *Error positioned within the value; like the parse funcs,
+// Unnest returns its best guess even in case of errors.
+func (conf Configuration) Unnest(value string) (any, error) {
+	u := unnester{s: scanner{tag: value, conf: conf}}
+	u.kvSep, u.itemSep = conf.separators()
+	result := u.node(0)
+	if u.i < len(value) {
+		u.s.fail(u.i, CodeInvalidBracket)
+	}
+	return result, u.s.result()
+}
+
+// Unnest parses a structured value like Configuration.Unnest does, using the
+// default separators.
+func Unnest(value string) (any, error) {
+	return nameNone.Unnest(value)
+}
+
+type unnester struct {
+	s              scanner
+	i              int
+	kvSep, itemSep byte
+}
+
+func (u *unnester) skipSpace() {
+	for u.i < len(u.s.tag) && asciiSpace[u.s.tag[u.i]] != 0 {
+		u.i++
+	}
+}
+
+func (u *unnester) at(c byte) bool {
+	return u.i < len(u.s.tag) && u.s.tag[u.i] == c
+}
+
+// node parses a string, map or list at u.i, which is left at the first
+// non-whitespace byte after the node.
+func (u *unnester) node(depth int) any {
+	start := u.i
+	u.skipSpace()
+	if max := u.s.conf.MaxNestingDepth; max > 0 && depth >= max && (u.at('{') || u.at('[')) {
+		u.s.failLimit(u.i, "brackets nested deeper than "+strconv.Itoa(max))
+		u.i = len(u.s.tag)
+		return nil
+	}
+	switch {
+	case u.at('{'):
+		return u.object(depth)
+	case u.at('['):
+		return u.list(depth)
+	}
+	u.i = start
+	return u.s.unquote(start, u.text(depth, false))
+}
+
+// text skips a string, stopping at separators and closing brackets when
+// nested, and returns its end.
+func (u *unnester) text(depth int, isKey bool) int {
+	tag := u.s.tag
+	q := u.s.conf.quotes()
+	quoteStart := -1
+	for ; u.i < len(tag); u.i++ {
+		c := tag[u.i]
+		switch {
+		case c == '\\':
+			u.i++
+			u.s.checkEscape(u.i, quoteStart >= 0)
+		case quoteStart >= 0:
+			if c == tag[quoteStart] {
+				quoteStart = -1
+			}
+		case q.has(c):
+			quoteStart = u.i
+		case depth == 0:
+		case c == u.itemSep || c == '}' || c == ']' || isKey && c == u.kvSep:
+			return u.i
+		}
+	}
+	if quoteStart >= 0 {
+		u.s.fail(quoteStart, CodeUnterminatedQuote)
+	}
+	u.i = len(tag)
+	return u.i
+}
+
+// object parses a braced map at u.i.
+func (u *unnester) object(depth int) any {
+	m := make(map[string]any)
+	u.items(depth, func() {
+		keyStart := u.i
+		key := u.s.unquote(keyStart, u.text(depth+1, true))
+		var value any = ""
+		if u.at(u.kvSep) {
+			u.i++
+			value = u.node(depth + 1)
+		}
+		if key == "" {
+			u.s.fail(keyStart, CodeEmptyKey)
+		} else if _, ok := m[key]; ok {
+			replace, err := u.s.conf.DuplicateKeys.duplicate()
+			if replace {
+				m[key] = value
+			}
+			if err != nil {
+				u.s.failCallback(keyStart, key, err)
+			}
+		} else {
+			m[key] = value
+		}
+	})
+	return m
+}
+
+// list parses a bracketed list at u.i.
+func (u *unnester) list(depth int) any {
+	l := make([]any, 0)
+	u.items(depth, func() {
+		l = append(l, u.node(depth+1))
+	})
+	return l
+}
+
+// items calls item for every item between the opening bracket at u.i and the
+// matching closing one.
+func (u *unnester) items(depth int, item func()) {
+	tag := u.s.tag
+	open := u.i
+	u.i++
+	for {
+		u.skipSpace()
+		if u.i >= len(tag) {
+			u.s.fail(open, CodeUnterminatedBracket)
+			return
+		}
+		if c := tag[u.i]; c == '}' || c == ']' {
+			if c != closingBracket(tag[open]) {
+				u.s.fail(u.i, CodeInvalidBracket)
+			}
+			u.i++
+			u.skipSpace()
+			return
+		}
+		item()
+		if u.s.limited {
+			return
+		}
+		if u.at(u.itemSep) {
+			u.i++
+		} else if u.i < len(tag) && !u.at('}') && !u.at(']') {
+			// Text after a nested bracket; skip to the next item.
+			u.s.fail(u.i, CodeInvalidBracket)
+			u.text(depth+1, false)
+		}
+	}
+}
+
+func closingBracket(open byte) byte {
+	if open == '{' {
+		return '}'
+	}
+	return ']'
+}
@@ -0,0 +1,62 @@
+package tagparser
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestUnnest(t *testing.T) {
+	var tests = []struct {
+		value    string
+		expected any
+		error    string
+	}{
+		{``, "", ``},
+		{`idx_a`, "idx_a", ``},
+		{` a, b:c `, "a, b:c", ``},
+		{`{}`, map[string]any{}, ``},
+		{`[]`, []any{}, ``},
+		{`{name:idx_a,priority:2}`, map[string]any{"name": "idx_a", "priority": "2"}, ``},
+		{` { name : idx_a , unique , cols : [ a , 'b, c' ] } `, map[string]any{"name": "idx_a", "unique": "", "cols": []any{"a", "b, c"}}, ``},
+		{`[a,[b,{c:d}],]`, []any{"a", []any{"b", map[string]any{"c": "d"}}}, ``},
+		{`[a,,b]`, []any{"a", "", "b"}, ``},
+		{`{a:b:c,\{d:'}'}`, map[string]any{"a": "b:c", "{d": "}"}, ``},
+		{`{a:1,a:2}`, map[string]any{"a": "1"}, `a: duplicate option key (at 6)`},
+		{`{:1,b}`, map[string]any{"b": ""}, `empty key (at 2)`},
+		{`{a:1`, map[string]any{"a": "1"}, `unterminated bracket (at 1)`},
+		{`{a:[1}`, map[string]any{"a": []any{"1"}}, `invalid bracket (at 6)`},
+		{`{a:[1]x,b}`, map[string]any{"a": []any{"1"}, "b": ""}, `invalid bracket (at 7)`},
+		{`{a:1} x`, map[string]any{"a": "1"}, `invalid bracket (at 7)`},
+		{`{a:'1}`, map[string]any{"a": "1}"}, `unterminated quote (at 4)`},
+		{`[\q]`, []any{"q"}, `invalid escape character (at 3)`},
+	}
+	for _, test := range tests {
+		actual, err := Unnest(test.value)
+		if !reflect.DeepEqual(actual, test.expected) {
+			t.Errorf("** Unnest(%q) = %#v, wanted %#v", test.value, actual, test.expected)
+		}
+		if err != nil {
+			if ae := err.Error(); ae != test.error {
+				t.Errorf("** Unnest(%q) error %q, wanted %q", test.value, ae, test.error)
+			}
+		} else if test.error != "" {
+			t.Errorf("** Unnest(%q) no error, wanted %q", test.value, test.error)
+		}
+	}
+}
+
+func TestConfiguration_Unnest(t *testing.T) {
+	conf := Configuration{KeyValueSeparator: '=', ItemSeparator: ';', DuplicateKeys: DuplicateKeysLastWins}
+	actual, err := conf.Unnest(`{a=1;a=2;b=[x;y]}`)
+	if expected := map[string]any{"a": "2", "b": []any{"x", "y"}}; err != nil || !reflect.DeepEqual(actual, expected) {
+		t.Errorf("** Unnest = %#v, %v, wanted %#v", actual, err, expected)
+	}
+}
+
+func TestConfiguration_Unnest_quotes(t *testing.T) {
+	conf := Configuration{QuoteChars: `'"`}
+	actual, err := conf.Unnest(`{"a:b":[x,"y,z"],c:'"'}`)
+	if expected := map[string]any{"a:b": []any{"x", "y,z"}, "c": `"`}; err != nil || !reflect.DeepEqual(actual, expected) {
+		t.Errorf("** Unnest = %#v, %v, wanted %#v", actual, err, expected)
+	}
+}
@@ -0,0 +1,111 @@
+package tagparser
+
+import "strings"
+
+// SplitVersion splits a version-gated key like `key@v2` into the base key and
+// the minimum version. Keys without a valid `@v<number>` suffix are returned
+// as is with version 0 and ok == false, so '@' can still be used freely in
+// ordinary keys.
+func SplitVersion(key string) (base string, version int, ok bool) {
+	i := strings.LastIndex(key, "@v")
+	if i < 0 || i+2 == len(key) {
+		return key, 0, false
+	}
+	for _, c := range []byte(key[i+2:]) {
+		if c < '0' || c > '9' {
+			return key, 0, false
+		}
+		version = version*10 + int(c-'0')
+	}
+	return key[:i], version, true
+}
+
+// VersionedOption is an option with the version gate split off its key.
+type VersionedOption struct {
+	// Option has the base key, without the gate.
+	Option
+	// Version is the minimum version the option applies to, or 0 if the
+	// key has no gate.
+	Version int
+}
+
+// SplitVersions splits the version gates off the keys of options in tag
+// order, as returned by ParseOrdered, with SplitVersion.
+func SplitVersions(opts []Option) []VersionedOption {
+	if opts == nil {
+		return nil
+	}
+	result := make([]VersionedOption, len(opts))
+	for i, o := range opts {
+		base, gate, _ := SplitVersion(o.Key)
+		result[i] = VersionedOption{Option{base, o.Value}, gate}
+	}
+	return result
+}
+
+// FilterVersion selects the options that apply to the given framework version
+// and strips version gates from their keys. An option `key@vN:value` applies
+// from version N onwards; options without a gate always apply. When several
+// variants of a key apply, the one with the highest gate wins, so
+//
+//	size:10, size@v2:20, size@v3:30
+//
+// yields size:20 for version 2. Version gates are only interpreted by
+// FilterVersion, so parsing itself is unaffected (e.g. by emails in values).
+//
+// Variants with equal gates, like `size` and `size@v0`, or `size@v2` and
+// `size@v02`, are resolved by key: the shortest one wins, then the smallest,
+// so that the result does not depend on map order. FilterVersionOrdered
+// picks the first one in the tag instead.
+func FilterVersion(opts map[string]string, v int) map[string]string {
+	var result map[string]string
+	winners := make(map[string]string, len(opts)) // by base key
+	gates := make(map[string]int, len(opts))
+	for k, value := range opts {
+		base, gate, _ := SplitVersion(k)
+		if gate > v {
+			continue
+		}
+		if result == nil {
+			result = make(map[string]string, len(opts))
+		}
+		prev, ok := gates[base]
+		if ok && gate == prev {
+			w := winners[base]
+			if len(k) > len(w) || len(k) == len(w) && k > w {
+				continue
+			}
+		}
+		if !ok || gate >= prev {
+			gates[base] = gate
+			winners[base] = k
+			result[base] = value
+		}
+	}
+	return result
+}
+
+// FilterVersionOrdered is like FilterVersion, but for options in tag order,
+// as returned by ParseOrdered. Each selected option is returned at the
+// position of the first variant of its key, and among variants with equal
+// gates, the first one wins.
+func FilterVersionOrdered(opts []Option, v int) []Option {
+	var result []Option
+	indices := make(map[string]int, len(opts)) // into result, by base key
+	gates := make(map[string]int, len(opts))
+	for _, o := range SplitVersions(opts) {
+		if o.Version > v {
+			continue
+		}
+		i, ok := indices[o.Key]
+		if !ok {
+			indices[o.Key] = len(result)
+			gates[o.Key] = o.Version
+			result = append(result, o.Option)
+		} else if o.Version > gates[o.Key] {
+			gates[o.Key] = o.Version
+			result[i] = o.Option
+		}
+	}
+	return result
+}
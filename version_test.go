@@ -0,0 +1,102 @@
+package tagparser
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSplitVersion(t *testing.T) {
+	var tests = []struct {
+		key     string
+		base    string
+		version int
+		ok      bool
+	}{
+		{`size`, `size`, 0, false},
+		{`size@v2`, `size`, 2, true},
+		{`size@v12`, `size`, 12, true},
+		{`size@v`, `size@v`, 0, false},
+		{`size@v2x`, `size@v2x`, 0, false},
+		{`user@example.com`, `user@example.com`, 0, false},
+		{`a@v1@v3`, `a@v1`, 3, true},
+	}
+	for _, test := range tests {
+		base, version, ok := SplitVersion(test.key)
+		if base != test.base || version != test.version || ok != test.ok {
+			t.Errorf("** SplitVersion(%q) = %q, %d, %v, wanted %q, %d, %v", test.key, base, version, ok, test.base, test.version, test.ok)
+		}
+	}
+}
+
+func TestFilterVersion(t *testing.T) {
+	opts := M{"size": "10", "size@v2": "20", "size@v3": "30", "new@v2": "", "mail": "a@v1"}
+	var tests = []struct {
+		version  int
+		expected map[string]string
+	}{
+		{0, M{"size": "10", "mail": "a@v1"}},
+		{1, M{"size": "10", "mail": "a@v1"}},
+		{2, M{"size": "20", "new": "", "mail": "a@v1"}},
+		{5, M{"size": "30", "new": "", "mail": "a@v1"}},
+	}
+	for _, test := range tests {
+		actual := FilterVersion(opts, test.version)
+		if !reflect.DeepEqual(actual, test.expected) {
+			t.Errorf("** FilterVersion(%d) = %q, wanted %q", test.version, actual, test.expected)
+		}
+	}
+	if actual := FilterVersion(M{"x@v2": ""}, 1); actual != nil {
+		t.Errorf("** FilterVersion = %q, wanted nil", actual)
+	}
+}
+
+func TestFilterVersion_ties(t *testing.T) {
+	var tests = []struct {
+		opts     map[string]string
+		version  int
+		expected map[string]string
+	}{
+		{M{"size": "a", "size@v0": "b"}, 0, M{"size": "a"}},
+		{M{"size@v2": "a", "size@v02": "b"}, 2, M{"size": "a"}},
+		{M{"size@v02": "a", "size@v20": "b", "size@v2": "c"}, 2, M{"size": "c"}},
+		{M{"size@v01": "a", "size@v10": "b", "size@v1": "c", "size@v001": "d"}, 1, M{"size": "c"}},
+	}
+	for _, test := range tests {
+		for i := 0; i < 20; i++ {
+			actual := FilterVersion(test.opts, test.version)
+			if !reflect.DeepEqual(actual, test.expected) {
+				t.Errorf("** FilterVersion(%q, %d) = %q, wanted %q", test.opts, test.version, actual, test.expected)
+				break
+			}
+		}
+	}
+}
+
+func TestFilterVersionOrdered(t *testing.T) {
+	opts := []Option{{"size@v2", "20"}, {"mail", "a@v1"}, {"size", "10"}, {"size@v02", "02"}, {"size@v3", "30"}, {"new@v2", ""}}
+	var tests = []struct {
+		version  int
+		expected []Option
+	}{
+		{0, []Option{{"mail", "a@v1"}, {"size", "10"}}},
+		{2, []Option{{"size", "20"}, {"mail", "a@v1"}, {"new", ""}}},
+		{5, []Option{{"size", "30"}, {"mail", "a@v1"}, {"new", ""}}},
+	}
+	for _, test := range tests {
+		actual := FilterVersionOrdered(opts, test.version)
+		if !reflect.DeepEqual(actual, test.expected) {
+			t.Errorf("** FilterVersionOrdered(%d) = %q, wanted %q", test.version, actual, test.expected)
+		}
+	}
+	if actual := FilterVersionOrdered(nil, 1); actual != nil {
+		t.Errorf("** FilterVersionOrdered(nil) = %q, wanted nil", actual)
+	}
+}
+
+func TestSplitVersions(t *testing.T) {
+	actual := SplitVersions([]Option{{"size@v2", "20"}, {"mail", "a@v1"}, {"x@v", ""}})
+	expected := []VersionedOption{{Option{"size", "20"}, 2}, {Option{"mail", "a@v1"}, 0}, {Option{"x@v", ""}, 0}}
+	if !reflect.DeepEqual(actual, expected) {
+		t.Errorf("** SplitVersions = %v, wanted %v", actual, expected)
+	}
+}